@@ -0,0 +1,73 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds. Every error that should reach a client with a specific HTTP
+// status wraps exactly one of these; anything else is treated as internal.
+// ErrInternal marks failures known to be the server's own, such as missing
+// configuration, so they carry context in logs.
+var (
+	ErrValidation           = errors.New("validation failed")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrUpstream             = errors.New("upstream service failed")
+	ErrInternal             = errors.New("internal error")
+)
+
+// Error is a classified error carrying a client-safe detail message and,
+// optionally, the underlying cause. errors.Is matches both Kind and the cause.
+type Error struct {
+	Kind   error
+	Detail string
+	Err    error
+}
+
+// Error returns the detail followed by the cause, for logs
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+// Unwrap exposes the kind and cause so callers can match them with errors.Is
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// New creates a classified error of the given kind
+func New(kind error, detail string) *Error {
+	return &Error{Kind: kind, Detail: detail}
+}
+
+// Newf creates a classified error with a formatted detail message
+func Newf(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Wrap classifies err, keeping it as the cause behind a client-safe detail
+func Wrap(kind error, err error, detail string) *Error {
+	return &Error{Kind: kind, Detail: detail, Err: err}
+}
+
+// Detail returns the client-safe message of the innermost classified error in
+// err's chain, or "" if err carries none.
+func Detail(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Detail
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+// Package calendar writes iCalendar (RFC 5545) files, so guests can add the
+// wedding schedule to their calendar.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of the files written by Write
+const ContentType = "text/calendar; charset=utf-8; method=PUBLISH"
+
+// productID identifies this application as the producer of the calendar
+const productID = "-//rsvp-backend//Wedding Calendar//EN"
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+// Event is one calendar entry
+type Event struct {
+	// UID identifies the event across updates; calendars replace an event
+	// they already have when a file with the same UID is imported again
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+}
+
+// Write writes a calendar holding the events. Times are written in UTC, so
+// no time zone definitions are needed. stamp is the DTSTAMP of every event,
+// normally the time the file is generated.
+func Write(w io.Writer, stamp time.Time, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", productID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	for _, e := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(e.UID))
+		line("DTSTAMP", formatTime(stamp))
+		line("DTSTART", formatTime(e.Start))
+		if !e.End.IsZero() {
+			line("DTEND", formatTime(e.End))
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("STATUS", "CONFIRMED")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// formatTime renders a UTC date-time value
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes a content line, folding it into lines of at most
+// maxLineOctets octets without splitting a UTF-8 sequence. Continuation lines
+// start with a space, which counts towards their length.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,70 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	tz, _ := time.LoadLocation("Asia/Jakarta")
+	stamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	err := Write(&buf, stamp, []Event{{
+		UID:         "ceremony@axeldaphne.com",
+		Summary:     "Tea ceremony, then lunch; all welcome",
+		Description: "Dress code: batik\nParking at the back",
+		Location:    `Hotel Mulia\Ballroom`,
+		URL:         "https://axeldaphne.com/rsvp/ABCD2345",
+		Start:       time.Date(2026, 6, 20, 10, 0, 0, 0, tz),
+		End:         time.Date(2026, 6, 20, 12, 30, 0, 0, tz),
+	}})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + productID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:ceremony@axeldaphne.com",
+		"DTSTAMP:20260102T030405Z",
+		"DTSTART:20260620T030000Z",
+		"DTEND:20260620T053000Z",
+		`SUMMARY:Tea ceremony\, then lunch\; all welcome`,
+		`LOCATION:Hotel Mulia\\Ballroom`,
+		`DESCRIPTION:Dress code: batik\nParking at the back`,
+		"URL:https://axeldaphne.com/rsvp/ABCD2345",
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buf.String(); got != want {
+		t.Errorf("calendar =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	summary := strings.Repeat("婚礼", 40) // 3-byte runes, so a naive cut would split one
+	var buf bytes.Buffer
+	Write(&buf, time.Now(), []Event{{UID: "x", Summary: summary, Start: time.Now()}})
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets long", i, len(line))
+		}
+		if !strings.HasPrefix(line, " ") && i > 0 {
+			unfolded.WriteString("\r\n")
+		}
+		unfolded.WriteString(strings.TrimPrefix(line, " "))
+	}
+	if !strings.Contains(unfolded.String(), "\r\nSUMMARY:"+summary+"\r\n") {
+		t.Errorf("unfolded calendar lost the summary:\n%s", unfolded.String())
+	}
+}
@@ -1,59 +1,268 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/g4l1l10/rsvp-backend/config"
 	"github.com/g4l1l10/rsvp-backend/db"
+	"github.com/g4l1l10/rsvp-backend/events"
 	"github.com/g4l1l10/rsvp-backend/handlers"
-	"github.com/g4l1l10/rsvp-backend/middlewares"
+	"github.com/g4l1l10/rsvp-backend/health"
+	"github.com/g4l1l10/rsvp-backend/lifecycle"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/metrics"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/ratelimit"
 	"github.com/g4l1l10/rsvp-backend/repository"
 	"github.com/g4l1l10/rsvp-backend/routes"
 	"github.com/g4l1l10/rsvp-backend/service"
+	"github.com/g4l1l10/rsvp-backend/tokens"
+	"github.com/g4l1l10/rsvp-backend/utils"
+	"github.com/g4l1l10/rsvp-backend/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Load and validate all configuration up front so misconfiguration fails fast
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	// Structured JSON logging with credential and PII redaction
+	logger, err := logging.New(os.Stdout, cfg.Log.Level)
+	if err != nil {
+		fatal("invalid logging configuration", err)
+	}
+	logging.Setup(logger)
+	slog.Info("configuration loaded", slog.Any("config", cfg)) // secrets render as [REDACTED]
+
 	// Initialize database connection
-	db.InitDB()
-	defer db.GetDB().Close()
+	conn, err := db.Connect(context.Background(), cfg.Database)
+	if err != nil {
+		fatal("database unavailable", err)
+	}
+
+	// Bring the schema up to date before serving traffic
+	if err := db.Migrate(conn); err != nil {
+		fatal("database migration failed", err)
+	}
 
 	// Set up repository, service, and handler
-	guestRepo := repository.NewGuestRepository(db.GetDB())
-	guestService := service.NewGuestService(guestRepo)
-	guestHandler := handlers.NewGuestHandler(guestService)
+	guestRepo := repository.NewGuestRepository(conn)
+	guestRepo.QueryTimeout = cfg.Database.QueryTimeout
+	signer, err := newSigner(cfg.RSVPLinks)
+	if err != nil {
+		fatal("invalid RSVP link signing keys", err)
+	}
+	mailer := utils.NewMailer(cfg.SMTP, cfg.PublicURL)
+	guestService := service.NewGuestService(guestRepo, mailer, signer)
+	guestService.AcceptLegacyTokens = cfg.RSVPLinks.AcceptLegacyTokens
+	guestService.PublicURL = cfg.PublicURL
+	guestService.Wedding = cfg.Wedding
+	// Guest changes fan out to the admin dashboard's event streams
+	broker := events.NewBroker(cfg.Events.ReplayBuffer)
+	guestService.Events = broker
+	dispatcher := webhooks.NewDispatcher(guestRepo, cfg.Webhooks)
+	guestService.Webhooks = dispatcher
+	subEventService := service.NewSubEventService(guestRepo, guestService)
+	guestService.SubEvents = subEventService
+	tagService := service.NewTagService(guestRepo, guestService)
+	guestService.Tags = tagService
+	householdService := service.NewHouseholdService(guestRepo, guestService)
+	checkInService := service.NewCheckInService(guestRepo, guestService)
+	duplicateService := service.NewDuplicateService(guestRepo, guestService)
+	webhookService := service.NewWebhookService(guestRepo, dispatcher)
+	handlerSet := routes.Handlers{
+		Guests:     handlers.NewGuestHandler(guestService),
+		Events:     handlers.NewEventsHandler(broker, cfg.Events.Heartbeat),
+		Webhooks:   handlers.NewWebhookHandler(webhookService),
+		SubEvents:  handlers.NewSubEventHandler(subEventService),
+		Tags:       handlers.NewTagHandler(tagService),
+		Households: handlers.NewHouseholdHandler(householdService),
+		CheckIns:   handlers.NewCheckInHandler(checkInService),
+		Duplicates: handlers.NewDuplicateHandler(duplicateService),
+	}
+
+	// Dependency checks for the readiness endpoint; subsystems add their own
+	healthRegistry := health.NewRegistry(cfg.Health.CacheTTL)
+	healthRegistry.Register("database", true, health.DatabaseCheck(conn))
+	// Only admin routes need the auth service, so its failure degrades rather than fails readiness
+	healthRegistry.Register("authentication_service", false, health.HTTPCheck(http.DefaultClient, cfg.Auth.ServiceURL+"/status"))
 
-	// Initialize router with middleware
-	router := gin.Default()
-	router.Use(middlewares.CORSMiddleware()) // Apply CORS middleware
+	// Metrics: pool stats and business gauges are computed on each scrape
+	metrics.RegisterDBStats(metrics.Default, conn)
+	registerGuestMetrics(guestService, checkInService)
+	metrics.Default.NewGaugeFunc("rsvp_event_stream_clients", "Connected admin event stream clients.", nil,
+		func(context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: float64(broker.Subscribers())}}, nil
+		})
+
+	// Background workers are started through the manager so shutdown can stop them in order
+	workers := lifecycle.NewManager()
+
+	// Initialize router; gin.New rather than gin.Default because request logging,
+	// panic recovery and per-group CORS policies are installed by SetupRoutes
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+
+	// Rate limit buckets live in memory; idle ones are swept in the background
+	rateStore := ratelimit.NewMemoryStore()
+	workers.Go("rate-limit-sweeper", func(ctx context.Context) {
+		rateStore.Run(ctx, cfg.RateLimit.SweepInterval)
+	})
+
+	// Webhook deliveries are queued by guest changes and sent, with retries, in the background
+	workers.Go("webhook-dispatcher", dispatcher.Run)
+
+	// RSVP confirmations and the couple's digest are emailed in the background
+	if cfg.SMTP.Enabled() {
+		notifier := service.NewRSVPNotifier(mailer, cfg.Notifications)
+		guestService.Notifier = notifier
+		workers.Go("rsvp-notifier", notifier.Run)
+	} else {
+		slog.Info("SMTP is not configured; RSVP confirmation and digest emails are disabled")
+	}
 
 	// Register API routes
-	routes.SetupRoutes(router, guestHandler)
+	routes.SetupRoutes(router, cfg, handlerSet, healthRegistry, rateStore)
 
-	// Get Cloud Run Port (Cloud Run requires this)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default for local development
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
+	// Event streams never finish on their own; end them so Shutdown can drain
+	server.RegisterOnShutdown(broker.Close)
 
 	// Graceful shutdown handling
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	// Start server
-	serverAddr := fmt.Sprintf(":%s", port)
-	log.Printf("🚀 RSVP Backend is running on port %s...", port)
+	serverErr := make(chan error, 1)
+	slog.Info("RSVP backend is running", slog.String("port", cfg.Server.Port))
 	go func() {
-		if err := router.Run(serverAddr); err != nil {
-			log.Fatalf("❌ Error starting server: %v", err)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
 		}
 	}()
+	health.MarkReady()
+
+	// Wait for shutdown signal or a fatal server error
+	select {
+	case <-quit:
+		slog.Info("shutting down RSVP backend gracefully")
+	case err := <-serverErr:
+		slog.Error("error starting server", slog.Any("error", err))
+	}
+
+	shutdown(cfg.Server, server, workers, conn)
+}
+
+// shutdown drains the server and releases resources in dependency order:
+// stop advertising readiness, finish in-flight requests, stop background
+// workers, and close the database pool last since everything else may use it.
+func shutdown(cfg config.ServerConfig, server *http.Server, workers *lifecycle.Manager, conn *sql.DB) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// 1. Fail readiness checks and give load balancers time to notice
+	health.MarkNotReady()
+	if delay := cfg.ShutdownReadinessDelay; delay > 0 {
+		slog.Info("waiting for load balancers to stop routing traffic", slog.Duration("delay", delay))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	// 2. Stop accepting connections and wait for in-flight requests
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server did not drain cleanly", slog.Any("error", err))
+		server.Close()
+	} else {
+		slog.Info("HTTP server drained")
+	}
+
+	// 3. Stop background workers
+	if err := workers.Stop(ctx); err != nil {
+		slog.Warn("background workers did not stop cleanly", slog.Any("error", err))
+	}
+
+	// 4. Close the database pool
+	if err := conn.Close(); err != nil {
+		slog.Warn("error closing database", slog.Any("error", err))
+	}
+
+	slog.Info("RSVP backend stopped")
+}
+
+// registerGuestMetrics exposes RSVP progress as gauges
+func registerGuestMetrics(guestService *service.GuestService, checkInService *service.CheckInService) {
+	metrics.Default.NewGaugeFunc("rsvp_guests", "Guest invitations by RSVP status.", []string{"rsvp_status"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			summary, err := guestService.GetRSVPSummary(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metrics.Sample, 0, len(summary))
+			for _, s := range summary {
+				samples = append(samples, metrics.Sample{LabelValues: []string{s.RSVPStatus}, Value: float64(s.Guests)})
+			}
+			return samples, nil
+		})
+
+	metrics.Default.NewGaugeFunc("rsvp_expected_attendees", "People expected to attend, summed over attending guests' party sizes.", nil,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			summary, err := guestService.GetRSVPSummary(ctx)
+			if err != nil {
+				return nil, err
+			}
+			attendees := 0
+			for _, s := range summary {
+				if s.RSVPStatus == models.RSVPStatusAttending {
+					attendees += s.Attendees
+				}
+			}
+			return []metrics.Sample{{Value: float64(attendees)}}, nil
+		})
 
-	// Wait for shutdown signal
-	<-quit
-	log.Println("🛑 Shutting down RSVP backend gracefully...")
+	metrics.Default.NewGaugeFunc("rsvp_arrived_attendees", "People checked in at the door.", nil,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			summary, err := checkInService.GetCheckInSummary(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []metrics.Sample{{Value: float64(summary.ArrivedAttendees)}}, nil
+		})
+}
+
+// fatal logs a startup failure and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
+
+// newSigner builds the RSVP link signer; the first configured key signs new links
+func newSigner(cfg config.RSVPLinkConfig) (*tokens.Signer, error) {
+	keys := make([]tokens.Key, 0, len(cfg.SigningKeys))
+	for _, key := range cfg.SigningKeys {
+		keys = append(keys, tokens.Key{ID: key.ID, Secret: []byte(key.Secret.Reveal())})
+	}
+	return tokens.NewSigner(keys, cfg.TTL)
 }
@@ -1,44 +1,738 @@
 package config
 
 import (
-	"log"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// Config struct holds environment variables
+// Config is the typed application configuration. It is loaded once at startup
+// and the relevant sections are passed to each constructor; nothing else in
+// the application reads the environment.
 type Config struct {
-	ServerPort     string
-	AuthServiceURL string
-	DatabaseURL    string
-	SMTPUser       string
-	SMTPPassword   string
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Auth          AuthConfig
+	SMTP          SMTPConfig
+	Log           LogConfig
+	Metrics       MetricsConfig
+	Health        HealthConfig
+	CORS          CORSConfig
+	RateLimit     RateLimitConfig
+	RSVPLinks     RSVPLinkConfig
+	Events        EventsConfig
+	Webhooks      WebhookConfig
+	Notifications NotificationConfig
+	Wedding       WeddingConfig
+
+	// PublicURL is the guest-facing site that RSVP links point to
+	PublicURL string
+}
+
+// ServerConfig controls the HTTP server and its shutdown
+type ServerConfig struct {
+	Port                   string
+	ReadHeaderTimeout      time.Duration
+	ReadTimeout            time.Duration
+	WriteTimeout           time.Duration
+	IdleTimeout            time.Duration
+	ShutdownTimeout        time.Duration
+	ShutdownReadinessDelay time.Duration
+
+	// TrustedProxies are the IPs or CIDR ranges whose X-Forwarded-For header is
+	// believed when determining the client IP; none are trusted by default
+	TrustedProxies []string
+}
+
+// DatabaseConfig holds the connection string and per-query timeout
+type DatabaseConfig struct {
+	URL          Secret // the DSN usually embeds a password
+	QueryTimeout time.Duration
+}
+
+// AuthConfig locates the authentication service that validates admin tokens
+type AuthConfig struct {
+	ServiceURL string
+}
+
+// SMTPConfig holds the outgoing mail server settings. Email is disabled when
+// no user is configured.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password Secret
+}
+
+// Enabled reports whether credentials for sending email are configured
+func (c SMTPConfig) Enabled() bool {
+	return c.User != ""
+}
+
+// LogConfig controls structured logging
+type LogConfig struct {
+	Level string
+}
+
+// MetricsConfig protects the Prometheus endpoint
+type MetricsConfig struct {
+	Token Secret // optional bearer token required to scrape /metrics
+}
+
+// HealthConfig controls the readiness endpoint
+type HealthConfig struct {
+	CacheTTL time.Duration
+}
+
+// CORSConfig holds separate cross-origin policies for the public RSVP routes
+// and the authenticated admin routes
+type CORSConfig struct {
+	RSVP  CORSPolicy
+	Admin CORSPolicy
+}
+
+// CORSPolicy lists the browser origins allowed to call a route group. Entries
+// are exact origins such as "https://example.com", wildcard subdomains such as
+// "https://*.example.com", or "*" for any origin.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // how long browsers may cache a preflight response
+}
+
+// RateLimitConfig throttles the public RSVP endpoints
+type RateLimitConfig struct {
+	Enabled  bool
+	PerIP    RateLimit // requests from one client IP
+	PerToken RateLimit // requests for one RSVP token, from any IP
+	Failures RateLimit // invalid token lookups from one IP before it is blocked
+	// SweepInterval controls how often idle buckets are evicted from memory
+	SweepInterval time.Duration
+}
+
+// RateLimit allows Burst requests at once, refilling Burst per Period
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// RSVPLinkConfig controls the signed magic-link tokens in invitations
+type RSVPLinkConfig struct {
+	// SigningKeys sign and verify tokens. The first key signs new links; the
+	// rest are only accepted for verification while they are being retired.
+	SigningKeys []SigningKey
+	TTL         time.Duration // how long a link stays valid after it is issued
+	// AcceptLegacyTokens keeps plaintext tokens from before links were signed working
+	AcceptLegacyTokens bool
+}
+
+// EventsConfig controls the real-time admin event stream
+type EventsConfig struct {
+	// ReplayBuffer is how many recent events are kept for clients that
+	// reconnect with Last-Event-ID
+	ReplayBuffer int
+	// Heartbeat is how often idle streams receive a comment, so proxies
+	// do not time out the connection
+	Heartbeat time.Duration
+}
+
+// NotificationConfig controls the emails sent when guests respond. Both
+// emails need SMTP to be configured.
+type NotificationConfig struct {
+	// GuestConfirmation emails guests a summary of their RSVP with a link to change it
+	GuestConfirmation bool
+	// DigestRecipients receive a digest of new responses; none disables the digest
+	DigestRecipients []string
+	// DigestInterval is how often the digest is sent, if there is anything new
+	DigestInterval time.Duration
+}
+
+// WeddingConfig describes the celebration for calendar entries. Calendars
+// are only offered once Start and End are set.
+type WeddingConfig struct {
+	Title       string
+	Start       time.Time
+	End         time.Time
+	Venue       string
+	Address     string
+	Description string
+
+	// Country is the ISO 3166 code invitations are posted from; mailing
+	// labels for addresses in it leave the country line out
+	Country string
+}
+
+// Scheduled reports whether the date of the wedding is configured
+func (c WeddingConfig) Scheduled() bool {
+	return !c.Start.IsZero()
+}
+
+// WebhookConfig controls delivery of outgoing webhooks
+type WebhookConfig struct {
+	Timeout     time.Duration // per delivery attempt
+	MaxAttempts int           // a delivery is marked failed after this many attempts
+	// Retries wait InitialBackoff, doubling after every failure up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// PollInterval is how often the dispatcher looks for deliveries that are due
+	PollInterval time.Duration
 }
 
-// LoadConfig loads environment variables from .env file
-func LoadConfig() *Config {
-	err := godotenv.Load(".env") // Force loading .env explicitly
-	if err != nil {
-		log.Println("⚠️ Warning: No .env file found, using system environment variables.")
+// SigningKey is an HMAC key and the ID embedded in the tokens it signs
+type SigningKey struct {
+	ID     string
+	Secret Secret
+}
+
+// Secret is a configuration value that must never be printed. It renders as
+// "[REDACTED]" in logs, JSON and fmt output; Reveal returns the real value.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+// Reveal returns the secret value for the code that actually needs it
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer
+func (s Secret) String() string {
+	if s == "" {
+		return ""
 	}
+	return redacted
+}
 
-	// Debugging: Log the values being loaded
-	log.Printf("🔄 ENV: SERVER_PORT=%s, AUTH_SERVICE_URL=%s", os.Getenv("SERVER_PORT"), os.Getenv("AUTH_SERVICE_URL"))
+// GoString keeps %#v from printing the value
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// LogValue implements slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
 
+// MarshalJSON implements json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Default returns the configuration used when nothing overrides a setting
+func Default() *Config {
 	return &Config{
-		ServerPort:     getEnv("SERVER_PORT", "8081"),
-		AuthServiceURL: getEnv("AUTH_SERVICE_URL", ""),
-		DatabaseURL:    getEnv("DATABASE_URL", ""),
-		SMTPUser:       getEnv("SMTP_USER", ""),
-		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   25 * time.Second,
+		},
+		Database: DatabaseConfig{QueryTimeout: 5 * time.Second},
+		SMTP:     SMTPConfig{Host: "smtp.gmail.com", Port: "587"},
+		Log:      LogConfig{Level: "info"},
+		Health:   HealthConfig{CacheTTL: 5 * time.Second},
+		CORS: CORSConfig{
+			RSVP:  CORSPolicy{MaxAge: 10 * time.Minute},
+			Admin: CORSPolicy{MaxAge: 10 * time.Minute},
+		},
+		RSVPLinks:     RSVPLinkConfig{TTL: 180 * 24 * time.Hour, AcceptLegacyTokens: true},
+		Events:        EventsConfig{ReplayBuffer: 1000, Heartbeat: 15 * time.Second},
+		Notifications: NotificationConfig{GuestConfirmation: true, DigestInterval: time.Hour},
+		Webhooks: WebhookConfig{
+			Timeout:        10 * time.Second,
+			MaxAttempts:    8,
+			InitialBackoff: 30 * time.Second,
+			MaxBackoff:     time.Hour,
+			PollInterval:   10 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       true,
+			PerIP:         RateLimit{Burst: 30, Period: time.Minute},
+			PerToken:      RateLimit{Burst: 10, Period: time.Minute},
+			Failures:      RateLimit{Burst: 5, Period: 15 * time.Minute},
+			SweepInterval: time.Minute,
+		},
+		// Kept as the default so existing invitation links stay valid
+		PublicURL: "https://axeldaphne.com",
+	}
+}
+
+// field binds one setting to its environment variables and config file key
+type field struct {
+	env  []string // checked in order; the first that is set wins
+	file string   // dotted path in the YAML/TOML file
+	set  func(value string) error
+}
+
+// fields lists every setting. PORT is what Cloud Run sets, so it takes
+// precedence over the older SERVER_PORT, which is still honored on its own.
+func (c *Config) fields() []field {
+	return []field{
+		{[]string{"PORT", "SERVER_PORT"}, "server.port", setString(&c.Server.Port)},
+		{[]string{"HTTP_READ_HEADER_TIMEOUT"}, "server.read_header_timeout", setDuration(&c.Server.ReadHeaderTimeout)},
+		{[]string{"HTTP_READ_TIMEOUT"}, "server.read_timeout", setDuration(&c.Server.ReadTimeout)},
+		{[]string{"HTTP_WRITE_TIMEOUT"}, "server.write_timeout", setDuration(&c.Server.WriteTimeout)},
+		{[]string{"HTTP_IDLE_TIMEOUT"}, "server.idle_timeout", setDuration(&c.Server.IdleTimeout)},
+		{[]string{"SHUTDOWN_TIMEOUT"}, "server.shutdown_timeout", setDuration(&c.Server.ShutdownTimeout)},
+		{[]string{"SHUTDOWN_READINESS_DELAY"}, "server.shutdown_readiness_delay", setDuration(&c.Server.ShutdownReadinessDelay)},
+		{[]string{"TRUSTED_PROXIES"}, "server.trusted_proxies", setList(&c.Server.TrustedProxies)},
+		{[]string{"DATABASE_URL"}, "database.url", setSecret(&c.Database.URL)},
+		{[]string{"DB_QUERY_TIMEOUT"}, "database.query_timeout", setDuration(&c.Database.QueryTimeout)},
+		{[]string{"AUTH_SERVICE_URL"}, "auth.service_url", setString(&c.Auth.ServiceURL)},
+		{[]string{"SMTP_HOST"}, "smtp.host", setString(&c.SMTP.Host)},
+		{[]string{"SMTP_PORT"}, "smtp.port", setString(&c.SMTP.Port)},
+		{[]string{"SMTP_USER"}, "smtp.user", setString(&c.SMTP.User)},
+		{[]string{"SMTP_PASSWORD"}, "smtp.password", setSecret(&c.SMTP.Password)},
+		{[]string{"LOG_LEVEL"}, "log.level", setString(&c.Log.Level)},
+		{[]string{"METRICS_TOKEN"}, "metrics.token", setSecret(&c.Metrics.Token)},
+		{[]string{"HEALTH_CACHE_TTL"}, "health.cache_ttl", setDuration(&c.Health.CacheTTL)},
+		{[]string{"PUBLIC_URL"}, "public_url", setString(&c.PublicURL)},
+		{[]string{"CORS_RSVP_ALLOWED_ORIGINS"}, "cors.rsvp.allowed_origins", setList(&c.CORS.RSVP.AllowedOrigins)},
+		{[]string{"CORS_RSVP_ALLOW_CREDENTIALS"}, "cors.rsvp.allow_credentials", setBool(&c.CORS.RSVP.AllowCredentials)},
+		{[]string{"CORS_RSVP_MAX_AGE"}, "cors.rsvp.max_age", setDuration(&c.CORS.RSVP.MaxAge)},
+		{[]string{"CORS_ADMIN_ALLOWED_ORIGINS"}, "cors.admin.allowed_origins", setList(&c.CORS.Admin.AllowedOrigins)},
+		{[]string{"CORS_ADMIN_ALLOW_CREDENTIALS"}, "cors.admin.allow_credentials", setBool(&c.CORS.Admin.AllowCredentials)},
+		{[]string{"CORS_ADMIN_MAX_AGE"}, "cors.admin.max_age", setDuration(&c.CORS.Admin.MaxAge)},
+		{[]string{"RSVP_SIGNING_KEYS"}, "rsvp_links.signing_keys", setSigningKeys(&c.RSVPLinks.SigningKeys)},
+		{[]string{"RSVP_LINK_TTL"}, "rsvp_links.ttl", setDuration(&c.RSVPLinks.TTL)},
+		{[]string{"RSVP_ACCEPT_LEGACY_TOKENS"}, "rsvp_links.accept_legacy_tokens", setBool(&c.RSVPLinks.AcceptLegacyTokens)},
+		{[]string{"RATE_LIMIT_ENABLED"}, "rate_limit.enabled", setBool(&c.RateLimit.Enabled)},
+		{[]string{"RATE_LIMIT_IP_BURST"}, "rate_limit.ip.burst", setInt(&c.RateLimit.PerIP.Burst)},
+		{[]string{"RATE_LIMIT_IP_PERIOD"}, "rate_limit.ip.period", setDuration(&c.RateLimit.PerIP.Period)},
+		{[]string{"RATE_LIMIT_TOKEN_BURST"}, "rate_limit.token.burst", setInt(&c.RateLimit.PerToken.Burst)},
+		{[]string{"RATE_LIMIT_TOKEN_PERIOD"}, "rate_limit.token.period", setDuration(&c.RateLimit.PerToken.Period)},
+		{[]string{"RATE_LIMIT_FAILURE_BURST"}, "rate_limit.failures.burst", setInt(&c.RateLimit.Failures.Burst)},
+		{[]string{"RATE_LIMIT_FAILURE_PERIOD"}, "rate_limit.failures.period", setDuration(&c.RateLimit.Failures.Period)},
+		{[]string{"RATE_LIMIT_SWEEP_INTERVAL"}, "rate_limit.sweep_interval", setDuration(&c.RateLimit.SweepInterval)},
+		{[]string{"EVENTS_REPLAY_BUFFER"}, "events.replay_buffer", setInt(&c.Events.ReplayBuffer)},
+		{[]string{"EVENTS_HEARTBEAT_INTERVAL"}, "events.heartbeat_interval", setDuration(&c.Events.Heartbeat)},
+		{[]string{"RSVP_CONFIRMATION_EMAILS"}, "notifications.guest_confirmation", setBool(&c.Notifications.GuestConfirmation)},
+		{[]string{"RSVP_DIGEST_RECIPIENTS"}, "notifications.digest_recipients", setList(&c.Notifications.DigestRecipients)},
+		{[]string{"RSVP_DIGEST_INTERVAL"}, "notifications.digest_interval", setDuration(&c.Notifications.DigestInterval)},
+		{[]string{"WEDDING_TITLE"}, "wedding.title", setString(&c.Wedding.Title)},
+		{[]string{"WEDDING_START"}, "wedding.start", setTime(&c.Wedding.Start)},
+		{[]string{"WEDDING_END"}, "wedding.end", setTime(&c.Wedding.End)},
+		{[]string{"WEDDING_VENUE"}, "wedding.venue", setString(&c.Wedding.Venue)},
+		{[]string{"WEDDING_ADDRESS"}, "wedding.address", setString(&c.Wedding.Address)},
+		{[]string{"WEDDING_DESCRIPTION"}, "wedding.description", setString(&c.Wedding.Description)},
+		{[]string{"WEDDING_COUNTRY"}, "wedding.country", setCountry(&c.Wedding.Country)},
+		{[]string{"WEBHOOK_TIMEOUT"}, "webhooks.timeout", setDuration(&c.Webhooks.Timeout)},
+		{[]string{"WEBHOOK_MAX_ATTEMPTS"}, "webhooks.max_attempts", setInt(&c.Webhooks.MaxAttempts)},
+		{[]string{"WEBHOOK_INITIAL_BACKOFF"}, "webhooks.initial_backoff", setDuration(&c.Webhooks.InitialBackoff)},
+		{[]string{"WEBHOOK_MAX_BACKOFF"}, "webhooks.max_backoff", setDuration(&c.Webhooks.MaxBackoff)},
+		{[]string{"WEBHOOK_POLL_INTERVAL"}, "webhooks.poll_interval", setDuration(&c.Webhooks.PollInterval)},
+	}
+}
+
+func setString(dst *string) func(string) error {
+	return func(value string) error {
+		*dst = value
+		return nil
+	}
+}
+
+// setCountry reads an ISO 3166-1 alpha-2 country code in any case; empty unsets it
+func setCountry(dst *string) func(string) error {
+	return func(value string) error {
+		code := strings.ToUpper(value)
+		if code != "" && len(code) != 2 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return fmt.Errorf("%q is not a two-letter ISO 3166 country code", value)
+		}
+		*dst = code
+		return nil
+	}
+}
+
+func setSecret(dst *Secret) func(string) error {
+	return func(value string) error {
+		*dst = Secret(value)
+		return nil
+	}
+}
+
+// setList splits a comma-separated value, dropping empty entries
+func setList(dst *[]string) func(string) error {
+	return func(value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*dst = list
+		return nil
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+// setSigningKeys parses "id:base64key" pairs separated by commas. The error
+// never echoes the value, which holds key material.
+func setSigningKeys(dst *[]SigningKey) func(string) error {
+	return func(value string) error {
+		var keys []SigningKey
+		for i, entry := range strings.Split(value, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			id, encoded, ok := strings.Cut(entry, ":")
+			if !ok || id == "" {
+				return fmt.Errorf("entry %d must have the form id:base64key", i+1)
+			}
+			secret, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("key %q is not valid base64", id)
+			}
+			keys = append(keys, SigningKey{ID: id, Secret: Secret(secret)})
+		}
+		*dst = keys
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q (use true or false)", value)
+		}
+		*dst = b
+		return nil
 	}
 }
 
-// getEnv retrieves an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// setTime parses an RFC 3339 timestamp; the UTC offset is required so the
+// time is unambiguous, e.g. 2026-06-20T15:00:00+07:00
+func setTime(dst *time.Time) func(string) error {
+	return func(value string) error {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("must be an RFC 3339 time with a UTC offset, such as 2026-06-20T15:00:00+07:00")
+		}
+		*dst = t
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (use a value such as \"30s\")", value)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+// Load builds the configuration from, in increasing precedence: defaults, the
+// YAML or TOML file named by CONFIG_FILE, a .env file in the working
+// directory, and the process environment. The result is validated.
+func Load() (*Config, error) {
+	dotenv, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := dotenv[key]
+		return value, ok
+	}
+	return load(lookup)
+}
+
+// load builds and validates the configuration using lookup for environment variables
+func load(lookup func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	var errs []error
+	if path, ok := lookup("CONFIG_FILE"); ok && path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, applyFile(fields, path, values)...)
+	}
+
+	for _, f := range fields {
+		for _, key := range f.env {
+			value, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			if err := f.set(strings.TrimSpace(value)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		errs = cfg.validate()
+	}
+	if len(errs) == 0 && len(cfg.CORS.RSVP.AllowedOrigins) == 0 {
+		// The guest-facing site is the one origin that always needs the RSVP API
+		u, _ := url.Parse(cfg.PublicURL)
+		cfg.CORS.RSVP.AllowedOrigins = []string{u.Scheme + "://" + u.Host}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// applyFile sets every field present in the file and rejects unknown keys
+func applyFile(fields []field, path string, values map[string]string) []error {
+	var errs []error
+	for _, f := range fields {
+		value, ok := values[f.file]
+		if !ok {
+			continue
+		}
+		delete(values, f.file)
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, f.file, err))
+		}
+	}
+	for key := range values {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+	}
+	return errs
+}
+
+// validate checks every setting and reports all problems at once
+func (c *Config) validate() []error {
+	var errs []error
+	fail := func(setting, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{setting}, args...)...))
+	}
+
+	if !validPort(c.Server.Port) {
+		fail("PORT", "must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+	positive := []struct {
+		setting string
+		value   time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"DB_QUERY_TIMEOUT", c.Database.QueryTimeout},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			fail(p.setting, "must be greater than zero")
+		}
+	}
+	if c.Server.ShutdownReadinessDelay < 0 {
+		fail("SHUTDOWN_READINESS_DELAY", "must not be negative")
+	}
+	if c.Health.CacheTTL < 0 {
+		fail("HEALTH_CACHE_TTL", "must not be negative")
+	}
+
+	if c.Database.URL == "" {
+		fail("DATABASE_URL", "is required")
+	}
+	if err := validateURL(c.Auth.ServiceURL); err != nil {
+		fail("AUTH_SERVICE_URL", "%v", err)
+	}
+	if err := validateURL(c.PublicURL); err != nil {
+		fail("PUBLIC_URL", "%v", err)
+	}
+
+	if c.SMTP.User != "" && c.SMTP.Password == "" {
+		fail("SMTP_PASSWORD", "is required when SMTP_USER is set")
+	}
+	if c.SMTP.User == "" && c.SMTP.Password != "" {
+		fail("SMTP_USER", "is required when SMTP_PASSWORD is set")
+	}
+	if c.SMTP.Enabled() && c.SMTP.Host == "" {
+		fail("SMTP_HOST", "is required when SMTP_USER is set")
+	}
+	if !validPort(c.SMTP.Port) {
+		fail("SMTP_PORT", "must be a number between 1 and 65535, got %q", c.SMTP.Port)
+	}
+
+	policies := []struct {
+		name   string
+		policy CORSPolicy
+	}{
+		{"CORS_RSVP", c.CORS.RSVP},
+		{"CORS_ADMIN", c.CORS.Admin},
+	}
+	for _, p := range policies {
+		for _, origin := range p.policy.AllowedOrigins {
+			if err := validateOrigin(origin); err != nil {
+				fail(p.name+"_ALLOWED_ORIGINS", "%v", err)
+			}
+			if origin == "*" && p.policy.AllowCredentials {
+				fail(p.name+"_ALLOW_CREDENTIALS", "cannot be combined with the \"*\" origin")
+			}
+		}
+		if p.policy.MaxAge < 0 {
+			fail(p.name+"_MAX_AGE", "must not be negative")
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				fail("TRUSTED_PROXIES", "%q is not an IP address or CIDR range", proxy)
+			}
+		}
+	}
+
+	if len(c.RSVPLinks.SigningKeys) == 0 {
+		fail("RSVP_SIGNING_KEYS", "is required (e.g. \"k1:$(openssl rand -base64 32)\")")
+	}
+	seen := make(map[string]bool)
+	for _, key := range c.RSVPLinks.SigningKeys {
+		if seen[key.ID] {
+			fail("RSVP_SIGNING_KEYS", "duplicate key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < 32 {
+			fail("RSVP_SIGNING_KEYS", "key %q must be at least 32 bytes", key.ID)
+		}
+	}
+	if c.RSVPLinks.TTL <= 0 {
+		fail("RSVP_LINK_TTL", "must be greater than zero")
+	}
+
+	if c.RateLimit.Enabled {
+		limits := []struct {
+			name  string
+			limit RateLimit
+		}{
+			{"RATE_LIMIT_IP", c.RateLimit.PerIP},
+			{"RATE_LIMIT_TOKEN", c.RateLimit.PerToken},
+			{"RATE_LIMIT_FAILURE", c.RateLimit.Failures},
+		}
+		for _, l := range limits {
+			if l.limit.Burst <= 0 {
+				fail(l.name+"_BURST", "must be greater than zero")
+			}
+			if l.limit.Period <= 0 {
+				fail(l.name+"_PERIOD", "must be greater than zero")
+			}
+		}
+		if c.RateLimit.SweepInterval <= 0 {
+			fail("RATE_LIMIT_SWEEP_INTERVAL", "must be greater than zero")
+		}
+	}
+
+	if c.Events.ReplayBuffer <= 0 {
+		fail("EVENTS_REPLAY_BUFFER", "must be greater than zero")
+	}
+	if c.Events.Heartbeat <= 0 {
+		fail("EVENTS_HEARTBEAT_INTERVAL", "must be greater than zero")
+	}
+
+	for _, recipient := range c.Notifications.DigestRecipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			fail("RSVP_DIGEST_RECIPIENTS", "%q is not an email address", recipient)
+		}
+	}
+	if c.Notifications.DigestInterval <= 0 {
+		fail("RSVP_DIGEST_INTERVAL", "must be greater than zero")
+	}
+
+	switch w := c.Wedding; {
+	case w.Start.IsZero() != w.End.IsZero():
+		fail("WEDDING_END", "WEDDING_START and WEDDING_END must be set together")
+	case w.Scheduled() && !w.End.After(w.Start):
+		fail("WEDDING_END", "must be after WEDDING_START")
+	case w.Scheduled() && w.Title == "":
+		fail("WEDDING_TITLE", "is required when WEDDING_START is set")
+	}
+
+	webhookDurations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"WEBHOOK_TIMEOUT", c.Webhooks.Timeout},
+		{"WEBHOOK_INITIAL_BACKOFF", c.Webhooks.InitialBackoff},
+		{"WEBHOOK_MAX_BACKOFF", c.Webhooks.MaxBackoff},
+		{"WEBHOOK_POLL_INTERVAL", c.Webhooks.PollInterval},
+	}
+	for _, d := range webhookDurations {
+		if d.value <= 0 {
+			fail(d.name, "must be greater than zero")
+		}
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		fail("WEBHOOK_MAX_ATTEMPTS", "must be greater than zero")
+	}
+	if c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+		fail("WEBHOOK_MAX_BACKOFF", "must not be shorter than WEBHOOK_INITIAL_BACKOFF")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		fail("LOG_LEVEL", "must be one of debug, info, warn or error, got %q", c.Log.Level)
+	}
+	return errs
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// validateURL requires an absolute http(s) URL
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL, got %q", raw)
+	}
+	return nil
+}
+
+// validateOrigin accepts "*", "scheme://host[:port]" and "scheme://*.domain[:port]"
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return fmt.Errorf("%q is not an origin such as https://example.com or https://*.example.com", origin)
+	}
+	if host := strings.TrimPrefix(u.Hostname(), "*."); strings.Contains(host, "*") || host == "" {
+		return fmt.Errorf("%q: a wildcard is only allowed as the first label", origin)
 	}
-	return defaultValue
+	return nil
 }
@@ -1,39 +1,29 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"os"
+	"fmt"
+	"log/slog"
+
+	"github.com/g4l1l10/rsvp-backend/config"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
-var DB *sql.DB
-
-// InitDB initializes the database connection
-func InitDB() {
-	// Read DATABASE_URL from environment
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal("❌ DATABASE_URL is not set in environment variables")
-	}
-
-	// Open connection
-	var err error
-	DB, err = sql.Open("postgres", dsn)
+// Connect opens the connection pool described by cfg and verifies it with a ping
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", cfg.URL.Reveal())
 	if err != nil {
-		log.Fatalf("❌ Error connecting to the database: %v", err)
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
 	}
 
 	// Verify connection
-	if err := DB.Ping(); err != nil {
-		log.Fatalf("❌ Database ping failed: %v", err)
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
-	log.Println("✅ Connected to CockroachDB successfully!")
-}
-
-// GetDB returns the database instance
-func GetDB() *sql.DB {
-	return DB
+	slog.Info("connected to CockroachDB")
+	return conn, nil
 }
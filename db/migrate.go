@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate applies any schema migrations that have not yet been recorded in
+// schema_migrations. Migrations run in filename order, each in its own transaction.
+func Migrate(conn *sql.DB) error {
+	_, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name       TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		id := strings.TrimPrefix(name, "migrations/")
+
+		var applied bool
+		err := conn.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE name = $1)", id).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %v", id, err)
+		}
+		if applied {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if err := applyMigration(conn, id, string(script)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %v", id, err)
+		}
+		slog.Info("applied migration", slog.String("migration", id))
+	}
+
+	return nil
+}
+
+// applyMigration runs one migration script and records it atomically
+func applyMigration(conn *sql.DB, id, script string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (name) VALUES ($1)", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
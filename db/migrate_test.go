@@ -0,0 +1,48 @@
+package db
+
+import (
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// plaintextToken matches the legacy rsvp_token column but not the hash columns
+var plaintextToken = regexp.MustCompile(`\brsvp_token\b`)
+
+func TestMigrationsDiscardPlaintextTokens(t *testing.T) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	sort.Strings(names)
+
+	// The last migration to touch rsvp_token must hash and clear every token
+	var last, script string
+	for _, name := range names {
+		content, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if plaintextToken.MatchString(stripComments(string(content))) {
+			last, script = name, stripComments(string(content))
+		}
+	}
+	if !strings.Contains(script, "legacy_rsvp_token_hash = ltrim(sha256(rsvp_token::bytea)::text") ||
+		!strings.Contains(script, "rsvp_token = NULL") || !strings.Contains(script, "WHERE rsvp_token IS NOT NULL") {
+		t.Errorf("%s does not replace every plaintext token by its hash:\n%s", last, script)
+	}
+}
+
+// stripComments drops SQL line comments
+func stripComments(script string) string {
+	lines := strings.Split(script, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS guests (
+    id           UUID PRIMARY KEY,
+    name         TEXT NOT NULL,
+    email        TEXT NOT NULL,
+    family_side  TEXT NOT NULL DEFAULT '',
+    hongbao      DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    total_guests INT NOT NULL DEFAULT 1,
+    rsvp_status  TEXT NOT NULL DEFAULT 'Pending',
+    rsvp_token   TEXT NOT NULL UNIQUE
+);
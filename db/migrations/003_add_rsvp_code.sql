@@ -0,0 +1,5 @@
+-- Short, human-friendly RSVP codes for printed invitations. Both credentials
+-- may be revoked, which stores NULL; unique indexes ignore NULLs.
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS rsvp_code TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS guests_rsvp_code_key ON guests (rsvp_code);
+ALTER TABLE guests ALTER COLUMN rsvp_token DROP NOT NULL;
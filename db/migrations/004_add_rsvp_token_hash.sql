@@ -0,0 +1,5 @@
+-- RSVP links are now signed tokens; only a hash of each link's secret is
+-- stored. Plaintext tokens issued before this change are replaced by their
+-- hash in the next two migrations, so invitations already sent keep working
+-- until they are regenerated.
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS rsvp_token_hash TEXT;
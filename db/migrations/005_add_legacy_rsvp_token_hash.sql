@@ -0,0 +1,6 @@
+-- Plaintext RSVP tokens from before links were signed are replaced by their
+-- SHA-256 (see tokens.HashLegacy) so that a leaked database holds no working
+-- credentials. The hashes are filled in by the next migration: CockroachDB
+-- cannot write a column in the transaction that adds it.
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS legacy_rsvp_token_hash TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS guests_legacy_rsvp_token_hash_key ON guests (legacy_rsvp_token_hash);
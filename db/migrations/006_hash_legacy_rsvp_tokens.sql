@@ -0,0 +1,7 @@
+-- Hash every legacy plaintext RSVP token and discard the token. sha256 returns
+-- bytea on Postgres, which casts to text as '\x' followed by hex, and hex text
+-- on CockroachDB; trimming the prefix gives lower-case hex on both.
+UPDATE guests
+SET legacy_rsvp_token_hash = ltrim(sha256(rsvp_token::bytea)::text, '\x'),
+    rsvp_token = NULL
+WHERE rsvp_token IS NOT NULL;
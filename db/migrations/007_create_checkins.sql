@@ -0,0 +1,9 @@
+-- Day-of arrivals. A party may arrive in several groups, so each check-in is
+-- its own row; deleting a guest removes their check-ins.
+CREATE TABLE IF NOT EXISTS checkins (
+    id            UUID PRIMARY KEY,
+    guest_id      UUID NOT NULL REFERENCES guests (id) ON DELETE CASCADE,
+    arrived       INT NOT NULL CHECK (arrived > 0),
+    checked_in_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS checkins_guest_id_idx ON checkins (guest_id);
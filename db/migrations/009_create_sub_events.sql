@@ -0,0 +1,23 @@
+-- Parts of the wedding with their own guest lists, such as the tea ceremony
+-- and the banquet. Each invitation row says a guest is invited to a
+-- sub-event and holds their answer for it.
+CREATE TABLE IF NOT EXISTS sub_events (
+    id          UUID PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    venue       TEXT NOT NULL DEFAULT '',
+    address     TEXT NOT NULL DEFAULT '',
+    starts_at   TIMESTAMPTZ NOT NULL,
+    ends_at     TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS sub_event_invitations (
+    sub_event_id UUID NOT NULL REFERENCES sub_events (id) ON DELETE CASCADE,
+    guest_id     UUID NOT NULL REFERENCES guests (id) ON DELETE CASCADE,
+    rsvp_status  TEXT NOT NULL DEFAULT 'Pending',
+    attendees    INT NOT NULL DEFAULT 0 CHECK (attendees >= 0),
+    responded_at TIMESTAMPTZ,
+    PRIMARY KEY (sub_event_id, guest_id)
+);
+CREATE INDEX IF NOT EXISTS sub_event_invitations_guest_id_idx ON sub_event_invitations (guest_id);
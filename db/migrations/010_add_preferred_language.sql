@@ -0,0 +1,6 @@
+-- The language a guest reads their invitation and RSVP page in; empty until
+-- known, in which case the RSVP page follows the browser's Accept-Language.
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS preferred_language TEXT NOT NULL DEFAULT '';
+
+-- Sub-event names and descriptions per language, e.g. {"zh": {"name": "茶礼"}}
+ALTER TABLE sub_events ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,15 @@
+-- Admin-defined labels such as "college friends", "overseas" or "VIP",
+-- assigned to any number of guests
+CREATE TABLE IF NOT EXISTS tags (
+    id         UUID PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tags_name_key ON tags (lower(name));
+
+CREATE TABLE IF NOT EXISTS guest_tags (
+    guest_id UUID NOT NULL REFERENCES guests (id) ON DELETE CASCADE,
+    tag_id   UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (guest_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS guest_tags_tag_id_idx ON guest_tags (tag_id);
@@ -0,0 +1,17 @@
+-- Postal addresses for paper invitations, one per household; the fields a
+-- country requires are checked by the application
+CREATE TABLE IF NOT EXISTS households (
+    id            UUID PRIMARY KEY,
+    addressee     TEXT NOT NULL,
+    address_line1 TEXT NOT NULL,
+    address_line2 TEXT NOT NULL DEFAULT '',
+    city          TEXT NOT NULL DEFAULT '',
+    region        TEXT NOT NULL DEFAULT '',
+    postal_code   TEXT NOT NULL DEFAULT '',
+    country       CHAR(2) NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Guests leave their household, rather than disappear, when it is deleted
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS household_id UUID REFERENCES households (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS guests_household_id_idx ON guests (household_id);
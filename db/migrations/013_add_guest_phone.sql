@@ -0,0 +1,2 @@
+-- An optional contact number, also used to spot guests entered twice
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
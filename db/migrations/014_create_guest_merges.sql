@@ -0,0 +1,13 @@
+-- Audit trail of duplicate guests merged into another. Both records are kept
+-- as they were before the merge; neither ID references guests, since the
+-- merge deletes the duplicate and the kept guest may be deleted later.
+CREATE TABLE IF NOT EXISTS guest_merges (
+    id              UUID PRIMARY KEY,
+    primary_id      UUID NOT NULL,
+    duplicate_id    UUID NOT NULL,
+    primary_guest   JSONB NOT NULL,
+    duplicate_guest JSONB NOT NULL,
+    merged_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    request_id      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS guest_merges_primary_id_idx ON guest_merges (primary_id);
@@ -0,0 +1,207 @@
+// Package events is an in-process publish/subscribe broker for guest changes,
+// feeding the admin dashboard's live event stream.
+package events
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types published by the guest service
+const (
+	GuestCreated   = "guest.created"
+	GuestUpdated   = "guest.updated"
+	GuestDeleted   = "guest.deleted"
+	GuestRSVP      = "guest.rsvp"
+	GuestCheckedIn = "guest.checked_in"
+)
+
+// subscriberBuffer is how many events may queue for a subscriber before it is
+// considered too slow and disconnected; it can catch up by reconnecting with
+// its last event ID
+const subscriberBuffer = 64
+
+// Event is one change notification
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	GuestID uuid.UUID `json:"guest_id"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data,omitempty"`
+
+	seq uint64
+}
+
+// Filter selects the events a subscriber receives. Empty fields match everything.
+type Filter struct {
+	Types   []string
+	GuestID uuid.UUID
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	return f.GuestID == uuid.Nil || f.GuestID == e.GuestID
+}
+
+// Broker fans published events out to subscribers and keeps the most recent
+// ones so reconnecting clients can replay what they missed. Event IDs embed
+// the broker's start time, so IDs from before a restart are recognised as
+// unknown rather than confused with new events.
+type Broker struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	recent []Event // ring buffer of the last len(recent) events
+	next   int     // index in recent for the next event
+	subs   map[*Subscription]struct{}
+	closed bool
+	now    func() time.Time
+}
+
+// NewBroker initializes a broker that keeps the last replay events
+func NewBroker(replay int) *Broker {
+	return &Broker{
+		epoch:  strconv.FormatInt(time.Now().UnixMilli(), 36),
+		recent: make([]Event, 0, max(replay, 1)),
+		subs:   make(map[*Subscription]struct{}),
+		now:    time.Now,
+	}
+}
+
+// Publish sends an event to every matching subscriber. It never blocks:
+// subscribers that have fallen too far behind are disconnected.
+func (b *Broker) Publish(eventType string, guestID uuid.UUID, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:      b.epoch + "-" + strconv.FormatUint(b.seq, 10),
+		Type:    eventType,
+		GuestID: guestID,
+		Time:    b.now().UTC(),
+		Data:    data,
+		seq:     b.seq,
+	}
+	if len(b.recent) < cap(b.recent) {
+		b.recent = append(b.recent, event)
+	} else {
+		b.recent[b.next] = event
+	}
+	b.next = (b.next + 1) % cap(b.recent)
+
+	if b.closed {
+		return event
+	}
+	for sub := range b.subs {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			b.lockedRemove(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a subscriber. When lastEventID is set, the retained
+// events after it are returned for replay; complete is false if some events
+// after it are no longer retained (or the ID is unknown), in which case the
+// client should reload its state.
+func (b *Broker) Subscribe(lastEventID string, filter Filter) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{c: make(chan Event, subscriberBuffer), filter: filter, broker: b}
+	sub.C = sub.c
+	if b.closed {
+		close(sub.c)
+		return sub, nil, true
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	replay, complete = b.lockedSince(lastEventID)
+	kept := replay[:0]
+	for _, e := range replay {
+		if filter.Matches(e) {
+			kept = append(kept, e)
+		}
+	}
+	return sub, kept, complete
+}
+
+// lockedSince returns the retained events after id, oldest first
+func (b *Broker) lockedSince(id string) ([]Event, bool) {
+	epoch, rawSeq, ok := strings.Cut(id, "-")
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if !ok || err != nil || epoch != b.epoch || seq > b.seq {
+		return nil, false
+	}
+
+	events := make([]Event, 0, len(b.recent))
+	for i := range b.recent {
+		// Oldest first: the ring starts at next once it is full
+		e := b.recent[(b.next+i)%len(b.recent)]
+		if e.seq > seq {
+			events = append(events, e)
+		}
+	}
+	oldest := b.seq - uint64(len(b.recent)) + 1
+	return events, seq+1 >= oldest
+}
+
+// Subscribers returns the number of connected subscribers
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close disconnects every subscriber; later subscriptions are closed at once.
+// It lets long-lived streams end during a graceful shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.lockedRemove(sub)
+	}
+}
+
+// lockedRemove unregisters a subscriber and closes its channel
+func (b *Broker) lockedRemove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
+
+// Subscription receives matching events on C until it is closed, either by
+// the subscriber, by the broker shutting down or for falling behind
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	filter Filter
+	broker *Broker
+}
+
+// Close unsubscribes; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.lockedRemove(s)
+}
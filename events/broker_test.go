@@ -0,0 +1,114 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func ids(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestPublishFilters(t *testing.T) {
+	b := NewBroker(10)
+	guest := uuid.New()
+
+	all, _, _ := b.Subscribe("", Filter{})
+	rsvps, _, _ := b.Subscribe("", Filter{Types: []string{GuestRSVP}})
+	one, _, _ := b.Subscribe("", Filter{GuestID: guest})
+
+	b.Publish(GuestCreated, uuid.New(), nil)
+	rsvp := b.Publish(GuestRSVP, guest, map[string]string{"rsvp_status": "Attending"})
+
+	if got := len(all.C); got != 2 {
+		t.Errorf("unfiltered subscriber got %d events, want 2", got)
+	}
+	if e := <-rsvps.C; e.ID != rsvp.ID || len(rsvps.C) != 0 {
+		t.Errorf("type filter delivered %v", e)
+	}
+	if e := <-one.C; e.ID != rsvp.ID || len(one.C) != 0 {
+		t.Errorf("guest filter delivered %v", e)
+	}
+
+	all.Close()
+	all.Close() // closing twice is harmless
+	for range all.C {
+		// drains the queued events, then ends because the channel is closed
+	}
+	if got := b.Subscribers(); got != 2 {
+		t.Errorf("Subscribers() = %d, want 2", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	b := NewBroker(3)
+	var published []Event
+	for i := 0; i < 5; i++ {
+		published = append(published, b.Publish(GuestUpdated, uuid.New(), nil))
+	}
+
+	tests := []struct {
+		name         string
+		lastEventID  string
+		want         []Event
+		wantComplete bool
+	}{
+		{"up to date", published[4].ID, nil, true},
+		{"within buffer", published[2].ID, published[3:], true},
+		{"oldest retained is next", published[1].ID, published[2:], true},
+		{"events evicted", published[0].ID, published[2:], false},
+		{"previous process", "abc-2", nil, false},
+		{"from the future", published[0].ID[:len(published[0].ID)-1] + "99", nil, false},
+		{"garbage", "nonsense", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, complete := b.Subscribe(tt.lastEventID, Filter{})
+			defer sub.Close()
+			if complete != tt.wantComplete || len(replay) != len(tt.want) {
+				t.Fatalf("replay = %v, complete = %v; want %v, %v", ids(replay), complete, ids(tt.want), tt.wantComplete)
+			}
+			for i := range replay {
+				if replay[i].ID != tt.want[i].ID {
+					t.Errorf("replay = %v, want %v", ids(replay), ids(tt.want))
+				}
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberIsDisconnected(t *testing.T) {
+	b := NewBroker(1)
+	slow, _, _ := b.Subscribe("", Filter{})
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(GuestUpdated, uuid.New(), nil)
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriberBuffer || b.Subscribers() != 0 {
+		t.Errorf("received %d events with %d subscribers left", received, b.Subscribers())
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := NewBroker(1)
+	sub, _, _ := b.Subscribe("", Filter{})
+	b.Close()
+	if _, open := <-sub.C; open {
+		t.Error("subscription survived Close")
+	}
+
+	late, _, _ := b.Subscribe("", Filter{})
+	if _, open := <-late.C; open {
+		t.Error("subscription after Close is open")
+	}
+	b.Publish(GuestCreated, uuid.New(), nil) // must not panic on closed channels
+}
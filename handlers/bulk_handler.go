@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// bulkRequest selects guests and names the action to apply to them. An empty
+// selection must be confirmed with "all", so that a forgotten filter does not
+// email or delete every guest.
+type bulkRequest struct {
+	Selection struct {
+		IDs        []uuid.UUID `json:"ids"`
+		RSVPStatus string      `json:"rsvp_status"`
+		FamilySide string      `json:"family_side"`
+		Tags       []string    `json:"tags"`
+		All        bool        `json:"all"`
+	} `json:"selection"`
+	Action string             `json:"action" binding:"required"`
+	Update *models.GuestPatch `json:"update"`
+
+	// Preview lists the affected guests without changing anything
+	Preview bool `json:"preview"`
+	// ConfirmCount must repeat the count of the preview to run the action
+	ConfirmCount *int `json:"confirm_count"`
+}
+
+// BulkGuests previews or applies an action to a selection of guests: sending
+// invitations or reminders, updating fields or deleting them. The action only
+// runs when confirm_count matches the number of guests it would affect.
+func (h *GuestHandler) BulkGuests(ctx *gin.Context) {
+	var req bulkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	filter := models.GuestFilter{
+		RSVPStatus: req.Selection.RSVPStatus,
+		FamilySide: req.Selection.FamilySide,
+		IDs:        req.Selection.IDs,
+		Tags:       req.Selection.Tags,
+	}
+	if filter.IsEmpty() && !req.Selection.All {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, `select guests by ids, rsvp_status, family_side or tags, or set "all" to select every guest`))
+		return
+	}
+	action := models.BulkAction{Action: req.Action, Update: req.Update}
+
+	if req.Preview {
+		preview, err := h.Service.PreviewBulk(ctx.Request.Context(), filter, action)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.JSON(http.StatusOK, preview)
+		return
+	}
+
+	if req.ConfirmCount == nil {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, "confirm_count is required: preview the action first and repeat its count"))
+		return
+	}
+	result, err := h.Service.RunBulk(ctx.Request.Context(), filter, action, *req.ConfirmCount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CheckInHandler records arrivals at the door and reports attendance so far
+type CheckInHandler struct {
+	Service *service.CheckInService
+}
+
+// NewCheckInHandler initializes a new check-in handler
+func NewCheckInHandler(service *service.CheckInService) *CheckInHandler {
+	return &CheckInHandler{Service: service}
+}
+
+// CheckIn records arriving guests at the door, identified by a scanned QR
+// code, a typed RSVP token or code, or a guest ID picked from the list.
+// Arrivals are counted per party: "arrived" says how many of the guest's
+// party came through, not which of them, since party members are not
+// recorded by name.
+func (h *CheckInHandler) CheckIn(ctx *gin.Context) {
+	var req struct {
+		RSVPToken string `json:"rsvp_token"` // token, short code or scanned RSVP link
+		GuestID   string `json:"guest_id"`
+		Arrived   int    `json:"arrived" binding:"gte=0"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	checkIn := service.CheckInRequest{Credential: req.RSVPToken, Arrived: req.Arrived}
+	if req.GuestID != "" {
+		id, err := uuid.Parse(req.GuestID)
+		if err != nil {
+			ctx.Error(apperrors.New(apperrors.ErrValidation, "invalid guest ID"))
+			return
+		}
+		checkIn.GuestID = id
+	}
+
+	result, err := h.Service.CheckIn(ctx.Request.Context(), checkIn)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// CheckInSummary reports arrivals against expected attendance
+func (h *CheckInHandler) CheckInSummary(ctx *gin.Context) {
+	summary, err := h.Service.GetCheckInSummary(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Door staff poll this during the event; never serve a stale count
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, summary)
+}
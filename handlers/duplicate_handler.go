@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DuplicateHandler finds guests entered twice and merges them
+type DuplicateHandler struct {
+	Service *service.DuplicateService
+}
+
+// NewDuplicateHandler initializes a new duplicate handler
+func NewDuplicateHandler(service *service.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{Service: service}
+}
+
+// mergeRequest names the guest to keep and the duplicate folded into it.
+// Versions are optional; when given they must match the current ones.
+type mergeRequest struct {
+	PrimaryID        uuid.UUID `json:"primary_id" binding:"required"`
+	DuplicateID      uuid.UUID `json:"duplicate_id" binding:"required"`
+	PrimaryVersion   int       `json:"primary_version"`
+	DuplicateVersion int       `json:"duplicate_version"`
+}
+
+// FindDuplicateGuests lists pairs of guests that were probably entered twice,
+// most alike first. min_score, from 0 to 1, sets how alike they must be.
+func (h *DuplicateHandler) FindDuplicateGuests(ctx *gin.Context) {
+	minScore := models.DefaultDuplicateScore
+	if raw := ctx.Query("min_score"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			ctx.Error(apperrors.New(apperrors.ErrValidation, "min_score must be a number"))
+			return
+		}
+		minScore = parsed
+	}
+
+	candidates, err := h.Service.FindDuplicates(ctx.Request.Context(), minScore)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, candidates)
+}
+
+// MergeGuests folds a duplicate guest into the one kept, moving its RSVP,
+// hongbao, tags, sub-event invitations and check-ins, and deletes it
+func (h *DuplicateHandler) MergeGuests(ctx *gin.Context) {
+	var req mergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	guest, merge, err := h.Service.MergeGuests(ctx.Request.Context(), req.PrimaryID, req.DuplicateID, req.PrimaryVersion, req.DuplicateVersion)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", guestETag(guest.Version))
+	ctx.JSON(http.StatusOK, gin.H{"guest": guest, "merge": merge})
+}
+
+// GetGuestMerges lists the merges made so far, newest first, with both guests as they were
+func (h *DuplicateHandler) GetGuestMerges(ctx *gin.Context) {
+	merges, err := h.Service.GetGuestMerges(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if merges == nil {
+		merges = []models.GuestMerge{}
+	}
+	ctx.JSON(http.StatusOK, merges)
+}
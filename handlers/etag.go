@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// guestETag renders a guest version as a strong entity tag
+func guestETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// requireIfMatch reads the If-Match precondition for a write on a guest.
+// It returns the expected version, or 0 for "*" meaning any current version.
+func requireIfMatch(ctx *gin.Context) (int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		return 0, apperrors.New(apperrors.ErrPreconditionRequired, "If-Match header is required")
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// If-Match uses strong comparison, so weak tags can never match
+	if strings.HasPrefix(header, "W/") || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return 0, apperrors.New(apperrors.ErrPreconditionFailed, "If-Match must be a single strong ETag")
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return 0, apperrors.New(apperrors.ErrPreconditionFailed, "If-Match does not match the current ETag")
+	}
+
+	return version, nil
+}
+
+// optionalIfMatch reads an If-Match precondition that clients may omit.
+// It returns 0, meaning unconditional, when the header is absent.
+func optionalIfMatch(ctx *gin.Context) (int, error) {
+	if strings.TrimSpace(ctx.GetHeader("If-Match")) == "" {
+		return 0, nil
+	}
+	return requireIfMatch(ctx)
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches the given ETag
+func ifNoneMatch(ctx *gin.Context, etag string) bool {
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
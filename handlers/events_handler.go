@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// eventTypes lists the event types clients may filter on
+var eventTypes = []string{events.GuestCreated, events.GuestUpdated, events.GuestDeleted, events.GuestRSVP, events.GuestCheckedIn}
+
+// reconnectDelay is the retry interval suggested to EventSource clients
+const reconnectDelay = 3 * time.Second
+
+// EventsHandler streams guest changes to the admin dashboard
+type EventsHandler struct {
+	Broker *events.Broker
+	// Heartbeat is how often an idle stream sends a comment so proxies keep it open
+	Heartbeat time.Duration
+}
+
+// NewEventsHandler initializes a new events handler
+func NewEventsHandler(broker *events.Broker, heartbeat time.Duration) *EventsHandler {
+	return &EventsHandler{Broker: broker, Heartbeat: heartbeat}
+}
+
+// parseEventFilter reads the types (comma-separated) and guest_id parameters
+func parseEventFilter(ctx *gin.Context) (events.Filter, error) {
+	var filter events.Filter
+	for _, param := range ctx.QueryArray("types") {
+		for _, t := range strings.Split(param, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(eventTypes, t) {
+				return filter, apperrors.Newf(apperrors.ErrValidation, "unknown event type %q; must be one of %s", t, strings.Join(eventTypes, ", "))
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	if raw := ctx.Query("guest_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, apperrors.New(apperrors.ErrValidation, "invalid guest ID")
+		}
+		filter.GuestID = id
+	}
+	return filter, nil
+}
+
+// Stream sends guest changes as Server-Sent Events until the client
+// disconnects or the server shuts down. Reconnecting clients get the events
+// they missed from the Last-Event-ID header (or last_event_id parameter, for
+// clients that cannot set headers); if those are no longer retained a reset
+// event tells them to reload their data.
+func (h *EventsHandler) Stream(ctx *gin.Context) {
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+
+	sub, replay, complete := h.Broker.Subscribe(lastEventID, filter)
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout, which would otherwise cut it off
+	logger := logging.FromContext(ctx.Request.Context())
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Debug("cannot clear write deadline for event stream", slog.Any("error", err))
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	ctx.Status(http.StatusOK)
+
+	w := ctx.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		if err := writeEvent(w, e); err != nil {
+			logger.Warn("failed to encode event", slog.String("event_id", e.ID), slog.Any("error", err))
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, open := <-sub.C:
+			if !open {
+				// Shutting down, or the client fell behind; it reconnects with its last event ID
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				logger.Warn("failed to encode event", slog.String("event_id", e.ID), slog.Any("error", err))
+				continue
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case <-ctx.Request.Context().Done():
+			return
+		}
+		w.Flush()
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w io.Writer, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/logging"
 	"github.com/g4l1l10/rsvp-backend/service"
 
 	"github.com/g4l1l10/rsvp-backend/models"
@@ -11,7 +16,8 @@ import (
 	"github.com/google/uuid"
 )
 
-// GuestHandler handles business logic for guest operations
+// GuestHandler handles business logic for guest operations.
+// Failures are recorded with ctx.Error and rendered by middlewares.ErrorHandler.
 type GuestHandler struct {
 	Service *service.GuestService
 }
@@ -21,35 +27,46 @@ func NewGuestHandler(service *service.GuestService) *GuestHandler {
 	return &GuestHandler{Service: service}
 }
 
+// bindingError classifies a request binding failure as a validation error
+func bindingError(err error) error {
+	return apperrors.Wrap(apperrors.ErrValidation, err, err.Error())
+}
+
+// parseGuestID reads the :id path parameter
+func parseGuestID(ctx *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, apperrors.New(apperrors.ErrValidation, "invalid guest ID")
+	}
+	return id, nil
+}
+
 // AddGuest handles adding a new guest
 func (h *GuestHandler) AddGuest(ctx *gin.Context) {
-	log.Println("📥 Received request to add guest")
-
 	var req struct {
 		Name        string `json:"name" binding:"required"`
 		Email       string `json:"email" binding:"required,email"`
 		FamilySide  string `json:"family_side" binding:"required"`
 		TotalGuests int    `json:"total_guests" binding:"required,gt=0"`
+
+		PreferredLanguage string `json:"preferred_language"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Error binding request:", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
-	guest, err := h.Service.AddGuest(req.Name, req.Email, req.FamilySide, req.TotalGuests)
+	guest, err := h.Service.AddGuest(ctx.Request.Context(), req.Name, req.Email, req.FamilySide, req.TotalGuests, req.PreferredLanguage)
 	if err != nil {
-		log.Println("❌ Failed to add guest:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	log.Println("✅ Guest successfully added:", guest.ID)
+	logging.FromContext(ctx.Request.Context()).Info("guest added", slog.String("guest_id", guest.ID.String()))
 	ctx.JSON(http.StatusCreated, guest)
 }
 
-// SendInvite handles sending an RSVP invitation email
 // SendInvite handles sending an RSVP invitation email
 func (h *GuestHandler) SendInvite(ctx *gin.Context) {
 	var req struct {
@@ -57,42 +74,58 @@ func (h *GuestHandler) SendInvite(ctx *gin.Context) {
 		Email       string `json:"email" binding:"required,email"`
 		FamilySide  string `json:"family_side" binding:"required"`
 		TotalGuests int    `json:"total_guests" binding:"required,gt=0"`
+
+		PreferredLanguage string `json:"preferred_language"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		ctx.Error(bindingError(err))
 		return
 	}
 
 	// Check if guest already exists
-	existingGuest, _ := h.Service.GetGuestByEmail(req.Email)
+	existingGuest, err := h.Service.GetGuestByEmail(ctx.Request.Context(), req.Email)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		ctx.Error(err)
+		return
+	}
 	if existingGuest != nil {
-		ctx.JSON(http.StatusConflict, gin.H{"error": "Guest already exists"})
+		ctx.Error(apperrors.New(apperrors.ErrConflict, "guest already exists"))
 		return
 	}
 
-	// ✅ Now, we add the guest *without sending an email here*
-	guest, err := h.Service.AddGuest(req.Name, req.Email, req.FamilySide, req.TotalGuests)
+	guest, err := h.Service.AddGuest(ctx.Request.Context(), req.Name, req.Email, req.FamilySide, req.TotalGuests, req.PreferredLanguage)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store guest"})
+		ctx.Error(err)
 		return
 	}
 
-	// ✅ Email only sent here now
-	err = h.Service.SendInvitation(guest)
+	err = h.Service.SendInvitation(ctx.Request.Context(), guest)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send invitation"})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation sent successfully"})
 }
 
-// GetAllGuests retrieves all guests
+// GetAllGuests retrieves all guests, or those selected by the same query
+// parameters as the invitation sheets, such as ?tag=VIP
 func (h *GuestHandler) GetAllGuests(ctx *gin.Context) {
-	guests, err := h.Service.GetAllGuests()
+	filter, err := parseGuestFilter(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	var guests []models.Guest
+	if filter.IsEmpty() {
+		guests, err = h.Service.GetAllGuests(ctx.Request.Context())
+	} else {
+		guests, err = h.Service.FindGuests(ctx.Request.Context(), filter)
+	}
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 	ctx.JSON(http.StatusOK, guests)
@@ -100,16 +133,22 @@ func (h *GuestHandler) GetAllGuests(ctx *gin.Context) {
 
 // GetGuestByID retrieves a guest by their UUID
 func (h *GuestHandler) GetGuestByID(ctx *gin.Context) {
-	idParam := ctx.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := parseGuestID(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guest ID"})
+		ctx.Error(err)
 		return
 	}
 
-	guest, err := h.Service.GetGuestByID(id)
+	guest, err := h.Service.GetGuestByID(ctx.Request.Context(), id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	etag := guestETag(guest.Version)
+	ctx.Header("ETag", etag)
+	if ifNoneMatch(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
 		return
 	}
 
@@ -119,9 +158,9 @@ func (h *GuestHandler) GetGuestByID(ctx *gin.Context) {
 // GetGuestByEmail retrieves a guest by their email
 func (h *GuestHandler) GetGuestByEmail(ctx *gin.Context) {
 	email := ctx.Param("email")
-	guest, err := h.Service.GetGuestByEmail(email)
+	guest, err := h.Service.GetGuestByEmail(ctx.Request.Context(), email)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -131,97 +170,246 @@ func (h *GuestHandler) GetGuestByEmail(ctx *gin.Context) {
 // GetGuestByToken retrieves a guest by their RSVP token
 func (h *GuestHandler) GetGuestByToken(ctx *gin.Context) {
 	token := ctx.Param("token")
-	guest, err := h.Service.GetGuestByToken(token)
+	guest, err := h.Service.GetGuestByToken(ctx.Request.Context(), token)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, guest)
 }
 
-// UpdateGuest updates a guest's information
+// RSVPPage shows a guest their invitation by RSVP token or code, with the
+// page's labels and sub-events in their language: ?lang= when given, else
+// the guest's preferred language, else the best match for Accept-Language
+func (h *GuestHandler) RSVPPage(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	page, err := h.Service.RSVPPage(ctx.Request.Context(), ctx.Param("token"), ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Language", page.Language)
+	ctx.JSON(http.StatusOK, page)
+}
+
+// UpdateGuest replaces a guest's information; every editable field must be provided
 func (h *GuestHandler) UpdateGuest(ctx *gin.Context) {
-	idParam := ctx.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := parseGuestID(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guest ID"})
+		ctx.Error(err)
 		return
 	}
 
+	version, err := requireIfMatch(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Pointers let "required" distinguish a missing field from a zero value
 	var req struct {
-		Name        string  `json:"name"`
-		Email       string  `json:"email"`
-		FamilySide  string  `json:"family_side"`
-		Hongbao     float64 `json:"hongbao"`
-		TotalGuests int     `json:"total_guests"`
-		RSVPStatus  string  `json:"rsvp_status"`
+		Name        *string  `json:"name" binding:"required"`
+		Email       *string  `json:"email" binding:"required"`
+		FamilySide  *string  `json:"family_side" binding:"required"`
+		Hongbao     *float64 `json:"hongbao" binding:"required"`
+		TotalGuests *int     `json:"total_guests" binding:"required"`
+		RSVPStatus  *string  `json:"rsvp_status" binding:"required"`
+
+		PreferredLanguage string `json:"preferred_language"` // Optional; omitting it clears the preference
+		Phone             string `json:"phone"`              // Optional; omitting it clears the number
 	}
 
 	if err = ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
 	guest := &models.Guest{
 		ID:          id,
-		Name:        req.Name,
-		Email:       req.Email,
-		FamilySide:  req.FamilySide,
-		Hongbao:     req.Hongbao,
-		TotalGuests: req.TotalGuests,
-		RSVPStatus:  req.RSVPStatus,
+		Name:        *req.Name,
+		Email:       *req.Email,
+		FamilySide:  *req.FamilySide,
+		Hongbao:     *req.Hongbao,
+		TotalGuests: *req.TotalGuests,
+		RSVPStatus:  *req.RSVPStatus,
+		Version:     version,
+
+		PreferredLanguage: req.PreferredLanguage,
+		Phone:             req.Phone,
 	}
 
-	err = h.Service.UpdateGuest(guest)
+	err = h.Service.UpdateGuest(ctx.Request.Context(), guest)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", guestETag(guest.Version))
 	ctx.JSON(http.StatusOK, gin.H{"message": "guest updated successfully"})
 }
 
+// PatchGuest applies a JSON Merge Patch (RFC 7396) to a guest. Read-only
+// members a GET returns (id, rsvp_token, rsvp_code, version, tags and
+// household_id) are ignored, so a fetched guest can be sent back edited.
+func (h *GuestHandler) PatchGuest(ctx *gin.Context) {
+	id, err := parseGuestID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	version, err := requireIfMatch(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	switch ctx.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		ctx.Error(apperrors.New(apperrors.ErrUnsupportedMediaType, "content type must be application/merge-patch+json"))
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Error(apperrors.Wrap(apperrors.ErrValidation, err, "failed to read request body"))
+		return
+	}
+
+	var patch models.GuestPatch
+	if err = json.Unmarshal(body, &patch); err != nil {
+		// Errors from GuestPatch itself already say what is wrong; syntax errors do not
+		if !errors.Is(err, apperrors.ErrValidation) {
+			err = apperrors.Wrap(apperrors.ErrValidation, err, "request body must be a JSON object")
+		}
+		ctx.Error(err)
+		return
+	}
+
+	guest, err := h.Service.PatchGuest(ctx.Request.Context(), id, &patch, version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", guestETag(guest.Version))
+	ctx.JSON(http.StatusOK, guest)
+}
+
 // DeleteGuest removes a guest
 func (h *GuestHandler) DeleteGuest(ctx *gin.Context) {
-	idParam := ctx.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := parseGuestID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	version, err := requireIfMatch(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guest ID"})
+		ctx.Error(err)
 		return
 	}
 
-	err = h.Service.DeleteGuest(id)
+	err = h.Service.DeleteGuest(ctx.Request.Context(), id, version)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "guest deleted successfully"})
 }
 
-// SubmitRSVP allows guests to confirm attendance using their RSVP token
+// SubmitRSVP allows guests to confirm attendance using their RSVP token.
+// Guests invited to sub-events may instead answer for each of them in
+// sub_events, in which case their overall RSVP follows from those answers.
 func (h *GuestHandler) SubmitRSVP(ctx *gin.Context) {
 	var req struct {
-		RSVPToken   string `json:"rsvp_token" binding:"required"`
-		RSVPStatus  string `json:"rsvp_status" binding:"required"`
-		TotalGuests int    `json:"total_guests" binding:"required,gt=0"`
+		RSVPToken   string                  `json:"rsvp_token" binding:"required"`
+		RSVPStatus  string                  `json:"rsvp_status"`
+		TotalGuests int                     `json:"total_guests"`
+		SubEvents   []models.SubEventAnswer `json:"sub_events"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Error binding RSVP request:", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	if req.SubEvents != nil {
+		if h.Service.SubEvents == nil {
+			ctx.Error(apperrors.New(apperrors.ErrValidation, "there are no sub-events to answer"))
+			return
+		}
+		err := h.Service.SubEvents.RespondToSubEvents(ctx.Request.Context(), req.RSVPToken, req.SubEvents)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		logging.FromContext(ctx.Request.Context()).Info("RSVP updated", slog.Int("sub_events", len(req.SubEvents)))
+		ctx.JSON(http.StatusOK, gin.H{"message": "RSVP updated successfully"})
+		return
+	}
+
+	if req.RSVPStatus == "" || req.TotalGuests <= 0 {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, "rsvp_status and a total_guests greater than zero are required"))
 		return
 	}
 
 	// Update RSVP status in the database
-	err := h.Service.UpdateRSVP(req.RSVPToken, req.RSVPStatus, req.TotalGuests)
+	err := h.Service.UpdateRSVP(ctx.Request.Context(), req.RSVPToken, req.RSVPStatus, req.TotalGuests)
 	if err != nil {
-		log.Println("❌ Failed to update RSVP:", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	log.Println("✅ RSVP successfully updated for token:", req.RSVPToken)
+	logging.FromContext(ctx.Request.Context()).Info("RSVP updated", slog.String("rsvp_status", req.RSVPStatus), slog.Int("total_guests", req.TotalGuests))
 	ctx.JSON(http.StatusOK, gin.H{"message": "RSVP updated successfully"})
 }
+
+// RegenerateRSVPToken issues a guest a new RSVP token, e.g. after the old one leaked
+func (h *GuestHandler) RegenerateRSVPToken(ctx *gin.Context) {
+	h.changeCredential(ctx, h.Service.RegenerateRSVPToken)
+}
+
+// RevokeRSVPToken disables a guest's RSVP token
+func (h *GuestHandler) RevokeRSVPToken(ctx *gin.Context) {
+	h.changeCredential(ctx, h.Service.RevokeRSVPToken)
+}
+
+// RegenerateRSVPCode issues a guest a new short RSVP code
+func (h *GuestHandler) RegenerateRSVPCode(ctx *gin.Context) {
+	h.changeCredential(ctx, h.Service.RegenerateRSVPCode)
+}
+
+// RevokeRSVPCode disables a guest's short RSVP code
+func (h *GuestHandler) RevokeRSVPCode(ctx *gin.Context) {
+	h.changeCredential(ctx, h.Service.RevokeRSVPCode)
+}
+
+// changeCredential runs one of the RSVP credential operations for the :id
+// guest and responds with the updated guest. If-Match is honoured when sent.
+func (h *GuestHandler) changeCredential(ctx *gin.Context, change func(context.Context, uuid.UUID, int) (*models.Guest, error)) {
+	id, err := parseGuestID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	version, err := optionalIfMatch(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	guest, err := change(ctx.Request.Context(), id, version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", guestETag(guest.Version))
+	ctx.JSON(http.StatusOK, guest)
+}
@@ -0,0 +1,1008 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/handlers"
+	"github.com/g4l1l10/rsvp-backend/health"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/metrics"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/ratelimit"
+	"github.com/g4l1l10/rsvp-backend/repository"
+	"github.com/g4l1l10/rsvp-backend/routes"
+	"github.com/g4l1l10/rsvp-backend/service"
+	"github.com/g4l1l10/rsvp-backend/tokens"
+	"github.com/g4l1l10/rsvp-backend/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ctx is the background context shared by tests that do not exercise cancellation
+var ctx = context.Background()
+
+const testAdminToken = "test-admin-token"
+
+// testServer wires the real routes to an in-memory store and a fake auth service
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	svc    *service.GuestService
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAdminToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(auth.Close)
+
+	cfg := config.Default()
+	cfg.Auth.ServiceURL = auth.URL
+
+	signer, err := tokens.NewSigner([]tokens.Key{{ID: "test", Secret: []byte(strings.Repeat("k", tokens.MinKeyLength))}}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	repo := repository.NewMemoryGuestRepository()
+	svc := service.NewGuestService(repo, nil, signer)
+	svc.PublicURL = cfg.PublicURL
+	svc.Events = events.NewBroker(cfg.Events.ReplayBuffer)
+	svc.Webhooks = webhooks.NewDispatcher(repo, cfg.Webhooks)
+	svc.SubEvents = service.NewSubEventService(repo, svc)
+	svc.Tags = service.NewTagService(repo, svc)
+	router := gin.New()
+	routes.SetupRoutes(router, cfg, routes.Handlers{
+		Guests:     handlers.NewGuestHandler(svc),
+		Events:     handlers.NewEventsHandler(svc.Events, cfg.Events.Heartbeat),
+		Webhooks:   handlers.NewWebhookHandler(service.NewWebhookService(repo, svc.Webhooks)),
+		SubEvents:  handlers.NewSubEventHandler(svc.SubEvents),
+		Tags:       handlers.NewTagHandler(svc.Tags),
+		Households: handlers.NewHouseholdHandler(service.NewHouseholdService(repo, svc)),
+		CheckIns:   handlers.NewCheckInHandler(service.NewCheckInService(repo, svc)),
+		Duplicates: handlers.NewDuplicateHandler(service.NewDuplicateService(repo, svc)),
+	}, health.NewRegistry(0), ratelimit.NewMemoryStore())
+
+	return &testServer{t: t, router: router, svc: svc}
+}
+
+// seed adds a guest directly through the service
+func (s *testServer) seed() *models.Guest {
+	s.t.Helper()
+	guest, err := s.svc.AddGuest(ctx, "Aunt May", "may@example.com", "Bride", 2, "")
+	if err != nil {
+		s.t.Fatalf("AddGuest: %v", err)
+	}
+	return guest
+}
+
+// do performs a request against the router; headers are given as key/value pairs
+func (s *testServer) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *testServer) admin(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do(method, path, body, append([]string{"Authorization", "Bearer " + testAdminToken}, headers...)...)
+}
+
+func TestAdminRequiresAuth(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"valid token", "Bearer " + testAdminToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec *httptest.ResponseRecorder
+			if tt.header == "" {
+				rec = srv.do(http.MethodGet, "/admin/guests", "")
+			} else {
+				rec = srv.do(http.MethodGet, "/admin/guests", "", "Authorization", tt.header)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGuestByIDETag(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	rec := srv.admin(http.MethodGet, "/admin/guests/"+guest.ID.String(), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q", etag)
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/guests/"+guest.ID.String(), "", "If-None-Match", etag)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("conditional GET status = %d, want 304", rec.Code)
+	}
+}
+
+func TestPatchGuest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		ifMatch  string
+		ctype    string
+		want     int
+		wantSide string
+	}{
+		{"clears family side", `{"family_side": null}`, `"1"`, "application/merge-patch+json", http.StatusOK, ""},
+		{"wildcard precondition", `{"family_side": "Groom"}`, "*", "application/merge-patch+json", http.StatusOK, "Groom"},
+		{"missing If-Match", `{"family_side": "Groom"}`, "", "application/merge-patch+json", http.StatusPreconditionRequired, "Bride"},
+		{"stale If-Match", `{"family_side": "Groom"}`, `"7"`, "application/merge-patch+json", http.StatusPreconditionFailed, "Bride"},
+		{"null required field", `{"name": null}`, `"1"`, "application/merge-patch+json", http.StatusBadRequest, "Bride"},
+		{"read-only fields ignored", `{"id": "x", "version": 9, "tags": ["VIP"], "household_id": null, "family_side": "Groom"}`, `"1"`, "application/merge-patch+json", http.StatusOK, "Groom"},
+		{"unknown field", `{"nickname": "May"}`, `"1"`, "application/merge-patch+json", http.StatusBadRequest, "Bride"},
+		{"invalid status", `{"rsvp_status": "Maybe"}`, `"1"`, "application/merge-patch+json", http.StatusBadRequest, "Bride"},
+		{"wrong content type", `{"family_side": "Groom"}`, `"1"`, "text/plain", http.StatusUnsupportedMediaType, "Bride"},
+		{"malformed JSON", `{`, `"1"`, "application/merge-patch+json", http.StatusBadRequest, "Bride"},
+		{"not an object", `[1]`, `"1"`, "application/merge-patch+json", http.StatusBadRequest, "Bride"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			guest := srv.seed()
+
+			headers := []string{"Content-Type", tt.ctype}
+			if tt.ifMatch != "" {
+				headers = append(headers, "If-Match", tt.ifMatch)
+			}
+			rec := srv.admin(http.MethodPatch, "/admin/guests/"+guest.ID.String(), tt.body, headers...)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusBadRequest && rec.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", rec.Header().Get("Content-Type"))
+			}
+
+			stored, _ := srv.svc.GetGuestByID(ctx, guest.ID)
+			if stored.FamilySide != tt.wantSide {
+				t.Errorf("family_side = %q, want %q", stored.FamilySide, tt.wantSide)
+			}
+			if tt.want == http.StatusOK && rec.Header().Get("ETag") != `"2"` {
+				t.Errorf("ETag = %q, want \"2\"", rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestUpdateGuestIsFullReplacement(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"complete body", `{"name":"May","email":"may@example.com","family_side":"","hongbao":0,"total_guests":1,"rsvp_status":"Attending"}`, http.StatusOK},
+		{"missing hongbao", `{"name":"May","email":"may@example.com","family_side":"","total_guests":1,"rsvp_status":"Attending"}`, http.StatusBadRequest},
+		{"invalid email", `{"name":"May","email":"nope","family_side":"","hongbao":0,"total_guests":1,"rsvp_status":"Attending"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			guest := srv.seed()
+
+			rec := srv.admin(http.MethodPut, "/admin/guests/"+guest.ID.String(), tt.body, "If-Match", `"1"`)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteGuest(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	path := "/admin/guests/" + guest.ID.String()
+
+	if rec := srv.admin(http.MethodDelete, path, ""); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("delete without If-Match status = %d", rec.Code)
+	}
+	if rec := srv.admin(http.MethodDelete, path, "", "If-Match", `"1"`); rec.Code != http.StatusOK {
+		t.Errorf("delete status = %d", rec.Code)
+	}
+	if rec := srv.admin(http.MethodDelete, path, "", "If-Match", "*"); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d", rec.Code)
+	}
+}
+
+func TestSubmitRSVP(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"rsvp_token":   guest.RSVPToken,
+		"rsvp_status":  models.RSVPStatusAttending,
+		"total_guests": 2,
+	})
+	if rec := srv.do(http.MethodPost, "/rsvp/", string(body)); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var got models.Guest
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.RSVPStatus != models.RSVPStatusAttending {
+		t.Errorf("rsvp_status = %q", got.RSVPStatus)
+	}
+}
+
+func TestExpiredRequestContextReturnsGatewayTimeout(t *testing.T) {
+	srv := newTestServer(t)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	guest := srv.seed()
+	req := httptest.NewRequest(http.MethodGet, "/rsvp/"+guest.RSVPToken, nil).WithContext(expired)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+}
+
+func TestErrorsAreProblemDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		admin      bool
+		wantStatus int
+		wantDetail string
+	}{
+		{"unknown guest", http.MethodGet, "/admin/guests/00000000-0000-0000-0000-000000000001", "", true, http.StatusNotFound, "guest not found"},
+		{"malformed guest ID", http.MethodGet, "/admin/guests/nope", "", true, http.StatusBadRequest, "invalid guest ID"},
+		{"invalid RSVP token", http.MethodGet, "/rsvp/nope", "", false, http.StatusNotFound, "invalid RSVP token"},
+		{"submit with invalid token", http.MethodPost, "/rsvp/", `{"rsvp_token":"nope","rsvp_status":"Attending","total_guests":1}`, false, http.StatusNotFound, "invalid RSVP token"},
+		{"submit with invalid status", http.MethodPost, "/rsvp/", `{"rsvp_token":"nope","rsvp_status":"Maybe","total_guests":1}`, false, http.StatusBadRequest, ""},
+		{"missing credentials", http.MethodGet, "/admin/guests", "", false, http.StatusUnauthorized, "missing token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+
+			var rec *httptest.ResponseRecorder
+			if tt.admin {
+				rec = srv.admin(tt.method, tt.path, tt.body)
+			} else {
+				rec = srv.do(tt.method, tt.path, tt.body)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			var problem struct {
+				Type     string `json:"type"`
+				Title    string `json:"title"`
+				Status   int    `json:"status"`
+				Detail   string `json:"detail"`
+				Instance string `json:"instance"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if problem.Status != tt.wantStatus || problem.Title != http.StatusText(tt.wantStatus) || problem.Instance != tt.path {
+				t.Errorf("unexpected problem: %+v", problem)
+			}
+			if tt.wantDetail != "" && problem.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", problem.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestMetricsUseRouteTemplates(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, "")
+	before := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/rsvp/:token", "200").Value()
+	srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, "")
+
+	if after := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/rsvp/:token", "200").Value(); after != before+1 {
+		t.Errorf("request counter = %v, want %v", after, before+1)
+	}
+
+	rec := srv.do(http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("scrape status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if strings.Contains(rec.Body.String(), guest.RSVPToken) {
+		t.Error("raw request paths leaked into metric labels")
+	}
+	if !strings.Contains(rec.Body.String(), `rsvp_http_requests_total{method="GET",route="/rsvp/:token",status="200"}`) {
+		t.Error("route template missing from exposition")
+	}
+}
+
+func TestRequestIDAndRedactedLogs(t *testing.T) {
+	var logs strings.Builder
+	logger, err := logging.New(&logs, "debug")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, "", "X-Request-ID", "req-123")
+	if got := rec.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("X-Request-ID = %q, want the client's ID echoed", got)
+	}
+	rec = srv.admin(http.MethodGet, "/admin/guests/email/"+guest.Email, "", "X-Request-ID", "bad id with spaces")
+	if got := rec.Header().Get("X-Request-ID"); got == "" || got == "bad id with spaces" {
+		t.Errorf("X-Request-ID = %q, want a generated ID", got)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, `"request_id":"req-123"`) {
+		t.Errorf("access log missing request ID:\n%s", out)
+	}
+	for _, secret := range []string{guest.RSVPToken, guest.Email, testAdminToken} {
+		if strings.Contains(out, secret) {
+			t.Errorf("logs contain %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestRSVPRateLimiting(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	// Default policy: five invalid lookups per IP, then the IP is blocked
+	for i := 0; i < 5; i++ {
+		if rec := srv.do(http.MethodGet, "/rsvp/not-a-real-token", ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("lookup %d: status = %d, want 404", i+1, rec.Code)
+		}
+	}
+
+	rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after repeated invalid lookups = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("429 response without Retry-After")
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/problem+json") {
+		t.Errorf("Content-Type = %q, want problem+json", rec.Header().Get("Content-Type"))
+	}
+
+	// Other clients are unaffected
+	req := httptest.NewRequest(http.MethodGet, "/rsvp/"+guest.RSVPToken, nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status for another IP = %d, want 200", rec.Code)
+	}
+}
+
+func TestRSVPCredentialRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	if rec := srv.do(http.MethodGet, "/rsvp/"+strings.ToLower(guest.RSVPCode), ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET by code: status = %d, want 200", rec.Code)
+	}
+
+	rec := srv.admin(http.MethodPost, "/admin/guests/"+guest.ID.String()+"/rsvp-token", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("regenerate token: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var updated models.Guest
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+	if updated.RSVPToken == guest.RSVPToken || rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("token not regenerated: %+v, ETag %s", updated, rec.Header().Get("ETag"))
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPToken, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("old token: status = %d, want 404", rec.Code)
+	}
+
+	rec = srv.admin(http.MethodDelete, "/admin/guests/"+guest.ID.String()+"/rsvp-code", "", "If-Match", `"1"`)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("revoke with stale If-Match: status = %d, want 412", rec.Code)
+	}
+	rec = srv.admin(http.MethodDelete, "/admin/guests/"+guest.ID.String()+"/rsvp-code", "", "If-Match", `"2"`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke code: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("revoked code: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPrintableInvitations(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	if _, err := srv.svc.AddGuest(ctx, "Uncle Ben", "ben@example.com", "Groom", 1, ""); err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	qrPath := "/admin/guests/" + guest.ID.String() + "/qr.png"
+
+	rec := srv.admin(http.MethodGet, qrPath+"?size=300", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("QR code: status = %d, headers = %v", rec.Code, rec.Header())
+	}
+	if _, err := png.Decode(rec.Body); err != nil {
+		t.Errorf("QR code is not a PNG: %v", err)
+	}
+	if rec := srv.admin(http.MethodGet, qrPath+"?size=5", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("tiny QR code: status = %d, want 400", rec.Code)
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/invitations.pdf?family_side=Bride", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+		t.Fatalf("PDF: status = %d, headers = %v", rec.Code, rec.Header())
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/invitations.pdf?rsvp_status=Maybe", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid filter: status = %d, want 400", rec.Code)
+	}
+
+	// A guest without a code cannot be printed
+	if _, err := srv.svc.RevokeRSVPCode(ctx, guest.ID, 0); err != nil {
+		t.Fatalf("RevokeRSVPCode: %v", err)
+	}
+	if rec := srv.admin(http.MethodGet, qrPath, ""); rec.Code != http.StatusConflict {
+		t.Errorf("QR code without RSVP code: status = %d, want 409", rec.Code)
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/invitations.pdf?id="+guest.ID.String(), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("PDF with nothing printable: status = %d, want 404", rec.Code)
+	}
+	rec = srv.admin(http.MethodGet, "/admin/invitations.pdf", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Skipped-Guests") != "1" {
+		t.Errorf("PDF of all guests: status = %d, skipped = %q", rec.Code, rec.Header().Get("X-Skipped-Guests"))
+	}
+}
+
+func TestCheckInRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	rec := srv.admin(http.MethodPost, "/admin/checkin", `{"rsvp_token":"`+guest.RSVPToken+`","arrived":2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("check-in: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var result models.CheckInResult
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result.Arrived != 2 || result.Guest == nil || result.Guest.ID != guest.ID || len(result.Warnings) != 1 {
+		t.Errorf("unexpected check-in result: %s", rec.Body)
+	}
+
+	rec = srv.admin(http.MethodPost, "/admin/checkin", `{"guest_id":"`+guest.ID.String()+`"}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), models.CheckInWarningExceedsParty) {
+		t.Errorf("duplicate check-in: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/checkin", `{"guest_id":"not-a-uuid"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid guest ID: status = %d, want 400", rec.Code)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/checkin", `{"rsvp_token":"ZZZZ2222"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown code: status = %d, want 404", rec.Code)
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/checkin/summary", "")
+	var summary models.CheckInSummary
+	json.Unmarshal(rec.Body.Bytes(), &summary)
+	if rec.Code != http.StatusOK || summary.ArrivedAttendees != 3 || summary.UnexpectedAttendees != 3 {
+		t.Errorf("summary: status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+// readEvent reads the next event from a text/event-stream, skipping comments
+// and the retry hint
+func readEvent(t *testing.T, stream *bufio.Reader) (id, eventType, data string) {
+	t.Helper()
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && eventType != "":
+			return id, eventType, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newTestServer(t)
+	watch, _, _ := srv.svc.Events.Subscribe("", events.Filter{})
+	guest := srv.seed()
+	created := <-watch.C
+	watch.Close()
+	if err := srv.svc.UpdateRSVP(ctx, guest.RSVPToken, models.RSVPStatusAttending, 2); err != nil {
+		t.Fatalf("UpdateRSVP: %v", err)
+	}
+
+	if rec := srv.admin(http.MethodGet, "/admin/events/stream?types=guest.exploded", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown event type: status = %d, want 400", rec.Code)
+	}
+
+	server := httptest.NewServer(srv.router)
+	defer server.Close()
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL+"/admin/events/stream?types=guest.rsvp,guest.deleted", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	req.Header.Set("Last-Event-ID", created.ID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("opening stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	// The RSVP made before connecting is replayed, without the guest's token
+	_, eventType, data := readEvent(t, stream)
+	if eventType != events.GuestRSVP || !strings.Contains(data, guest.ID.String()) || strings.Contains(data, guest.RSVPToken) {
+		t.Errorf("replayed %s event: %s", eventType, data)
+	}
+
+	// Live events arrive as they happen
+	if err := srv.svc.DeleteGuest(ctx, guest.ID, 0); err != nil {
+		t.Fatalf("DeleteGuest: %v", err)
+	}
+	if _, eventType, _ := readEvent(t, stream); eventType != events.GuestDeleted {
+		t.Errorf("live event = %s, want %s", eventType, events.GuestDeleted)
+	}
+
+	// An ID the server no longer knows asks the client to reload
+	req.Header.Set("Last-Event-ID", "stale-1")
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("reopening stream: %v", err)
+	}
+	defer resp2.Body.Close()
+	if _, eventType, _ := readEvent(t, bufio.NewReader(resp2.Body)); eventType != "reset" {
+		t.Errorf("stale last event ID: first event = %s, want reset", eventType)
+	}
+}
+
+func TestWebhookRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(webhooks.EventHeader))
+	}))
+	defer receiver.Close()
+
+	if rec := srv.admin(http.MethodPost, "/admin/webhooks", `{"url":"`+receiver.URL+`","event_types":["guest.updated"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unsupported event type: status = %d, want 400", rec.Code)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/webhooks", `{"url":"ftp://caterer.example","event_types":["guest.rsvp"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("non-HTTP URL: status = %d, want 400", rec.Code)
+	}
+
+	rec := srv.admin(http.MethodPost, "/admin/webhooks", `{"url":"`+receiver.URL+`","event_types":["guest.rsvp","guest.rsvp"],"description":"Caterer"}`)
+	var created models.Webhook
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if rec.Code != http.StatusCreated || created.Secret == "" || !created.Active || len(created.EventTypes) != 1 {
+		t.Fatalf("create: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	path := "/admin/webhooks/" + created.ID.String()
+	if rec := srv.admin(http.MethodGet, path, ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) {
+		t.Errorf("get: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	rec = srv.admin(http.MethodPost, path+"/test", "")
+	var delivery models.WebhookDelivery
+	json.Unmarshal(rec.Body.Bytes(), &delivery)
+	if rec.Code != http.StatusOK || delivery.Status != models.WebhookDeliverySucceeded || len(received) != 1 || received[0] != webhooks.EventTest {
+		t.Errorf("test fire: status = %d, body = %s, received %v", rec.Code, rec.Body, received)
+	}
+
+	// An RSVP queues a delivery for the background sender
+	guest := srv.seed()
+	if err := srv.svc.UpdateRSVP(ctx, guest.RSVPToken, models.RSVPStatusAttending, 2); err != nil {
+		t.Fatalf("UpdateRSVP: %v", err)
+	}
+	rec = srv.admin(http.MethodGet, path+"/deliveries?limit=10", "")
+	var log []models.WebhookDelivery
+	json.Unmarshal(rec.Body.Bytes(), &log)
+	if rec.Code != http.StatusOK || len(log) != 2 || log[0].EventType != events.GuestRSVP || log[0].Status != models.WebhookDeliveryPending {
+		t.Errorf("deliveries: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), guest.RSVPToken) {
+		t.Error("webhook payload carries the guest's RSVP token")
+	}
+
+	rec = srv.admin(http.MethodPut, path, `{"url":"`+receiver.URL+`","event_types":["guest.created"],"active":false}`)
+	var updated models.Webhook
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+	if rec.Code != http.StatusOK || updated.Active || updated.EventTypes[0] != events.GuestCreated || updated.Secret != "" {
+		t.Errorf("update: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	rec = srv.admin(http.MethodPost, path+"/secret", "")
+	var rotated models.Webhook
+	json.Unmarshal(rec.Body.Bytes(), &rotated)
+	if rec.Code != http.StatusOK || rotated.Secret == "" || rotated.Secret == created.Secret {
+		t.Errorf("rotate secret: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	if rec := srv.admin(http.MethodDelete, path, ""); rec.Code != http.StatusOK {
+		t.Errorf("delete: status = %d", rec.Code)
+	}
+	if rec := srv.admin(http.MethodGet, path+"/deliveries", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deliveries of a deleted webhook: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestGuestCalendarRoute(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	path := "/rsvp/" + guest.RSVPCode + "/calendar.ics"
+
+	if rec := srv.do(http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("before the date is set: status = %d, want 404", rec.Code)
+	}
+
+	srv.svc.Wedding = config.WeddingConfig{
+		Title: "Axel & Daphne's Wedding",
+		Start: time.Date(2026, 6, 20, 8, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 6, 20, 15, 0, 0, 0, time.UTC),
+	}
+	rec := srv.do(http.MethodGet, path, "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if body := rec.Body.String(); !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.Contains(body, "SUMMARY:Axel & Daphne's Wedding\r\n") {
+		t.Errorf("calendar =\n%s", body)
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/ZZZZ2222/calendar.ics", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown code: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestSubEventRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	rec := srv.admin(http.MethodPost, "/admin/sub-events", `{"name":"Tea ceremony","starts_at":"2026-06-20T09:00:00+07:00","ends_at":"2026-06-20T11:00:00+07:00"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var tea models.SubEvent
+	if err := json.Unmarshal(rec.Body.Bytes(), &tea); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/sub-events", `{"name":"Banquet","starts_at":"2026-06-20T18:00:00+07:00","ends_at":"2026-06-20T17:00:00+07:00"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("sub-event ending before it starts: status = %d, want 400", rec.Code)
+	}
+
+	path := "/admin/sub-events/" + tea.ID.String()
+	if rec := srv.admin(http.MethodPost, path+"/invitations", `{"invite":["`+guest.ID.String()+`"]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"invited":1`) {
+		t.Fatalf("invite: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode+"/sub-events", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"rsvp_status":"Pending"`) {
+		t.Errorf("guest's sub-events: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"rsvp_token": guest.RSVPToken,
+		"sub_events": []models.SubEventAnswer{{SubEventID: tea.ID, RSVPStatus: models.RSVPStatusAttending, Attendees: 2}},
+	})
+	if rec := srv.do(http.MethodPost, "/rsvp/", string(body)); rec.Code != http.StatusOK {
+		t.Fatalf("submit: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.do(http.MethodPost, "/rsvp/", `{"rsvp_token":"`+guest.RSVPToken+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("submit without an answer: status = %d, want 400", rec.Code)
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/sub-events/summary", "")
+	var summary []models.SubEventSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil || len(summary) != 1 || summary[0].Attending != 1 || summary[0].ExpectedAttendees != 2 {
+		t.Errorf("summary: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/sub-events/matrix", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"`+tea.ID.String()+`":"Attending"`) {
+		t.Errorf("matrix: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := srv.admin(http.MethodDelete, path, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rec.Code)
+	}
+	if rec := srv.admin(http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("after delete: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestRSVPPageLanguage(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode, "", "Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Language") != "id" {
+		t.Fatalf("status = %d, Content-Language = %q", rec.Code, rec.Header().Get("Content-Language"))
+	}
+	var page models.RSVPPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if page.Name != guest.Name || page.Labels["rsvp.submit"] != "Kirim RSVP" || page.Labels["status.Pending"] != "Menunggu jawaban" {
+		t.Errorf("page = %+v", page)
+	}
+
+	// A preferred language set by the couple wins over the browser
+	rec = srv.admin(http.MethodPatch, "/admin/guests/"+guest.ID.String(), `{"preferred_language":"zh-Hans"}`,
+		"Content-Type", "application/merge-patch+json", "If-Match", `"1"`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"preferred_language":"zh"`) {
+		t.Fatalf("patch: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode, "", "Accept-Language", "id"); rec.Header().Get("Content-Language") != "zh" {
+		t.Errorf("with a preferred language: Content-Language = %q, want zh", rec.Header().Get("Content-Language"))
+	}
+	if rec := srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode+"?lang=en", ""); rec.Header().Get("Content-Language") != "en" {
+		t.Errorf("with ?lang=en: Content-Language = %q, want en", rec.Header().Get("Content-Language"))
+	}
+}
+
+func TestRSVPPageHidesPrivateFields(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	rec := srv.admin(http.MethodPatch, "/admin/guests/"+guest.ID.String(), `{"phone":"+62 812-3456-7890","hongbao":88}`,
+		"Content-Type", "application/merge-patch+json", "If-Match", `"1"`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = srv.do(http.MethodGet, "/rsvp/"+guest.RSVPCode, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var page map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, field := range []string{"id", "email", "phone", "hongbao", "household_id", "version", "tags", "family_side"} {
+		if _, ok := page[field]; ok {
+			t.Errorf("public RSVP page exposes %s", field)
+		}
+	}
+	if page["name"] != "Aunt May" || page["rsvp_status"] != models.RSVPStatusPending || page["total_guests"] != float64(2) {
+		t.Errorf("page = %v", page)
+	}
+	for _, secret := range []string{"may@example.com", "+62 812-3456-7890"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("public RSVP page contains %q", secret)
+		}
+	}
+}
+
+func TestTagAndBulkRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	other, err := srv.svc.AddGuest(ctx, "Uncle Ben", "ben@example.com", "Groom", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	rec := srv.admin(http.MethodPost, "/admin/tags", `{"name":"College friends"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var tag models.Tag
+	if err := json.Unmarshal(rec.Body.Bytes(), &tag); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/tags", `{"name":"college FRIENDS"}`); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate tag: status = %d, want 409", rec.Code)
+	}
+
+	path := "/admin/tags/" + tag.ID.String()
+	if rec := srv.admin(http.MethodPost, path+"/guests", `{"add":["`+guest.ID.String()+`"]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"added":1`) {
+		t.Fatalf("tag guest: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = srv.admin(http.MethodGet, "/admin/guests?tag=college+friends", "")
+	var guests []models.Guest
+	if err := json.Unmarshal(rec.Body.Bytes(), &guests); err != nil || len(guests) != 1 || guests[0].ID != guest.ID {
+		t.Errorf("guests by tag: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/guests?tag=nobody", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown tag: status = %d, want 400", rec.Code)
+	}
+
+	// Bulk actions need a selection, a preview and a matching confirmation
+	if rec := srv.admin(http.MethodPost, "/admin/guests/bulk", `{"action":"delete"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bulk without a selection: status = %d, want 400", rec.Code)
+	}
+	update := `{"selection":{"tags":["College friends"]},"action":"update","update":{"hongbao":88}`
+	rec = srv.admin(http.MethodPost, "/admin/guests/bulk", update+`,"preview":true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"count":1`) {
+		t.Fatalf("preview: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/guests/bulk", update+`}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bulk without confirm_count: status = %d, want 400", rec.Code)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/guests/bulk", update+`,"confirm_count":2}`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("bulk with a stale count: status = %d, want 412", rec.Code)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/guests/bulk", update+`,"confirm_count":1}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"succeeded":1`) {
+		t.Fatalf("bulk update: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := srv.svc.GetGuestByID(ctx, guest.ID); got.Hongbao != 88 {
+		t.Errorf("hongbao after bulk update = %v", got.Hongbao)
+	}
+	if got, _ := srv.svc.GetGuestByID(ctx, other.ID); got.Hongbao != 0 {
+		t.Errorf("untagged guest was updated: hongbao = %v", got.Hongbao)
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/guests/bulk", `{"selection":{"all":true},"action":"send_invites","confirm_count":2}`); rec.Code != http.StatusBadGateway {
+		t.Errorf("invites without a mailer: status = %d, want 502", rec.Code)
+	}
+
+	if rec := srv.admin(http.MethodDelete, path, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rec.Code)
+	}
+	if got, _ := srv.svc.GetGuestByID(ctx, guest.ID); len(got.Tags) != 0 {
+		t.Errorf("tags after deleting the tag = %v", got.Tags)
+	}
+}
+
+func TestHouseholdRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+
+	if rec := srv.admin(http.MethodPost, "/admin/households", `{"addressee":"The Tans","address_line1":"1 Main St","city":"Springfield","postal_code":"62701","country":"US"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "state is required") {
+		t.Errorf("US address without a state: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := srv.admin(http.MethodPost, "/admin/households", `{"addressee":"=The Tans","address_line1":"10 Downing Street","city":"London","postal_code":"sw1a2aa","country":"gb"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var household models.Household
+	if err := json.Unmarshal(rec.Body.Bytes(), &household); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if household.PostalCode != "SW1A 2AA" || household.Country != "GB" {
+		t.Errorf("address not normalized: %+v", household.PostalAddress)
+	}
+
+	if rec := srv.admin(http.MethodGet, "/admin/labels.csv", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("labels without households: status = %d, want 404", rec.Code)
+	}
+	path := "/admin/households/" + household.ID.String()
+	if rec := srv.admin(http.MethodPost, path+"/guests", `{"add":["`+guest.ID.String()+`"]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"added":1`) {
+		t.Fatalf("add guest: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/labels.csv", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Skipped-Guests") != "0" {
+		t.Fatalf("labels.csv: status = %d, skipped = %q", rec.Code, rec.Header().Get("X-Skipped-Guests"))
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("labels.csv = %v, %v", records, err)
+	}
+	if records[1][1] != "'=The Tans" || records[1][6] != "SW1A 2AA" || records[1][9] != "Aunt May" {
+		t.Errorf("label row = %q", records[1])
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/labels.pdf?rsvp_status=Pending", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("labels.pdf: status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	if rec := srv.admin(http.MethodDelete, path, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rec.Code)
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/guests/"+guest.ID.String(), ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"household_id":null`) {
+		t.Errorf("guest after deleting the household: status = %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDuplicateRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	guest := srv.seed()
+	duplicate, err := srv.svc.AddGuest(ctx, "May", "may.again@example.com", "Bride", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	if rec := srv.admin(http.MethodGet, "/admin/guests/duplicates?min_score=x", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric min_score: status = %d, want 400", rec.Code)
+	}
+	rec := srv.admin(http.MethodGet, "/admin/guests/duplicates", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("duplicates: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var candidates []models.DuplicateCandidate
+	if err := json.Unmarshal(rec.Body.Bytes(), &candidates); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Guests[1].ID != duplicate.ID {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/guests/duplicates?min_score=0.9", ""); rec.Code != http.StatusOK || rec.Body.String() != "[]" {
+		t.Errorf("strict min_score: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := `{"primary_id":"` + guest.ID.String() + `","duplicate_id":"` + duplicate.ID.String() + `"}`
+	if rec := srv.admin(http.MethodPost, "/admin/guests/merge", `{"primary_id":"`+guest.ID.String()+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("merge without a duplicate: status = %d, want 400", rec.Code)
+	}
+	rec = srv.admin(http.MethodPost, "/admin/guests/merge", body)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == "" {
+		t.Fatalf("merge: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.admin(http.MethodPost, "/admin/guests/merge", body); rec.Code != http.StatusNotFound {
+		t.Errorf("merging again: status = %d, want 404", rec.Code)
+	}
+
+	rec = srv.admin(http.MethodGet, "/admin/guests/merges", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"duplicate_id":"`+duplicate.ID.String()+`"`) {
+		t.Errorf("merges: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := srv.admin(http.MethodGet, "/admin/guests/duplicates", ""); rec.Body.String() != "[]" {
+		t.Errorf("duplicates after merging: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/invitations"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// HouseholdHandler manages households and the mailing labels printed for them
+type HouseholdHandler struct {
+	Service *service.HouseholdService
+}
+
+// NewHouseholdHandler initializes a new household handler
+func NewHouseholdHandler(service *service.HouseholdService) *HouseholdHandler {
+	return &HouseholdHandler{Service: service}
+}
+
+// householdRequest is the body accepted when creating or replacing a household
+type householdRequest struct {
+	Addressee string `json:"addressee" binding:"required"`
+	models.PostalAddress
+}
+
+// bindHousehold reads a householdRequest body
+func bindHousehold(ctx *gin.Context) (*models.Household, error) {
+	var req householdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, bindingError(err)
+	}
+	return &models.Household{Addressee: req.Addressee, PostalAddress: req.PostalAddress}, nil
+}
+
+// parseHouseholdID reads the :id path parameter
+func parseHouseholdID(ctx *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, apperrors.New(apperrors.ErrValidation, "invalid household ID")
+	}
+	return id, nil
+}
+
+// GetHouseholds lists the households with how many guests live in each
+func (h *HouseholdHandler) GetHouseholds(ctx *gin.Context) {
+	households, err := h.Service.GetHouseholds(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if households == nil {
+		households = []models.Household{}
+	}
+	ctx.JSON(http.StatusOK, households)
+}
+
+// GetHousehold retrieves one household
+func (h *HouseholdHandler) GetHousehold(ctx *gin.Context) {
+	id, err := parseHouseholdID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	household, err := h.Service.GetHousehold(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, household)
+}
+
+// CreateHousehold adds a household with the postal address its invitation is mailed to
+func (h *HouseholdHandler) CreateHousehold(ctx *gin.Context) {
+	household, err := bindHousehold(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.CreateHousehold(ctx.Request.Context(), household); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, household)
+}
+
+// UpdateHousehold replaces a household's addressee and address
+func (h *HouseholdHandler) UpdateHousehold(ctx *gin.Context) {
+	id, err := parseHouseholdID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	household, err := bindHousehold(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	household.ID = id
+
+	if err := h.Service.UpdateHousehold(ctx.Request.Context(), household); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, household)
+}
+
+// DeleteHousehold removes a household; its guests are kept
+func (h *HouseholdHandler) DeleteHousehold(ctx *gin.Context) {
+	id, err := parseHouseholdID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.DeleteHousehold(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "household deleted successfully"})
+}
+
+// ChangeHouseholdMembers moves guests into a household and takes others out
+func (h *HouseholdHandler) ChangeHouseholdMembers(ctx *gin.Context) {
+	id, err := parseHouseholdID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var req struct {
+		Add    []uuid.UUID `json:"add"`
+		Remove []uuid.UUID `json:"remove"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, "list guest IDs to add or remove"))
+		return
+	}
+
+	added, removed, err := h.Service.ChangeHouseholdMembers(ctx.Request.Context(), id, req.Add, req.Remove)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"added": added, "removed": removed})
+}
+
+// MailingLabelsCSV exports the mailing labels of the selected guests'
+// households as CSV, one row per household, for mail-merge tools
+func (h *HouseholdHandler) MailingLabelsCSV(ctx *gin.Context) {
+	labels, skipped, ok := h.mailingLabels(ctx)
+	if !ok {
+		return
+	}
+
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	w.Write([]string{"household_id", "addressee", "address_line1", "address_line2", "city", "region", "postal_code", "country", "label", "guests"})
+	for _, label := range labels {
+		a := label.Address
+		w.Write([]string{
+			label.HouseholdID.String(), csvSafe(label.Addressee),
+			csvSafe(a.Line1), csvSafe(a.Line2), csvSafe(a.City), csvSafe(a.Region), csvSafe(a.PostalCode), a.Country,
+			csvSafe(strings.Join(label.Lines, "\n")), csvSafe(strings.Join(label.Guests, "; ")),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("X-Skipped-Guests", strconv.Itoa(skipped))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Disposition", `attachment; filename="mailing-labels.csv"`)
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", out.Bytes())
+}
+
+// MailingLabelsPDF renders the mailing labels of the selected guests'
+// households as A4 sheets of 21 stick-on labels
+func (h *HouseholdHandler) MailingLabelsPDF(ctx *gin.Context) {
+	labels, skipped, ok := h.mailingLabels(ctx)
+	if !ok {
+		return
+	}
+
+	sheet := make([]invitations.Label, len(labels))
+	for i, label := range labels {
+		sheet[i] = invitations.Label{Lines: label.Lines}
+	}
+	var pdf bytes.Buffer
+	if err := invitations.WriteLabelsPDF(&pdf, sheet); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("X-Skipped-Guests", strconv.Itoa(skipped))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Disposition", `attachment; filename="mailing-labels.pdf"`)
+	ctx.Data(http.StatusOK, "application/pdf", pdf.Bytes())
+}
+
+// mailingLabels builds the labels for the guests selected by the query
+// string, as for the invitation sheets; guests without a household are
+// counted in skipped. It reports the error itself and returns false on failure.
+func (h *HouseholdHandler) mailingLabels(ctx *gin.Context) (labels []models.MailingLabel, skipped int, ok bool) {
+	filter, err := parseGuestFilter(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return nil, 0, false
+	}
+	labels, skipped, err = h.Service.MailingLabels(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.Error(err)
+		return nil, 0, false
+	}
+	return labels, skipped, true
+}
+
+// csvSafe keeps spreadsheet apps from running a cell as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
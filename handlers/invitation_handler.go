@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/calendar"
+	"github.com/g4l1l10/rsvp-backend/invitations"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/qrcode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// QR image sizes accepted by GuestQRCode, in pixels
+const (
+	defaultQRPixels = 400
+	minQRPixels     = 100
+	maxQRPixels     = 2000
+)
+
+// parseGuestFilter reads a guest selection from the query string:
+// rsvp_status, family_side and any number of id and tag parameters, which
+// may also be comma-separated
+func parseGuestFilter(ctx *gin.Context) (models.GuestFilter, error) {
+	filter := models.GuestFilter{
+		RSVPStatus: ctx.Query("rsvp_status"),
+		FamilySide: ctx.Query("family_side"),
+	}
+	for _, param := range ctx.QueryArray("id") {
+		for _, raw := range strings.Split(param, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				return filter, apperrors.Newf(apperrors.ErrValidation, "invalid guest ID %q", raw)
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+	for _, param := range ctx.QueryArray("tag") {
+		for _, name := range strings.Split(param, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.Tags = append(filter.Tags, name)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// GuestQRCode renders a QR code of the guest's printed RSVP link as a PNG.
+// The optional size parameter sets the image width in pixels.
+func (h *GuestHandler) GuestQRCode(ctx *gin.Context) {
+	id, err := parseGuestID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	pixels := defaultQRPixels
+	if raw := ctx.Query("size"); raw != "" {
+		pixels, err = strconv.Atoi(raw)
+		if err != nil || pixels < minQRPixels || pixels > maxQRPixels {
+			ctx.Error(apperrors.Newf(apperrors.ErrValidation, "size must be between %d and %d pixels", minQRPixels, maxQRPixels))
+			return
+		}
+	}
+
+	code, err := h.Service.InvitationQRCode(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var png bytes.Buffer
+	if err := code.WritePNG(&png, pixels/(code.Size()+2*qrcode.QuietZone)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The image carries a working RSVP credential
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "image/png", png.Bytes())
+}
+
+// InvitationsPDF renders printable invitation cards for the selected guests
+func (h *GuestHandler) InvitationsPDF(ctx *gin.Context) {
+	filter, err := parseGuestFilter(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	cards, skipped, err := h.Service.InvitationCards(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var pdf bytes.Buffer
+	if err := invitations.WritePDF(&pdf, invitations.Heading(h.Service.Wedding.Title), cards); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Guests without an RSVP code cannot be printed; tell the caller how many were left out
+	ctx.Header("X-Skipped-Guests", strconv.Itoa(skipped))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Disposition", `attachment; filename="invitations.pdf"`)
+	ctx.Data(http.StatusOK, "application/pdf", pdf.Bytes())
+}
+
+// GuestCalendar serves the iCalendar file of the guest holding the RSVP
+// token or code, for importing into or subscribing from a calendar app
+func (h *GuestHandler) GuestCalendar(ctx *gin.Context) {
+	ics, err := h.Service.GuestCalendar(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The entries link to the guest's RSVP page, so keep them out of shared caches
+	ctx.Header("Cache-Control", "private, no-cache")
+	ctx.Header("Content-Disposition", `attachment; filename="wedding.ics"`)
+	ctx.Data(http.StatusOK, calendar.ContentType, ics)
+}
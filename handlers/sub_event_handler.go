@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SubEventHandler manages sub-events and who is invited to them
+type SubEventHandler struct {
+	Service *service.SubEventService
+}
+
+// NewSubEventHandler initializes a new sub-event handler
+func NewSubEventHandler(service *service.SubEventService) *SubEventHandler {
+	return &SubEventHandler{Service: service}
+}
+
+// subEventRequest is the body accepted when creating or replacing a sub-event
+type subEventRequest struct {
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description"`
+	Venue       string    `json:"venue"`
+	Address     string    `json:"address"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+
+	Translations map[string]models.SubEventText `json:"translations"`
+}
+
+// bindSubEvent reads a subEventRequest body
+func bindSubEvent(ctx *gin.Context) (*models.SubEvent, error) {
+	var req subEventRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, bindingError(err)
+	}
+	return &models.SubEvent{
+		Name:        req.Name,
+		Description: req.Description,
+		Venue:       req.Venue,
+		Address:     req.Address,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+
+		Translations: req.Translations,
+	}, nil
+}
+
+// parseSubEventID reads the :id path parameter
+func parseSubEventID(ctx *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, apperrors.New(apperrors.ErrValidation, "invalid sub-event ID")
+	}
+	return id, nil
+}
+
+// GetSubEvents lists the sub-events in chronological order
+func (h *SubEventHandler) GetSubEvents(ctx *gin.Context) {
+	subEvents, err := h.Service.GetSubEvents(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if subEvents == nil {
+		subEvents = []models.SubEvent{}
+	}
+	ctx.JSON(http.StatusOK, subEvents)
+}
+
+// GetSubEvent retrieves one sub-event
+func (h *SubEventHandler) GetSubEvent(ctx *gin.Context) {
+	id, err := parseSubEventID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	subEvent, err := h.Service.GetSubEvent(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subEvent)
+}
+
+// CreateSubEvent adds a sub-event such as the tea ceremony or the banquet
+func (h *SubEventHandler) CreateSubEvent(ctx *gin.Context) {
+	subEvent, err := bindSubEvent(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.CreateSubEvent(ctx.Request.Context(), subEvent); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, subEvent)
+}
+
+// UpdateSubEvent replaces a sub-event's details; its invitations are kept
+func (h *SubEventHandler) UpdateSubEvent(ctx *gin.Context) {
+	id, err := parseSubEventID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	subEvent, err := bindSubEvent(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	subEvent.ID = id
+
+	if err := h.Service.UpdateSubEvent(ctx.Request.Context(), subEvent); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subEvent)
+}
+
+// DeleteSubEvent removes a sub-event along with its invitations
+func (h *SubEventHandler) DeleteSubEvent(ctx *gin.Context) {
+	id, err := parseSubEventID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.DeleteSubEvent(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "sub-event deleted successfully"})
+}
+
+// GetSubEventInvitations lists who is invited to a sub-event and their answers
+func (h *SubEventHandler) GetSubEventInvitations(ctx *gin.Context) {
+	id, err := parseSubEventID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	invitations, err := h.Service.GetSubEventInvitations(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if invitations == nil {
+		invitations = []models.Invitation{}
+	}
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// ChangeInvitations invites and uninvites guests to a sub-event
+func (h *SubEventHandler) ChangeInvitations(ctx *gin.Context) {
+	id, err := parseSubEventID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var req struct {
+		Invite   []uuid.UUID `json:"invite"`
+		Uninvite []uuid.UUID `json:"uninvite"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+	if len(req.Invite) == 0 && len(req.Uninvite) == 0 {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, "list guest IDs to invite or uninvite"))
+		return
+	}
+
+	invited, uninvited, err := h.Service.ChangeInvitations(ctx.Request.Context(), id, req.Invite, req.Uninvite)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"invited": invited, "uninvited": uninvited})
+}
+
+// InvitationMatrix shows which guest is invited to which sub-event
+func (h *SubEventHandler) InvitationMatrix(ctx *gin.Context) {
+	matrix, err := h.Service.InvitationMatrix(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, matrix)
+}
+
+// SubEventSummary counts answers and expected attendees per sub-event
+func (h *SubEventHandler) SubEventSummary(ctx *gin.Context) {
+	summary, err := h.Service.GetSubEventSummary(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// GuestSubEvents lists the sub-events a guest is invited to, by RSVP token or
+// code, in the language negotiated as for the RSVP page
+func (h *SubEventHandler) GuestSubEvents(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	subEvents, err := h.Service.GuestSubEvents(ctx.Request.Context(), ctx.Param("token"), ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subEvents)
+}
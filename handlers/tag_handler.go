@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TagHandler manages the tags grouping guests
+type TagHandler struct {
+	Service *service.TagService
+}
+
+// NewTagHandler initializes a new tag handler
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{Service: service}
+}
+
+// tagRequest is the body accepted when creating or renaming a tag
+type tagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// parseTagID reads the :id path parameter
+func parseTagID(ctx *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, apperrors.New(apperrors.ErrValidation, "invalid tag ID")
+	}
+	return id, nil
+}
+
+// GetTags lists the tags with how many guests carry each
+func (h *TagHandler) GetTags(ctx *gin.Context) {
+	tags, err := h.Service.GetTags(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// GetTag retrieves one tag
+func (h *TagHandler) GetTag(ctx *gin.Context) {
+	id, err := parseTagID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tag, err := h.Service.GetTag(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// CreateTag adds a tag such as "college friends" or "VIP"
+func (h *TagHandler) CreateTag(ctx *gin.Context) {
+	var req tagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	tag, err := h.Service.CreateTag(ctx.Request.Context(), req.Name)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, tag)
+}
+
+// RenameTag changes a tag's name
+func (h *TagHandler) RenameTag(ctx *gin.Context) {
+	id, err := parseTagID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	var req tagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	tag, err := h.Service.RenameTag(ctx.Request.Context(), id, req.Name)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag removes a tag from every guest and deletes it
+func (h *TagHandler) DeleteTag(ctx *gin.Context) {
+	id, err := parseTagID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.DeleteTag(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag deleted successfully"})
+}
+
+// ChangeTagging assigns a tag to guests and removes it from others
+func (h *TagHandler) ChangeTagging(ctx *gin.Context) {
+	id, err := parseTagID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var req struct {
+		Add    []uuid.UUID `json:"add"`
+		Remove []uuid.UUID `json:"remove"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		ctx.Error(apperrors.New(apperrors.ErrValidation, "list guest IDs to add or remove"))
+		return
+	}
+
+	added, removed, err := h.Service.ChangeTagging(ctx.Request.Context(), id, req.Add, req.Remove)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"added": added, "removed": removed})
+}
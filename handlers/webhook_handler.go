@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookHandler manages the endpoints guest events are delivered to
+type WebhookHandler struct {
+	Service *service.WebhookService
+}
+
+// NewWebhookHandler initializes a new webhook handler
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{Service: service}
+}
+
+// Page sizes for the webhook delivery log
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// webhookRequest is the body accepted when creating or replacing a webhook
+type webhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // Defaults to true
+}
+
+// bindWebhookSettings reads a webhookRequest body
+func bindWebhookSettings(ctx *gin.Context) (service.WebhookSettings, error) {
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return service.WebhookSettings{}, bindingError(err)
+	}
+	settings := service.WebhookSettings{URL: req.URL, EventTypes: req.EventTypes, Description: req.Description, Active: true}
+	if req.Active != nil {
+		settings.Active = *req.Active
+	}
+	return settings, nil
+}
+
+// parseWebhookID reads the :id path parameter
+func parseWebhookID(ctx *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, apperrors.New(apperrors.ErrValidation, "invalid webhook ID")
+	}
+	return id, nil
+}
+
+// GetWebhooks lists registered webhooks
+func (h *WebhookHandler) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := h.Service.GetWebhooks(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook retrieves one webhook
+func (h *WebhookHandler) GetWebhook(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	webhook, err := h.Service.GetWebhook(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// CreateWebhook registers an endpoint; the response carries its signing secret
+func (h *WebhookHandler) CreateWebhook(ctx *gin.Context) {
+	settings, err := bindWebhookSettings(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	webhook, err := h.Service.CreateWebhook(ctx.Request.Context(), settings)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+// UpdateWebhook replaces a webhook's URL, event types, description and active flag
+func (h *WebhookHandler) UpdateWebhook(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	settings, err := bindWebhookSettings(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	webhook, err := h.Service.UpdateWebhook(ctx.Request.Context(), id, settings)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// RotateWebhookSecret issues a webhook a new signing secret
+func (h *WebhookHandler) RotateWebhookSecret(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	webhook, err := h.Service.RotateWebhookSecret(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (h *WebhookHandler) DeleteWebhook(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.Service.DeleteWebhook(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+// TestWebhook sends a test event and responds with the delivery, whose
+// status shows whether the endpoint accepted it
+func (h *WebhookHandler) TestWebhook(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	delivery, err := h.Service.TestWebhook(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetWebhookDeliveries lists a webhook's recent deliveries, newest first.
+// The optional limit parameter caps how many are returned.
+func (h *WebhookHandler) GetWebhookDeliveries(ctx *gin.Context) {
+	id, err := parseWebhookID(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxDeliveryLimit {
+			ctx.Error(apperrors.Newf(apperrors.ErrValidation, "limit must be between 1 and %d", maxDeliveryLimit))
+			return
+		}
+	}
+
+	deliveries, err := h.Service.GetWebhookDeliveries(ctx.Request.Context(), id, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
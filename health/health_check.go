@@ -1,39 +1,193 @@
 package health
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
 	"net/http"
-	"os"
-
-	"github.com/g4l1l10/rsvp-backend/db"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// HealthCheckHandler returns system health status
-func HealthCheckHandler(ctx *gin.Context) {
-	status := checkSystemHealth()
-	ctx.JSON(http.StatusOK, status)
+// Status values reported for individual checks and the overall report
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // a non-critical dependency is failing
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// DefaultCheckTimeout bounds a single dependency check
+const DefaultCheckTimeout = 2 * time.Second
+
+// CheckFunc probes one dependency and returns an error if it is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the structured health of the service and its dependencies
+type Report struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+type registeredCheck struct {
+	name     string
+	critical bool
+	timeout  time.Duration
+	check    CheckFunc
+}
+
+// Registry holds the dependency checks used by the readiness endpoint.
+// Subsystems register their own checks; results are cached for CacheTTL so
+// frequent probes do not hammer the dependencies.
+type Registry struct {
+	CacheTTL time.Duration
+
+	mu     sync.Mutex // guards checks and serializes refreshes
+	checks []registeredCheck
+	cached *Report
+}
+
+// NewRegistry initializes an empty registry caching results for cacheTTL
+func NewRegistry(cacheTTL time.Duration) *Registry {
+	return &Registry{CacheTTL: cacheTTL}
+}
+
+// Register adds a dependency check. A failing critical check makes the
+// service not ready; a failing non-critical check only degrades the report.
+func (r *Registry) Register(name string, critical bool, check CheckFunc) {
+	r.RegisterWithTimeout(name, critical, DefaultCheckTimeout, check)
 }
 
-// checkSystemHealth verifies database and authentication service health
-func checkSystemHealth() gin.H {
-	// Check database connection
-	dbStatus := "OK"
-	if err := db.GetDB().Ping(); err != nil {
-		dbStatus = "ERROR: " + err.Error()
+// RegisterWithTimeout adds a dependency check with its own timeout
+func (r *Registry) RegisterWithTimeout(name string, critical bool, timeout time.Duration, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, registeredCheck{name: name, critical: critical, timeout: timeout, check: check})
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].name < r.checks[j].name })
+	r.cached = nil
+}
+
+// Report returns the cached report, running every check again once it is stale.
+// Concurrent callers wait for a single refresh instead of each probing dependencies.
+func (r *Registry) Report(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cached != nil && time.Since(r.cached.CheckedAt) < r.CacheTTL {
+		return *r.cached
 	}
 
-	// Check authentication service
-	authStatus := "OK"
-	authServiceURL := os.Getenv("AUTH_SERVICE_URL") + "/status"
-	resp, err := http.Get(authServiceURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		authStatus = "ERROR: Auth service unavailable"
+	// A caller hanging up must not poison the shared cache with cancellation errors
+	report := runChecks(context.WithoutCancel(ctx), r.checks)
+	r.cached = &report
+	return report
+}
+
+// runChecks probes every dependency concurrently
+func runChecks(ctx context.Context, checks []registeredCheck) Report {
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c registeredCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.check(checkCtx)
+			result := CheckResult{
+				Status:    StatusOK,
+				Critical:  c.critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, c)
 	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, CheckedAt: time.Now(), Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status == StatusOK {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// LivenessHandler reports that the process is up and serving HTTP.
+// It never touches dependencies, so a database outage does not trigger restarts.
+func LivenessHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// ReadinessHandler reports whether the instance should receive traffic.
+// It answers 503 while draining or when any critical dependency is failing.
+func (r *Registry) ReadinessHandler(ctx *gin.Context) {
+	if !IsReady() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": StatusDraining})
+		return
+	}
+
+	report := r.Report(ctx.Request.Context())
+	status := http.StatusOK
+	if report.Status == StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
+
+// DatabaseCheck pings the database pool
+func DatabaseCheck(conn *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return conn.PingContext(ctx)
+	}
+}
+
+// HTTPCheck issues a GET to url and expects a 200 response
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
 
-	return gin.H{
-		"server":                 "OK",
-		"database":               dbStatus,
-		"authentication_service": authStatus,
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
 	}
 }
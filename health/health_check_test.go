@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveReadiness(t *testing.T, r *Registry) (*httptest.ResponseRecorder, Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/readyz", r.ReadinessHandler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec, report
+}
+
+func TestReadiness(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("down") }
+	passing := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		register   func(r *Registry)
+		ready      bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"all healthy", func(r *Registry) { r.Register("db", true, passing) }, true, http.StatusOK, StatusOK},
+		{"critical failure", func(r *Registry) { r.Register("db", true, failing) }, true, http.StatusServiceUnavailable, StatusFail},
+		{"non-critical failure", func(r *Registry) {
+			r.Register("db", true, passing)
+			r.Register("smtp", false, failing)
+		}, true, http.StatusOK, StatusDegraded},
+		{"draining", func(r *Registry) { r.Register("db", true, passing) }, false, http.StatusServiceUnavailable, StatusDraining},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ready {
+				MarkReady()
+			} else {
+				MarkNotReady()
+			}
+			t.Cleanup(MarkNotReady)
+
+			r := NewRegistry(0)
+			tt.register(r)
+
+			rec, report := serveReadiness(t, r)
+			if rec.Code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Errorf("got %d %q, want %d %q", rec.Code, report.Status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReportIsCachedAndTimed(t *testing.T) {
+	var calls atomic.Int32
+	r := NewRegistry(time.Minute)
+	r.RegisterWithTimeout("slow", true, 10*time.Millisecond, func(ctx context.Context) error {
+		calls.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	first := r.Report(context.Background())
+	second := r.Report(context.Background())
+
+	if calls.Load() != 1 {
+		t.Errorf("check ran %d times, want 1", calls.Load())
+	}
+	result := first.Checks["slow"]
+	if result.Status != StatusFail || result.LatencyMS < 10 || !second.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("unexpected report: %+v", first)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if err := HTTPCheck(srv.Client(), srv.URL+"/status")(context.Background()); err != nil {
+		t.Errorf("healthy endpoint: %v", err)
+	}
+	if err := HTTPCheck(srv.Client(), srv.URL+"/other")(context.Background()); err == nil {
+		t.Error("expected failure for non-200 response")
+	}
+}
@@ -0,0 +1,21 @@
+package health
+
+import "sync/atomic"
+
+// ready reports whether the instance should receive new traffic
+var ready atomic.Bool
+
+// MarkReady signals that startup has finished and traffic may be routed here
+func MarkReady() {
+	ready.Store(true)
+}
+
+// MarkNotReady signals that the instance is draining and should be taken out of rotation
+func MarkNotReady() {
+	ready.Store(false)
+}
+
+// IsReady reports the current readiness state
+func IsReady() bool {
+	return ready.Load()
+}
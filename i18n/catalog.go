@@ -0,0 +1,85 @@
+package i18n
+
+// catalogs holds the translated text per language. Keys starting with
+// "rsvp." and "status." are labels for the guest's RSVP page; the English
+// catalog is the reference every other one is checked against.
+var catalogs = map[string]map[string]string{
+	English: {
+		"rsvp.heading":       "Will you join us?",
+		"rsvp.welcome":       "We would be honoured to celebrate our wedding with you.",
+		"rsvp.attending":     "Joyfully accepts",
+		"rsvp.not_attending": "Regretfully declines",
+		"rsvp.total_guests":  "Number of guests",
+		"rsvp.sub_events":    "Celebrations you are invited to",
+		"rsvp.attendees":     "Guests attending",
+		"rsvp.submit":        "Send RSVP",
+		"rsvp.thank_you":     "Thank you! Your RSVP has been saved.",
+		"rsvp.change":        "You can change your answer at any time before the wedding.",
+		"rsvp.calendar":      "Add to calendar",
+
+		"status.Pending":       "Awaiting reply",
+		"status.Attending":     "Attending",
+		"status.Not Attending": "Not attending",
+
+		"email.invitation.subject":         "💍 You're Invited to Axel and Daphne's Wedding Celebration!",
+		"email.reminder.subject":           "⏰ Reminder: Please RSVP to Axel and Daphne's Wedding",
+		"email.confirmation.subject":       "💌 Your RSVP for Axel and Daphne's Wedding",
+		"email.confirmation.attending":     "Thank you for your RSVP! We can't wait to celebrate with you. 🎉",
+		"email.confirmation.not_attending": "Thank you for letting us know. We're sorry you can't make it and will miss you! 💕",
+		"email.confirmation.recorded":      "Thank you for your RSVP! We've recorded your response.",
+
+		"calendar.rsvp": "View or change your RSVP: ",
+	},
+	Mandarin: {
+		"rsvp.heading":       "诚邀您的光临",
+		"rsvp.welcome":       "若能与您共同庆祝我们的婚礼，将是我们莫大的荣幸。",
+		"rsvp.attending":     "欣然出席",
+		"rsvp.not_attending": "遗憾无法出席",
+		"rsvp.total_guests":  "出席人数",
+		"rsvp.sub_events":    "您受邀参加的活动",
+		"rsvp.attendees":     "出席人数",
+		"rsvp.submit":        "提交回复",
+		"rsvp.thank_you":     "谢谢！您的回复已保存。",
+		"rsvp.change":        "婚礼前您可以随时更改您的回复。",
+		"rsvp.calendar":      "添加到日历",
+
+		"status.Pending":       "待回复",
+		"status.Attending":     "出席",
+		"status.Not Attending": "不出席",
+
+		"email.invitation.subject":         "💍 诚邀您参加 Axel 与 Daphne 的婚礼！",
+		"email.reminder.subject":           "⏰ 温馨提醒：请回复 Axel 与 Daphne 的婚礼邀请",
+		"email.confirmation.subject":       "💌 您对 Axel 与 Daphne 婚礼的回复",
+		"email.confirmation.attending":     "感谢您的回复！我们期待与您共同庆祝。🎉",
+		"email.confirmation.not_attending": "感谢您告知我们。很遗憾您无法出席，我们会想念您的！💕",
+		"email.confirmation.recorded":      "感谢您的回复！我们已记录您的答复。",
+
+		"calendar.rsvp": "查看或更改您的回复：",
+	},
+	Indonesian: {
+		"rsvp.heading":       "Maukah Anda hadir bersama kami?",
+		"rsvp.welcome":       "Merupakan suatu kehormatan bagi kami untuk merayakan pernikahan kami bersama Anda.",
+		"rsvp.attending":     "Dengan senang hati hadir",
+		"rsvp.not_attending": "Mohon maaf tidak dapat hadir",
+		"rsvp.total_guests":  "Jumlah tamu",
+		"rsvp.sub_events":    "Acara yang Anda hadiri",
+		"rsvp.attendees":     "Jumlah tamu yang hadir",
+		"rsvp.submit":        "Kirim RSVP",
+		"rsvp.thank_you":     "Terima kasih! RSVP Anda telah disimpan.",
+		"rsvp.change":        "Anda dapat mengubah jawaban Anda kapan saja sebelum hari pernikahan.",
+		"rsvp.calendar":      "Tambahkan ke kalender",
+
+		"status.Pending":       "Menunggu jawaban",
+		"status.Attending":     "Hadir",
+		"status.Not Attending": "Tidak hadir",
+
+		"email.invitation.subject":         "💍 Anda Diundang ke Pernikahan Axel dan Daphne!",
+		"email.reminder.subject":           "⏰ Pengingat: Mohon Kirim RSVP untuk Pernikahan Axel dan Daphne",
+		"email.confirmation.subject":       "💌 RSVP Anda untuk Pernikahan Axel dan Daphne",
+		"email.confirmation.attending":     "Terima kasih atas RSVP Anda! Kami tidak sabar untuk merayakannya bersama Anda. 🎉",
+		"email.confirmation.not_attending": "Terima kasih telah memberi tahu kami. Sayang sekali Anda tidak dapat hadir, kami akan merindukan Anda! 💕",
+		"email.confirmation.recorded":      "Terima kasih atas RSVP Anda! Jawaban Anda telah kami catat.",
+
+		"calendar.rsvp": "Lihat atau ubah RSVP Anda: ",
+	},
+}
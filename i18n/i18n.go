@@ -0,0 +1,120 @@
+// Package i18n holds the languages guests can read their invitation in and
+// the translated text shown to them, falling back to English for anything
+// not yet translated.
+package i18n
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages, as ISO 639-1 codes
+const (
+	English    = "en"
+	Mandarin   = "zh"
+	Indonesian = "id"
+)
+
+// Default is the language used when nothing better is known
+const Default = English
+
+// Supported lists every language with a catalog, the default first
+var Supported = []string{English, Mandarin, Indonesian}
+
+// aliases maps other primary subtags to the language they are read as
+var aliases = map[string]string{
+	"cmn": Mandarin,   // Mandarin by its ISO 639-3 code
+	"in":  Indonesian, // Indonesian's withdrawn ISO 639 code, still sent by older Android and Java
+}
+
+// Normalize reduces a language tag such as "zh-Hans-CN" or "id_ID" to a
+// supported language, reporting false if it is not one
+func Normalize(tag string) (string, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	if alias, ok := aliases[primary]; ok {
+		primary = alias
+	}
+	if slices.Contains(Supported, primary) {
+		return primary, true
+	}
+	return "", false
+}
+
+// Negotiate picks the supported language a client prefers most from an
+// Accept-Language header (RFC 9110), or returns "" if it accepts none
+func Negotiate(acceptLanguage string) string {
+	type choice struct {
+		tag     string
+		quality float64
+	}
+	var choices []choice
+	for _, item := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(item, ";")
+		c := choice{tag: strings.TrimSpace(tag), quality: 1}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			c.quality = quality
+		}
+		if c.tag != "" && c.quality > 0 {
+			choices = append(choices, c)
+		}
+	}
+	// Equal qualities keep the order the client listed them in
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].quality > choices[j].quality })
+
+	for _, c := range choices {
+		if c.tag == "*" {
+			return Default
+		}
+		if lang, ok := Normalize(c.tag); ok {
+			return lang
+		}
+	}
+	return ""
+}
+
+// Resolve returns the first of the candidates that names a supported
+// language, or Default
+func Resolve(candidates ...string) string {
+	for _, candidate := range candidates {
+		if lang, ok := Normalize(candidate); ok {
+			return lang
+		}
+	}
+	return Default
+}
+
+// T returns the text for key in lang, falling back to English and then to
+// the key itself
+func T(lang, key string) string {
+	if text, ok := catalogs[lang][key]; ok {
+		return text
+	}
+	if text, ok := catalogs[Default][key]; ok {
+		return text
+	}
+	return key
+}
+
+// Labels returns every text shown on the guest's RSVP page in lang, with
+// English for the keys that are not translated yet
+func Labels(lang string) map[string]string {
+	labels := make(map[string]string)
+	for key := range catalogs[Default] {
+		if strings.HasPrefix(key, "rsvp.") || strings.HasPrefix(key, "status.") {
+			labels[key] = T(lang, key)
+		}
+	}
+	return labels
+}
+
+// StatusLabel translates an RSVP status for display
+func StatusLabel(lang, status string) string {
+	return T(lang, "status."+status)
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	for tag, want := range map[string]string{
+		"en":         English,
+		"EN-us":      English,
+		"zh-Hans-CN": Mandarin,
+		"cmn":        Mandarin,
+		"id_ID":      Indonesian,
+		"in":         Indonesian,
+		"fr":         "",
+		"":           "",
+	} {
+		got, ok := Normalize(tag)
+		if got != want || ok != (want != "") {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                               "",
+		"zh-CN,zh;q=0.9,en;q=0.8":        Mandarin,
+		"fr-FR, id;q=0.5, en;q=0.4":      Indonesian,
+		"en;q=0.3, id-ID;q=0.7":          Indonesian,
+		"fr, de":                         "",
+		"fr, *;q=0.1":                    Default,
+		"zh;q=0, en":                     English,
+		"id;q=oops, zh":                  Mandarin,
+		"  en-GB ; q=1.0 ,zh-TW;q=1.0  ": English,
+	} {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if got := Resolve("", "klingon", "id", "zh"); got != Indonesian {
+		t.Errorf("Resolve = %q, want the first supported candidate", got)
+	}
+	if got := Resolve(); got != Default {
+		t.Errorf("Resolve() = %q, want the default", got)
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	for _, lang := range Supported {
+		catalog, ok := catalogs[lang]
+		if !ok {
+			t.Errorf("no catalog for %s", lang)
+			continue
+		}
+		for key := range catalogs[Default] {
+			if catalog[key] == "" {
+				t.Errorf("%s catalog lacks %q", lang, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s catalog has %q, which English lacks", lang, key)
+			}
+		}
+	}
+
+	if got := T("fr", "rsvp.submit"); got != "Send RSVP" {
+		t.Errorf("unsupported language: T = %q, want the English text", got)
+	}
+	if got := StatusLabel(Mandarin, "Attending"); got != "出席" {
+		t.Errorf("StatusLabel = %q", got)
+	}
+	if labels := Labels(Indonesian); labels["rsvp.submit"] != "Kirim RSVP" || labels["status.Pending"] == "" || labels["email.invitation.subject"] != "" {
+		t.Errorf("Labels = %v", labels)
+	}
+}
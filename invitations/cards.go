@@ -0,0 +1,168 @@
+// Package invitations renders printable invitation cards for guests who
+// receive their invitation on paper, and the address labels to mail them
+// with, in pure Go so it works offline.
+package invitations
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/qrcode"
+)
+
+// Card is one printed invitation
+type Card struct {
+	Name string // guest name, printed as given
+	Code string // short RSVP code, printed for guests who type it in
+	Link string // RSVP link the QR code points to
+}
+
+// A4 portrait, in points, cut into a grid of cards
+const (
+	pageWidth   = 595.28
+	pageHeight  = 841.89
+	columns     = 2
+	rows        = 4
+	cardWidth   = pageWidth / columns
+	cardHeight  = pageHeight / rows
+	cardsOnPage = columns * rows
+	margin      = 18.0
+	qrSize      = 132.0 // including the quiet zone
+)
+
+// Heading is the line shown at the top of every card, inviting guests to the
+// celebration named by title, or simply inviting them when there is no title
+func Heading(title string) string {
+	if title == "" {
+		return "You're invited"
+	}
+	return "You're invited to " + title
+}
+
+// WritePDF writes the cards as an A4 PDF, eight to a page with cut guides,
+// each headed by heading. Text is set in Helvetica; characters outside
+// Latin-1 print as '?'.
+func WritePDF(w io.Writer, heading string, cards []Card) error {
+	doc := newPDFDocument()
+	var pages []int
+	for start := 0; start < len(cards) || start == 0; start += cardsOnPage {
+		var content bytes.Buffer
+		drawCutGuides(&content)
+		for i, card := range cards[start:min(start+cardsOnPage, len(cards))] {
+			x := float64(i%columns) * cardWidth
+			y := pageHeight - float64(i/columns+1)*cardHeight
+			if err := drawCard(&content, heading, card, x, y); err != nil {
+				return err
+			}
+		}
+		if err := doc.addPage(&pages, pageWidth, pageHeight, content.Bytes()); err != nil {
+			return err
+		}
+	}
+	return doc.writeTo(w, pages, "Wedding invitations")
+}
+
+// drawCutGuides draws light dashed lines between the cards
+func drawCutGuides(out *bytes.Buffer) {
+	out.WriteString("q 0.75 G 0.5 w [4 4] 0 d\n")
+	for c := 1; c < columns; c++ {
+		fmt.Fprintf(out, "%s 0 m %s %s l S\n", num(float64(c)*cardWidth), num(float64(c)*cardWidth), num(pageHeight))
+	}
+	for r := 1; r < rows; r++ {
+		fmt.Fprintf(out, "0 %s m %s %s l S\n", num(float64(r)*cardHeight), num(pageWidth), num(float64(r)*cardHeight))
+	}
+	out.WriteString("Q\n")
+}
+
+// drawCard lays out one card with its lower-left corner at (x, y):
+// the heading and name across the top, the QR code below on the left and the
+// typed-in fallback on the right
+func drawCard(out *bytes.Buffer, heading string, card Card, x, y float64) error {
+	code, err := qrcode.Encode([]byte(card.Link))
+	if err != nil {
+		return fmt.Errorf("QR code for %q: %w", card.Name, err)
+	}
+
+	top := y + cardHeight - margin
+	textWidth := cardWidth - 2*margin
+	text(out, "F1", 10, x+margin, top-10, heading)
+	nameSize := fitSize(card.Name, 18, 10, textWidth, true)
+	text(out, "F2", nameSize, x+margin, top-34, card.Name)
+
+	// QR modules as filled rectangles, so the code stays sharp at any print resolution
+	qrX, qrY := x+margin-4, y+margin-4
+	module := qrSize / float64(code.Size()+2*qrcode.QuietZone)
+	out.WriteString("q 0 g\n")
+	for row := 0; row < code.Size(); row++ {
+		for col := 0; col < code.Size(); col++ {
+			if code.Dark(col, row) {
+				fmt.Fprintf(out, "%s %s %s %s re\n",
+					num(qrX+float64(col+qrcode.QuietZone)*module),
+					num(qrY+qrSize-float64(row+qrcode.QuietZone+1)*module),
+					num(module+0.01), num(module+0.01)) // slight overlap avoids hairline gaps
+			}
+		}
+	}
+	out.WriteString("f Q\n")
+
+	// Fallback for guests who cannot scan: the site and the short code
+	colX := qrX + qrSize + 8
+	colWidth := x + cardWidth - margin - colX
+	site := strings.TrimPrefix(strings.TrimPrefix(card.Link, "https://"), "http://")
+	if i := strings.Index(site, "/rsvp/"); i >= 0 {
+		site = site[:i+len("/rsvp")]
+	}
+	lineY := qrY + qrSize - 24
+	text(out, "F2", 10, colX, lineY, "Scan to RSVP")
+	text(out, "F1", 9, colX, lineY-18, "or visit")
+	text(out, "F1", fitSize(site, 9, 6, colWidth, false), colX, lineY-30, site)
+	text(out, "F1", 9, colX, lineY-42, "and enter your code")
+	if card.Code != "" {
+		text(out, "F2", fitSize(card.Code, 18, 10, colWidth, true), colX, lineY-66, card.Code)
+	}
+	return nil
+}
+
+// text draws a single line of text with its baseline at (x, y)
+func text(out *bytes.Buffer, font string, size, x, y float64, s string) {
+	fmt.Fprintf(out, "BT /%s %s Tf %s %s Td %s Tj ET\n", font, num(size), num(x), num(y), pdfString(s))
+}
+
+// fitSize returns the largest font size, down to minSize, at which s fits in width
+func fitSize(s string, size, minSize, width float64, bold bool) float64 {
+	for ; size > minSize; size-- {
+		if textWidth(s, size, bold) <= width {
+			break
+		}
+	}
+	return size
+}
+
+// textWidth estimates the width of s in points using Helvetica's metrics
+func textWidth(s string, size float64, bold bool) float64 {
+	units := 0
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7F {
+			units += helveticaWidths[r-0x20]
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if bold {
+		width *= 1.08 // Helvetica-Bold runs slightly wider
+	}
+	return width
+}
+
+// helveticaWidths are the advance widths of printable ASCII in Helvetica, in 1/1000 em
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
@@ -0,0 +1,116 @@
+package invitations
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDF(t *testing.T) {
+	cards := make([]Card, 9)
+	for i := range cards {
+		cards[i] = Card{Name: fmt.Sprintf("Guest %d", i+1), Code: "ABCD-2345", Link: "https://axeldaphne.com/rsvp/ABCD2345"}
+	}
+	cards[0].Name = "Zoë (Aunt) Müller 王"
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, Heading("Axel & Daphne's Wedding"), cards); err != nil {
+		t.Fatalf("WritePDF: %v", err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	// Every cross-reference entry must point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if startxref == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[offset:min(offset+12, len(pdf))])
+		}
+	}
+
+	if !bytes.Contains(pdf, []byte("/Count 2")) {
+		t.Error("nine cards should fill two pages")
+	}
+
+	// The first page names the guests, escaping parentheses and mapping to WinAnsi
+	content := firstStream(t, pdf)
+	for _, want := range []string{`(Zo\353 \(Aunt\) M\374ller ?)`, "(Guest 8)", "(ABCD-2345)", "(axeldaphne.com/rsvp)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("page content missing %s", want)
+		}
+	}
+	if strings.Contains(content, "(Guest 9)") {
+		t.Error("ninth card printed on the first page")
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	if got := fitSize("Ann", 18, 10, 200, true); got != 18 {
+		t.Errorf("short name shrunk to %v", got)
+	}
+	long := strings.Repeat("W", 40)
+	if got := fitSize(long, 18, 10, 200, true); got != 10 {
+		t.Errorf("long name size = %v, want the minimum", got)
+	}
+}
+
+// firstStream inflates the first content stream in the document
+func firstStream(t *testing.T, pdf []byte) string {
+	t.Helper()
+	start := bytes.Index(pdf, []byte("stream\n"))
+	if start < 0 {
+		t.Fatal("no content stream")
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(pdf[start+len("stream\n"):]))
+	if err != nil {
+		t.Fatalf("zlib: %v", err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("inflate: %v", err)
+	}
+	return string(content)
+}
+
+func TestWriteLabelsPDF(t *testing.T) {
+	labels := make([]Label, 22)
+	for i := range labels {
+		labels[i] = Label{Lines: []string{fmt.Sprintf("Household %d", i+1), "Jl. Kemang Raya 8", "Jakarta Selatan 12730"}}
+	}
+	labels[0].Lines = []string{"Família Müller (Bride)", "10 Downing Street", "London", "SW1A 2AA", "UNITED KINGDOM"}
+
+	var buf bytes.Buffer
+	if err := WriteLabelsPDF(&buf, labels); err != nil {
+		t.Fatalf("WriteLabelsPDF: %v", err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.Contains(pdf, []byte("/Count 2")) {
+		t.Error("22 labels should fill two sheets")
+	}
+
+	content := firstStream(t, pdf)
+	for _, want := range []string{`(Fam\355lia M\374ller \(Bride\))`, "(SW1A 2AA)", "(UNITED KINGDOM)", "(Household 21)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("sheet content missing %s", want)
+		}
+	}
+	if strings.Contains(content, "(Household 22)") {
+		t.Error("22nd label printed on the first sheet")
+	}
+	if !strings.Contains(content, "/F2 9 Tf") {
+		t.Error("addressee should be set in bold")
+	}
+}
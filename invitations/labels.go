@@ -0,0 +1,66 @@
+package invitations
+
+import (
+	"bytes"
+	"io"
+)
+
+// Label is one address label: the addressee, then the address as printed
+type Label struct {
+	Lines []string
+}
+
+// A4 sheets of 21 labels, 63.5 × 38.1 mm in three columns of seven, as sold
+// for most office printers (Avery L7160 and compatibles); sizes in points
+const (
+	mm             = 72 / 25.4
+	labelWidth     = 63.5 * mm
+	labelHeight    = 38.1 * mm
+	labelPitch     = 66.0 * mm // horizontal distance between label edges
+	labelLeft      = 7.2 * mm
+	labelTop       = 15.15 * mm
+	labelColumns   = 3
+	labelRows      = 7
+	labelsOnSheet  = labelColumns * labelRows
+	labelPadding   = 8.0
+	labelFontSize  = 9.0
+	labelLineSpace = 11.0
+	labelMaxLines  = 8
+)
+
+// WriteLabelsPDF writes the labels as A4 label sheets, 21 to a page, for
+// printing straight onto a sheet of stick-on labels. No guides are drawn.
+// Text is set in Helvetica, so characters outside Latin-1 print as '?'.
+func WriteLabelsPDF(w io.Writer, labels []Label) error {
+	doc := newPDFDocument()
+	var pages []int
+	for start := 0; start < len(labels) || start == 0; start += labelsOnSheet {
+		var content bytes.Buffer
+		for i, label := range labels[start:min(start+labelsOnSheet, len(labels))] {
+			x := labelLeft + float64(i%labelColumns)*labelPitch
+			y := pageHeight - labelTop - float64(i/labelColumns+1)*labelHeight
+			drawLabel(&content, label, x, y)
+		}
+		if err := doc.addPage(&pages, pageWidth, pageHeight, content.Bytes()); err != nil {
+			return err
+		}
+	}
+	return doc.writeTo(w, pages, "Mailing labels")
+}
+
+// drawLabel sets one label with its lower-left corner at (x, y), the
+// addressee in bold and every line shrunk as needed to fit the width
+func drawLabel(out *bytes.Buffer, label Label, x, y float64) {
+	lines := label.Lines[:min(len(label.Lines), labelMaxLines)]
+	width := labelWidth - 2*labelPadding
+	// Centre the block vertically so short addresses do not hug the top edge
+	blockHeight := float64(len(lines)-1)*labelLineSpace + labelFontSize
+	baseline := y + labelHeight/2 + blockHeight/2 - labelFontSize
+	for i, line := range lines {
+		font, bold := "F1", false
+		if i == 0 {
+			font, bold = "F2", true
+		}
+		text(out, font, fitSize(line, labelFontSize, 6, width, bold), x+labelPadding, baseline-float64(i)*labelLineSpace, line)
+	}
+}
@@ -0,0 +1,132 @@
+package invitations
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdfDocument assembles a minimal PDF 1.4 file: numbered objects, then a
+// cross-reference table of their byte offsets. Only the two standard
+// Helvetica faces are used, so no fonts need to be embedded.
+type pdfDocument struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+	next    int
+}
+
+// Reserved object numbers
+const (
+	objCatalog = iota + 1
+	objPages
+	objFontRegular
+	objFontBold
+	firstFreeObject
+)
+
+func newPDFDocument() *pdfDocument {
+	d := &pdfDocument{offsets: make(map[int]int), next: firstFreeObject}
+	// The binary comment marks the file as binary for transfer tools
+	d.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	d.object(objFontRegular, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	d.object(objFontBold, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	return d
+}
+
+// allocate reserves the next object number
+func (d *pdfDocument) allocate() int {
+	d.next++
+	return d.next - 1
+}
+
+// object writes object n with the given body
+func (d *pdfDocument) object(n int, body string) {
+	d.offsets[n] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+// stream writes object n as a Flate-compressed stream
+func (d *pdfDocument) stream(n int, content []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(content); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	d.object(n, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	return nil
+}
+
+// addPage writes a page of the given size (in points) drawing content
+func (d *pdfDocument) addPage(pages *[]int, width, height float64, content []byte) error {
+	contentObj, pageObj := d.allocate(), d.allocate()
+	if err := d.stream(contentObj, content); err != nil {
+		return err
+	}
+	d.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+		"/Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		objPages, num(width), num(height), objFontRegular, objFontBold, contentObj))
+	*pages = append(*pages, pageObj)
+	return nil
+}
+
+// writeTo finishes the document with the page tree, catalog and trailer
+func (d *pdfDocument) writeTo(w io.Writer, pages []int, title string) error {
+	kids := make([]string, len(pages))
+	for i, p := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", p)
+	}
+	d.object(objPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	d.object(objCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", objPages))
+	info := d.allocate()
+	d.object(info, fmt.Sprintf("<< /Title %s /Producer (rsvp-backend) >>", pdfString(title)))
+
+	xref := d.buf.Len()
+	fmt.Fprintf(&d.buf, "xref\n0 %d\n0000000000 65535 f \n", d.next)
+	for n := 1; n < d.next; n++ {
+		fmt.Fprintf(&d.buf, "%010d 00000 n \n", d.offsets[n])
+	}
+	fmt.Fprintf(&d.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", d.next, objCatalog, info, xref)
+
+	_, err := w.Write(d.buf.Bytes())
+	return err
+}
+
+// pdfString encodes text as a literal PDF string in WinAnsiEncoding.
+// Characters the standard fonts cannot show are replaced with '?'.
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			// WinAnsi matches Latin-1 in this range
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '’':
+			b.WriteString("\\222") // right single quotation mark
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// num formats a coordinate without a trailing ".00"
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
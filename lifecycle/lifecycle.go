@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Manager runs background workers and stops them in a predictable order.
+// Workers are stopped in reverse registration order, so a worker may rely on
+// anything that was registered before it until it has exited.
+type Manager struct {
+	mu      sync.Mutex
+	workers []*worker
+	stopped bool
+}
+
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager initializes an empty worker manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Go starts run in its own goroutine. The context passed to run is cancelled
+// when the manager stops; run should return promptly once it is.
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		slog.Warn("worker not started: shutdown in progress", slog.String("worker", name))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+	m.workers = append(m.workers, w)
+
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+}
+
+// Stop cancels every worker, newest first, waiting for each to exit before
+// moving on. It gives up once ctx expires and reports which workers were still running.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	workers := m.workers
+	m.workers = nil
+	m.mu.Unlock()
+
+	for i := len(workers) - 1; i >= 0; i-- {
+		w := workers[i]
+		w.cancel()
+		select {
+		case <-w.done:
+			slog.Info("worker stopped", slog.String("worker", w.name))
+		case <-ctx.Done():
+			// Cancel the rest so they can still wind down on their own
+			for j := i - 1; j >= 0; j-- {
+				workers[j].cancel()
+			}
+			return fmt.Errorf("worker %s did not stop in time: %w", w.name, ctx.Err())
+		}
+	}
+
+	return nil
+}
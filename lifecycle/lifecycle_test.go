@@ -0,0 +1,58 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopOrdersWorkersNewestFirst(t *testing.T) {
+	m := NewManager()
+
+	var mu sync.Mutex
+	var order []string
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		m.Go(name, func(ctx context.Context) {
+			<-ctx.Done()
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		})
+	}
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	want := []string{"third", "second", "first"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("stop order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestStopGivesUpAfterDeadline(t *testing.T) {
+	m := NewManager()
+	release := make(chan struct{})
+	defer close(release)
+
+	m.Go("stubborn", func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Stop(ctx); err == nil {
+		t.Fatal("expected Stop to report the stuck worker")
+	}
+
+	// Workers registered after shutdown began are never started
+	started := make(chan struct{}, 1)
+	m.Go("late", func(context.Context) { started <- struct{}{} })
+	select {
+	case <-started:
+		t.Error("worker started after Stop")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in log output
+const Redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values are never logged
+var secretKeys = map[string]bool{
+	"token":         true,
+	"rsvp_token":    true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"smtp_password": true,
+	"cookie":        true,
+}
+
+// emailKeys are attribute keys holding email addresses, which are masked
+var emailKeys = map[string]bool{
+	"email": true,
+	"to":    true,
+	"from":  true,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// redactAttr is the slog ReplaceAttr hook applied to every attribute
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key]:
+		return slog.String(a.Key, Redacted)
+	case emailKeys[key]:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// Errors and other values are flattened to text so they can be scrubbed
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+// Scrub removes bearer credentials and masks email addresses found in free text.
+// The message and any string attribute pass through here.
+func Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	return emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// which is enough to correlate reports without exposing the address.
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// RedactToken shortens an RSVP token to a prefix safe for correlating log lines
+func RedactToken(token string) string {
+	if len(token) <= 8 {
+		return Redacted
+	}
+	return token[:4] + "…"
+}
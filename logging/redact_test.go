@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"bearer token", "Authorization: Bearer abc.def-ghi", "Authorization: Bearer [REDACTED]"},
+		{"email address", "sent to may@example.com", "sent to m***@example.com"},
+		{"plain text", "guest created", "guest created"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Scrub(tt.in); got != tt.want {
+				t.Errorf("Scrub(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	if got := RedactToken("short"); got != Redacted {
+		t.Errorf("RedactToken(short) = %q, want %q", got, Redacted)
+	}
+	if got := RedactToken("0123456789abcdef"); got != "0123…" {
+		t.Errorf("RedactToken() = %q, want prefix only", got)
+	}
+}
+
+func TestLoggerRedactsAttributes(t *testing.T) {
+	var out strings.Builder
+	logger, err := New(&out, "info")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Info("login for may@example.com",
+		slog.String("rsvp_token", "tok-secret-value"),
+		slog.String("Authorization", "Bearer abc"),
+		slog.String("email", "may@example.com"),
+		slog.Any("error", errors.New("smtp rejected may@example.com")),
+	)
+
+	line := out.String()
+	for _, leaked := range []string{"tok-secret-value", "Bearer abc", "may@example.com"} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("log line contains %q: %s", leaked, line)
+		}
+	}
+	if !strings.Contains(line, `"email":"m***@example.com"`) {
+		t.Errorf("email not masked: %s", line)
+	}
+}
+
+func TestNewRejectsUnknownLevel(t *testing.T) {
+	if _, err := New(&strings.Builder{}, "loud"); err == nil {
+		t.Error("New accepted an unknown level")
+	}
+}
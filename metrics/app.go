@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Application metrics, updated by the packages that own the measured behaviour
+var (
+	HTTPRequests = Default.NewCounterVec("rsvp_http_requests_total",
+		"HTTP requests by method, route template and status code.", "method", "route", "status")
+
+	HTTPDuration = Default.NewHistogramVec("rsvp_http_request_duration_seconds",
+		"HTTP request latency by method and route template.", DefaultBuckets, "method", "route")
+
+	AuthValidations = Default.NewCounterVec("rsvp_auth_validations_total",
+		"Admin token validations by outcome (valid, invalid, missing, error).", "outcome")
+
+	Emails = Default.NewCounterVec("rsvp_emails_total",
+		"Emails by kind and result (sent, failed).", "kind", "result")
+
+	RateLimited = Default.NewCounterVec("rsvp_rate_limited_total",
+		"Requests rejected by the rate limiter, by the limit that was exceeded (ip, token, failures).", "limit")
+)
+
+// scrapeTimeout bounds the work done to collect scrape-time metrics
+const scrapeTimeout = 5 * time.Second
+
+// RegisterDBStats exposes connection pool statistics from sql.DB.Stats()
+func RegisterDBStats(r *Registry, conn *sql.DB) {
+	stat := func(read func(sql.DBStats) float64) func(context.Context) ([]Sample, error) {
+		return func(context.Context) ([]Sample, error) {
+			return []Sample{{Value: read(conn.Stats())}}, nil
+		}
+	}
+
+	r.NewGaugeFunc("rsvp_db_max_open_connections", "Maximum number of open connections to the database.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	r.NewGaugeFunc("rsvp_db_open_connections", "Established connections, both in use and idle.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	r.NewGaugeFunc("rsvp_db_in_use_connections", "Connections currently in use.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	r.NewGaugeFunc("rsvp_db_idle_connections", "Idle connections.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	r.NewCounterFunc("rsvp_db_wait_count_total", "Connections waited for.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	r.NewCounterFunc("rsvp_db_wait_duration_seconds_total", "Time blocked waiting for a new connection.", nil,
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	r.NewCounterFunc("rsvp_db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	r.NewCounterFunc("rsvp_db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
+
+// Handler serves the registry in the text exposition format. When token is
+// non-empty, scrapers must send it as a bearer token.
+func (r *Registry) Handler(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token != "" {
+			given := ctx.GetHeader("Authorization")
+			if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+token)) != 1 {
+				ctx.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		scrapeCtx, cancel := context.WithTimeout(ctx.Request.Context(), scrapeTimeout)
+		defer cancel()
+
+		ctx.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		if err := r.WriteText(scrapeCtx, ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
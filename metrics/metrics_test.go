@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.", "route", "status")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	r.NewGaugeFunc("test_guests", "Guests.", []string{"status"}, func(context.Context) ([]Sample, error) {
+		return []Sample{{LabelValues: []string{"Pending"}, Value: 2}, {LabelValues: []string{"Attending"}, Value: 3}}, nil
+	})
+	r.NewGaugeFunc("test_broken", "Broken.", nil, func(context.Context) ([]Sample, error) {
+		return nil, errors.New("db down")
+	})
+
+	requests.WithLabelValues("/rsvp/:token", "200").Inc()
+	requests.WithLabelValues("/rsvp/:token", "200").Inc()
+	requests.WithLabelValues(`/odd"path`, "404").Inc()
+	latency.Observe(0.05, "/rsvp/:token")
+	latency.Observe(0.5, "/rsvp/:token")
+	latency.Observe(3, "/rsvp/:token")
+
+	var out strings.Builder
+	if err := r.WriteText(context.Background(), &out); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+
+	want := `# HELP test_broken Broken.
+# TYPE test_broken gauge
+# collection failed: db down
+# HELP test_guests Guests.
+# TYPE test_guests gauge
+test_guests{status="Attending"} 3
+test_guests{status="Pending"} 2
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/rsvp/:token",le="0.1"} 1
+test_latency_seconds_bucket{route="/rsvp/:token",le="1"} 2
+test_latency_seconds_bucket{route="/rsvp/:token",le="+Inf"} 3
+test_latency_seconds_sum{route="/rsvp/:token"} 3.55
+test_latency_seconds_count{route="/rsvp/:token"} 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/odd\"path",status="404"} 1
+test_requests_total{route="/rsvp/:token",status="200"} 2
+`
+	if out.String() != want {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "Dup.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	r.NewCounterVec("dup_total", "Dup.")
+}
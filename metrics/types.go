@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CounterVec is a family of monotonically increasing counters partitioned by labels
+type CounterVec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec registers a counter family
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{metricName: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+	r.register(c)
+	return c
+}
+
+// Counter is a single labelled counter
+type Counter struct {
+	vec    *CounterVec
+	values []string
+}
+
+// WithLabelValues selects the counter for the given label values, in declaration order
+func (c *CounterVec) WithLabelValues(values ...string) Counter {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.metricName, len(c.labels), len(values)))
+	}
+	return Counter{vec: c, values: values}
+}
+
+// Inc adds one
+func (c Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by delta, which must not be negative
+func (c Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+
+	key := labelKey(c.values)
+	v, ok := c.vec.values[key]
+	if !ok {
+		v = &counterValue{labels: append([]string(nil), c.values...)}
+		c.vec.values[key] = v
+	}
+	v.value += delta
+}
+
+// Value returns the current count, mainly for tests
+func (c Counter) Value() float64 {
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+
+	if v, ok := c.vec.values[labelKey(c.values)]; ok {
+		return v.value
+	}
+	return 0
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(_ context.Context, w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := writeHeader(w, c.metricName, c.help, "counter"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.metricName, formatLabels(c.labels, v.labels), formatValue(v.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramVec is a family of histograms partitioned by labels
+type HistogramVec struct {
+	metricName string
+	help       string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram family with the given upper bounds
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{metricName: name, help: help, labels: labels, buckets: sorted, values: make(map[string]*histogramValue)}
+	r.register(h)
+	return h
+}
+
+// Observe records one sample for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", h.metricName, len(h.labels), len(labelValues)))
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := labelKey(labelValues)
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			v.counts[i]++
+			break
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *HistogramVec) name() string { return h.metricName }
+
+func (h *HistogramVec) write(_ context.Context, w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := writeHeader(w, h.metricName, h.help, "histogram"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += v.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, v.labels, "le", formatValue(upper)), cumulative); err != nil {
+				return err
+			}
+		}
+		labels := formatLabels(h.labels, v.labels)
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.metricName, formatLabels(h.labels, v.labels, "le", "+Inf"), v.count,
+			h.metricName, labels, formatValue(v.sum),
+			h.metricName, labels, v.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample is one labelled value produced by a collect function
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// FuncFamily is a metric family whose samples are computed at scrape time,
+// for values owned elsewhere such as sql.DBStats or database aggregates
+type FuncFamily struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+	collect    func(ctx context.Context) ([]Sample, error)
+}
+
+// NewGaugeFunc registers a gauge family computed by collect on every scrape.
+// If collect fails the family is rendered without samples and the error is noted as a comment.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(ctx context.Context) ([]Sample, error)) *FuncFamily {
+	f := &FuncFamily{metricName: name, help: help, kind: "gauge", labels: labels, collect: collect}
+	r.register(f)
+	return f
+}
+
+// NewCounterFunc registers a counter family read from a cumulative source on every scrape
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func(ctx context.Context) ([]Sample, error)) *FuncFamily {
+	f := &FuncFamily{metricName: name, help: help, kind: "counter", labels: labels, collect: collect}
+	r.register(f)
+	return f
+}
+
+func (f *FuncFamily) name() string { return f.metricName }
+
+func (f *FuncFamily) write(ctx context.Context, w io.Writer) error {
+	if err := writeHeader(w, f.metricName, f.help, f.kind); err != nil {
+		return err
+	}
+
+	samples, err := f.collect(ctx)
+	if err != nil {
+		_, err = fmt.Fprintf(w, "# collection failed: %s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		return err
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return labelKey(samples[i].LabelValues) < labelKey(samples[j].LabelValues)
+	})
+	for _, s := range samples {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", f.metricName, formatLabels(f.labels, s.LabelValues), formatValue(s.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,32 +1,38 @@
 package middlewares
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/metrics"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates the JWT token by calling the authentication service
-func AuthMiddleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		// ✅ Fetch AUTH_SERVICE_URL inside the function to ensure it is read at runtime
-		authServiceURL := os.Getenv("AUTH_SERVICE_URL")
-		if authServiceURL == "" {
-			log.Println("❌ AUTH_SERVICE_URL is not set")
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "AUTH_SERVICE_URL is not set"})
-			ctx.Abort()
-			return
-		}
+// authRequestTimeout bounds each call to the authentication service
+const authRequestTimeout = 5 * time.Second
+
+// maxAuthResponseBytes caps how much of the auth service's response is read
+const maxAuthResponseBytes = 64 << 10
+
+// authClient is shared so connections to the auth service are reused
+var authClient = &http.Client{Timeout: authRequestTimeout}
 
+// AuthMiddleware validates the JWT token by calling the authentication service at authServiceURL
+func AuthMiddleware(authServiceURL string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
 		// Get token from the Authorization header
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+			metrics.AuthValidations.WithLabelValues("missing").Inc()
+			ctx.Error(apperrors.New(apperrors.ErrUnauthorized, "missing token"))
 			ctx.Abort()
 			return
 		}
@@ -35,24 +41,30 @@ func AuthMiddleware() gin.HandlerFunc {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// Validate the token with the authentication service
-		valid, err := validateTokenWithAuthService(authServiceURL, token)
-		if err != nil || !valid {
-			log.Printf("❌ Token validation failed: %v", err)
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		valid, err := validateTokenWithAuthService(ctx.Request.Context(), authServiceURL, token)
+		if err != nil {
+			logging.FromContext(ctx.Request.Context()).Warn("token validation failed", slog.Any("error", err))
+			metrics.AuthValidations.WithLabelValues("error").Inc()
+			ctx.Error(apperrors.Wrap(apperrors.ErrUpstream, err, "authentication service unavailable"))
+			ctx.Abort()
+			return
+		}
+		if !valid {
+			metrics.AuthValidations.WithLabelValues("invalid").Inc()
+			ctx.Error(apperrors.New(apperrors.ErrUnauthorized, "invalid or expired token"))
 			ctx.Abort()
 			return
 		}
 
 		// Proceed if token is valid
+		metrics.AuthValidations.WithLabelValues("valid").Inc()
 		ctx.Next()
 	}
 }
 
 // validateTokenWithAuthService sends the token to the authentication service for validation
-func validateTokenWithAuthService(authServiceURL, token string) (bool, error) {
-	// ✅ Ensure the URL is not empty
+func validateTokenWithAuthService(ctx context.Context, authServiceURL, token string) (bool, error) {
 	if authServiceURL == "" {
-		log.Println("❌ AUTH_SERVICE_URL is empty in validateTokenWithAuthService")
 		return false, fmt.Errorf("AUTH_SERVICE_URL is empty")
 	}
 
@@ -60,38 +72,42 @@ func validateTokenWithAuthService(authServiceURL, token string) (bool, error) {
 	url := fmt.Sprintf("%s/auth/validate", authServiceURL)
 
 	// Create the request with the token in the Authorization header
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return false, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
 
 	// Log the outgoing request
-	log.Printf("🔄 Validating token with auth service: %s", url)
+	logger := logging.FromContext(ctx)
+	logger.Debug("validating token with auth service", slog.String("url", url))
 
 	// Make the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := authClient.Do(req)
 	if err != nil {
-		log.Printf("❌ Error calling auth service: %v", err)
 		return false, err
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Drain a bounded amount of the body so the connection can be reused
+	read, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxAuthResponseBytes))
 	if err != nil {
-		log.Printf("❌ Error reading auth service response: %v", err)
 		return false, err
 	}
 
-	// Log the response
-	log.Printf("🔄 Auth service response: %s", string(body))
+	// Log only the outcome; the response body may echo claims or credentials
+	logger.Debug("auth service responded", slog.Int("status", resp.StatusCode), slog.Int64("body_bytes", read))
 
-	// If status is 200, the token is valid
-	if resp.StatusCode == http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
 		return true, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, nil
+	default:
+		// Anything else is the auth service failing, not the token being bad
+		return false, fmt.Errorf("auth service responded with status %d", resp.StatusCode)
 	}
-
-	return false, fmt.Errorf("invalid token")
 }
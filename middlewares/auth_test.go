@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name       string
+		authStatus int // Response of the auth service; zero means it cannot be reached
+		header     string
+		want       int
+	}{
+		{"valid token", http.StatusOK, "Bearer good", http.StatusOK},
+		{"missing token", http.StatusOK, "", http.StatusUnauthorized},
+		{"rejected token", http.StatusUnauthorized, "Bearer bad", http.StatusUnauthorized},
+		{"forbidden token", http.StatusForbidden, "Bearer bad", http.StatusUnauthorized},
+		{"auth service error", http.StatusInternalServerError, "Bearer good", http.StatusBadGateway},
+		{"auth service unavailable", http.StatusServiceUnavailable, "Bearer good", http.StatusBadGateway},
+		{"auth service unreachable", 0, "Bearer good", http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := unreachable.URL
+			if tt.authStatus != 0 {
+				auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tt.authStatus)
+				}))
+				defer auth.Close()
+				url = auth.URL
+			}
+
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/admin", AuthMiddleware(url), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
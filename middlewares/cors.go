@@ -2,24 +2,129 @@ package middlewares
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/config"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware sets up Cross-Origin Resource Sharing (CORS) rules
-func CORSMiddleware() gin.HandlerFunc {
+// corsAllowedHeaders are the request headers browsers may send cross-origin
+const corsAllowedHeaders = "Content-Type, Authorization, If-Match, If-None-Match, X-Request-ID"
+
+// corsExposedHeaders are the response headers scripts may read cross-origin
+const corsExposedHeaders = "Content-Length, Content-Type, ETag, Retry-After, X-Request-ID"
+
+// CORS applies a Cross-Origin Resource Sharing policy to a route group.
+// Only allowlisted origins receive CORS headers, and the origin is echoed
+// rather than answered with "*" so credentials can be allowed per policy.
+// Preflight requests from allowed origins are answered here with 204; the
+// group must route OPTIONS requests for them to reach this middleware.
+func CORS(policy config.CORSPolicy, methods ...string) gin.HandlerFunc {
+	allowed := newOriginMatcher(policy.AllowedOrigins)
+	allowMethods := strings.Join(append(methods[:len(methods):len(methods)], http.MethodOptions), ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
 	return func(ctx *gin.Context) {
-		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*") // Allow frontend
-		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		ctx.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type") // Fix for missing headers
+		header := ctx.Writer.Header()
+		// Responses differ by origin, so shared caches must key on it
+		header.Add("Vary", "Origin")
+
+		origin := ctx.GetHeader("Origin")
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			// Same-origin or non-browser request
+			ctx.Next()
+			return
+		}
+
+		if !allowed.match(origin) {
+			if preflight {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Without CORS headers the browser withholds the response from the page
+			ctx.Next()
+			return
+		}
 
-		// Allow actual requests, not just preflight
-		if ctx.Request.Method == "OPTIONS" {
+		header.Set("Access-Control-Allow-Origin", origin)
+		if policy.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			header.Set("Access-Control-Max-Age", maxAge)
 			ctx.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
+		header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
 		ctx.Next()
 	}
 }
+
+// Preflight is the OPTIONS route handler for a CORS-enabled group; the CORS
+// middleware has already answered preflights from allowed origins by then.
+func Preflight(ctx *gin.Context) {
+	ctx.Status(http.StatusNoContent)
+}
+
+// originMatcher checks origins against exact and wildcard-subdomain entries
+type originMatcher struct {
+	any       bool
+	exact     map[string]bool
+	wildcards []wildcardOrigin
+}
+
+// wildcardOrigin matches any subdomain of suffix under scheme
+type wildcardOrigin struct {
+	scheme string // "https://"
+	suffix string // ".example.com" or ".example.com:8443"
+}
+
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if origin == "*" {
+			m.any = true
+			continue
+		}
+		if scheme, host, ok := strings.Cut(origin, "://*."); ok {
+			m.wildcards = append(m.wildcards, wildcardOrigin{scheme: scheme + "://", suffix: "." + host})
+			continue
+		}
+		m.exact[origin] = true
+	}
+	return m
+}
+
+func (m *originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		host, ok := strings.CutPrefix(origin, w.scheme)
+		// The subdomain must be non-empty and the apex itself does not match
+		if ok && strings.HasSuffix(host, w.suffix) && len(host) > len(w.suffix) {
+			sub := strings.TrimSuffix(host, w.suffix)
+			if !strings.ContainsAny(sub, "/:@") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := newOriginMatcher([]string{"https://axeldaphne.com", "https://*.example.com"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://axeldaphne.com", true},
+		{"HTTPS://AxelDaphne.com", true},
+		{"http://axeldaphne.com", false},
+		{"https://evil-axeldaphne.com", false},
+		{"https://admin.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"https://example.com.evil.io", false},
+		{"https://evil.io/.example.com", false},
+		{"http://admin.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := m.match(tt.origin); got != tt.want {
+				t.Errorf("match(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/admin")
+	group.Use(CORS(config.CORSPolicy{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}, http.MethodGet, http.MethodPatch))
+	group.OPTIONS("/*path", Preflight)
+	group.GET("/guests", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  bool
+		wantStatus int
+		wantOrigin string
+		wantMaxAge string
+		wantCreds  string
+	}{
+		{"allowed request", http.MethodGet, "https://admin.example.com", false, http.StatusOK, "https://admin.example.com", "", "true"},
+		{"disallowed request", http.MethodGet, "https://evil.io", false, http.StatusOK, "", "", ""},
+		{"no origin", http.MethodGet, "", false, http.StatusOK, "", "", ""},
+		{"allowed preflight", http.MethodOptions, "https://admin.example.com", true, http.StatusNoContent, "https://admin.example.com", "3600", "true"},
+		{"disallowed preflight", http.MethodOptions, "https://evil.io", true, http.StatusForbidden, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/guests", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.wantCreds)
+			}
+			if rec.Header().Values("Vary")[0] != "Origin" {
+				t.Errorf("Vary = %v, want Origin first", rec.Header().Values("Vary"))
+			}
+		})
+	}
+}
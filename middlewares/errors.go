@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest is the de-facto status for requests abandoned by the client
+const statusClientClosedRequest = 499
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// errorStatuses maps each error kind to its HTTP status, checked in order.
+// Deadlines come first so a timed-out upstream call reports 504, not 502.
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{context.DeadlineExceeded, http.StatusGatewayTimeout},
+	{apperrors.ErrValidation, http.StatusBadRequest},
+	{apperrors.ErrUnauthorized, http.StatusUnauthorized},
+	{apperrors.ErrForbidden, http.StatusForbidden},
+	{apperrors.ErrNotFound, http.StatusNotFound},
+	{apperrors.ErrConflict, http.StatusConflict},
+	{apperrors.ErrPreconditionFailed, http.StatusPreconditionFailed},
+	{apperrors.ErrPreconditionRequired, http.StatusPreconditionRequired},
+	{apperrors.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+	{apperrors.ErrTooManyRequests, http.StatusTooManyRequests},
+	{apperrors.ErrUpstream, http.StatusBadGateway},
+	{apperrors.ErrInternal, http.StatusInternalServerError},
+}
+
+// ErrorHandler turns the last error recorded with ctx.Error into an
+// application/problem+json response. Handlers and middlewares record the error
+// and return; this is the only place that decides status codes for failures.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		err := ctx.Errors.Last().Err
+
+		// The client has gone away; record the status for logging only
+		if errors.Is(err, context.Canceled) {
+			ctx.Status(statusClientClosedRequest)
+			return
+		}
+
+		status, detail := StatusOf(err), apperrors.Detail(err)
+		if status >= http.StatusInternalServerError {
+			logging.FromContext(ctx.Request.Context()).Error("request failed", slog.Int("status", status), slog.Any("error", err))
+		}
+		if status == http.StatusInternalServerError {
+			// Never leak internal error text to clients
+			detail = "an unexpected error occurred"
+		}
+		if status == http.StatusGatewayTimeout {
+			detail = "request timed out"
+		}
+
+		WriteProblem(ctx, status, detail)
+	}
+}
+
+// StatusOf returns the HTTP status for err, defaulting to 500
+func StatusOf(err error) int {
+	for _, entry := range errorStatuses {
+		if errors.Is(err, entry.kind) {
+			return entry.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteProblem writes an RFC 7807 problem details response and aborts the chain
+func WriteProblem(ctx *gin.Context, status int, detail string) {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+	}
+	ctx.Header("Content-Type", "application/problem+json")
+	ctx.AbortWithStatusJSON(status, problem)
+}
+
+// Recovery turns a panic into a logged 500 problem response instead of a dropped connection
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		logging.FromContext(ctx.Request.Context()).Error("panic recovered",
+			slog.Any("panic", recovered),
+			slog.String("stack", string(debug.Stack())),
+		)
+		WriteProblem(ctx, http.StatusInternalServerError, "an unexpected error occurred")
+	})
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"validation", apperrors.New(apperrors.ErrValidation, "bad"), http.StatusBadRequest},
+		{"wrapped not found", fmt.Errorf("lookup: %w", apperrors.New(apperrors.ErrNotFound, "missing")), http.StatusNotFound},
+		{"forbidden", apperrors.New(apperrors.ErrForbidden, "no"), http.StatusForbidden},
+		{"conflict", apperrors.New(apperrors.ErrConflict, "dup"), http.StatusConflict},
+		{"precondition", apperrors.New(apperrors.ErrPreconditionFailed, "stale"), http.StatusPreconditionFailed},
+		{"upstream", apperrors.Wrap(apperrors.ErrUpstream, errors.New("smtp down"), "send failed"), http.StatusBadGateway},
+		{"upstream timeout", apperrors.Wrap(apperrors.ErrUpstream, context.DeadlineExceeded, "send failed"), http.StatusGatewayTimeout},
+		{"internal", apperrors.New(apperrors.ErrInternal, "signing is not configured"), http.StatusInternalServerError},
+		{"unclassified", errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusOf(tt.err); got != tt.want {
+				t.Errorf("StatusOf() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/metrics"
+	"github.com/g4l1l10/rsvp-backend/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPeekBody bounds how much of a request body is read to find its RSVP token
+const maxPeekBody = 64 << 10
+
+// rateCheck is one bucket consulted before a request is let through
+type rateCheck struct {
+	name  string // metric label
+	key   string
+	limit ratelimit.Limit
+	cost  int
+}
+
+// RateLimit throttles the public RSVP routes with token buckets kept in store:
+// one per client IP, one per RSVP token, and one counting invalid token
+// lookups per IP. Once an IP exhausts its invalid-lookup budget it is blocked
+// until the budget refills, which makes guessing tokens impractical.
+// The client IP honours the router's trusted proxies.
+func RateLimit(cfg config.RateLimitConfig, store ratelimit.Store) gin.HandlerFunc {
+	perIP := ratelimit.Limit(cfg.PerIP)
+	perToken := ratelimit.Limit(cfg.PerToken)
+	failures := ratelimit.Limit(cfg.Failures)
+
+	return func(ctx *gin.Context) {
+		if !cfg.Enabled {
+			ctx.Next()
+			return
+		}
+
+		ip := ctx.ClientIP()
+		checks := []rateCheck{
+			// Cost 0 only checks the failure budget; failures are recorded after the handler
+			{"failures", "failures:" + ip, failures, 0},
+			{"ip", "ip:" + ip, perIP, 1},
+		}
+		if token := rsvpToken(ctx); token != "" {
+			checks = append(checks, rateCheck{"token", "token:" + token, perToken, 1})
+		}
+
+		for _, check := range checks {
+			result, err := store.Take(ctx.Request.Context(), check.key, check.limit, check.cost)
+			if err != nil {
+				// Fail open: an unavailable store must not lock guests out
+				logging.FromContext(ctx.Request.Context()).Warn("rate limit store unavailable", slog.Any("error", err))
+				break
+			}
+			if !result.Allowed {
+				metrics.RateLimited.WithLabelValues(check.name).Inc()
+				ctx.Header("Retry-After", retryAfterSeconds(result.RetryAfter))
+				ctx.Error(apperrors.New(apperrors.ErrTooManyRequests, "too many requests, please try again later"))
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Next()
+
+		// An unknown token is the signature of guessing; charge it to the IP
+		if err := ctx.Errors.Last(); err != nil && errors.Is(err.Err, apperrors.ErrNotFound) {
+			if _, err := store.Take(ctx.Request.Context(), "failures:"+ip, failures, 1); err != nil {
+				logging.FromContext(ctx.Request.Context()).Warn("rate limit store unavailable", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// rsvpToken returns the token named in the path or, for submissions, in the
+// JSON body. The body is restored so the handler can still bind it.
+func rsvpToken(ctx *gin.Context) string {
+	if token := ctx.Param("token"); token != "" {
+		return token
+	}
+	if ctx.Request.Body == nil || ctx.ContentType() != "application/json" {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPeekBody))
+	if err != nil {
+		return ""
+	}
+	ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), ctx.Request.Body))
+
+	var req struct {
+		RSVPToken string `json:"rsvp_token"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return ""
+	}
+	return req.RSVPToken
+}
+
+// retryAfterSeconds formats a wait as whole seconds, rounding up so clients do not retry too early
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
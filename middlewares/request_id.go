@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID between clients, this service and upstreams
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits accepted client IDs to short, log-safe values
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// secretParams are path parameters that must never appear in logs
+var secretParams = map[string]bool{"token": true, "code": true}
+
+// RequestID assigns every request an ID, reusing a well-formed incoming
+// X-Request-ID, echoes it in the response, and stores a request-scoped logger
+// carrying it on the request context for every layer below.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, id)
+
+		logger := slog.Default().With(slog.String("request_id", id))
+		reqCtx := logging.WithRequestID(ctx.Request.Context(), id)
+		reqCtx = logging.WithLogger(reqCtx, logger)
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+	}
+}
+
+// AccessLog writes one structured line per request once it has completed.
+// Token path parameters are redacted so RSVP links never reach the logs.
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		path := ctx.Request.URL.Path
+		for _, param := range ctx.Params {
+			if secretParams[param.Key] && param.Value != "" {
+				path = strings.Replace(path, param.Value, logging.RedactToken(param.Value), 1)
+			}
+		}
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logging.FromContext(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), level, "request completed",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.Int("response_bytes", ctx.Writer.Size()),
+		)
+	}
+}
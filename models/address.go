@@ -0,0 +1,174 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+)
+
+// PostalAddress is where paper invitations are mailed. Country is an ISO
+// 3166-1 alpha-2 code; which other fields are required, and how they are laid
+// out on a label, depends on it.
+type PostalAddress struct {
+	Line1      string `json:"address_line1"`
+	Line2      string `json:"address_line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"` // State, province, prefecture or county
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// addressFormat describes a country's postal conventions
+type addressFormat struct {
+	name          string // English name, printed on international mail
+	requireCity   bool
+	requireRegion bool
+	requirePostal bool
+	postal        *regexp.Regexp // Accepted postal codes, after normalizing
+	regionLabel   string         // What the country calls its regions, for error messages
+	postalLabel   string         // What the country calls its postal codes
+	// locality lays out the lines after the street, with {city}, {region}
+	// and {postal} replaced
+	locality []string
+}
+
+// defaultFormat applies to countries without specific rules
+var defaultFormat = addressFormat{
+	requireCity: true,
+	regionLabel: "region",
+	postalLabel: "postal code",
+	locality:    []string{"{city} {region} {postal}"},
+}
+
+// addressFormats holds the rules for the countries guests most often live in
+var addressFormats = map[string]addressFormat{
+	"AU": {name: "AUSTRALIA", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^\d{4}$`),
+		regionLabel: "state", postalLabel: "postcode", locality: []string{"{city} {region} {postal}"}},
+	"CA": {name: "CANADA", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^[A-Z]\d[A-Z] \d[A-Z]\d$`),
+		regionLabel: "province", postalLabel: "postal code", locality: []string{"{city} {region} {postal}"}},
+	"CN": {name: "CHINA", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^\d{6}$`),
+		regionLabel: "province", postalLabel: "postal code", locality: []string{"{city}, {region} {postal}"}},
+	"DE": {name: "GERMANY", requireCity: true, requirePostal: true, postal: regexp.MustCompile(`^\d{5}$`),
+		regionLabel: "state", postalLabel: "postal code", locality: []string{"{postal} {city}"}},
+	"FR": {name: "FRANCE", requireCity: true, requirePostal: true, postal: regexp.MustCompile(`^\d{5}$`),
+		regionLabel: "region", postalLabel: "postal code", locality: []string{"{postal} {city}"}},
+	"GB": {name: "UNITED KINGDOM", requireCity: true, requirePostal: true, postal: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`),
+		regionLabel: "county", postalLabel: "postcode", locality: []string{"{city}", "{postal}"}},
+	"HK": {name: "HONG KONG", requireRegion: true,
+		regionLabel: "district", postalLabel: "postal code", locality: []string{"{city}", "{region}"}},
+	"ID": {name: "INDONESIA", requireCity: true, requirePostal: true, postal: regexp.MustCompile(`^\d{5}$`),
+		regionLabel: "province", postalLabel: "postal code", locality: []string{"{city} {postal}", "{region}"}},
+	"JP": {name: "JAPAN", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^\d{3}-\d{4}$`),
+		regionLabel: "prefecture", postalLabel: "postal code", locality: []string{"{city}, {region} {postal}"}},
+	"MY": {name: "MALAYSIA", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^\d{5}$`),
+		regionLabel: "state", postalLabel: "postcode", locality: []string{"{postal} {city}", "{region}"}},
+	"NL": {name: "NETHERLANDS", requireCity: true, requirePostal: true, postal: regexp.MustCompile(`^\d{4} [A-Z]{2}$`),
+		regionLabel: "province", postalLabel: "postal code", locality: []string{"{postal} {city}"}},
+	"SG": {name: "SINGAPORE", requirePostal: true, postal: regexp.MustCompile(`^\d{6}$`),
+		regionLabel: "region", postalLabel: "postal code", locality: []string{"SINGAPORE {postal}"}},
+	"TW": {name: "TAIWAN", requireCity: true, postal: regexp.MustCompile(`^\d{3}(\d{2,3})?$`),
+		regionLabel: "county", postalLabel: "postal code", locality: []string{"{city} {postal}"}},
+	"US": {name: "UNITED STATES", requireCity: true, requireRegion: true, requirePostal: true, postal: regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		regionLabel: "state", postalLabel: "ZIP code", locality: []string{"{city}, {region} {postal}"}},
+}
+
+// countryCode matches an ISO 3166-1 alpha-2 code once upper-cased
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Normalize trims the fields, upper-cases the country and puts postal codes
+// into their country's canonical spacing, e.g. "sw1a1aa" becomes "SW1A 1AA"
+func (a *PostalAddress) Normalize() {
+	for _, field := range []*string{&a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode} {
+		*field = strings.Join(strings.Fields(*field), " ")
+	}
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.PostalCode = strings.ToUpper(a.PostalCode)
+
+	compact := strings.ReplaceAll(a.PostalCode, " ", "")
+	switch a.Country {
+	case "CA", "GB":
+		if len(compact) > 3 {
+			a.PostalCode = compact[:len(compact)-3] + " " + compact[len(compact)-3:]
+		}
+	case "NL":
+		if len(compact) == 6 {
+			a.PostalCode = compact[:4] + " " + compact[4:]
+		}
+	case "JP":
+		if len(compact) == 7 && !strings.Contains(compact, "-") {
+			a.PostalCode = compact[:3] + "-" + compact[3:]
+		}
+	}
+}
+
+// Validate checks that the fields the country requires are present and that
+// the postal code has the country's format. Call Normalize first.
+func (a *PostalAddress) Validate() error {
+	if a.Country == "" {
+		return apperrors.New(apperrors.ErrValidation, "country is required")
+	}
+	if !countryCode.MatchString(a.Country) {
+		return apperrors.Newf(apperrors.ErrValidation, "country %q must be a two-letter ISO 3166 code such as ID or US", a.Country)
+	}
+	if a.Line1 == "" {
+		return apperrors.New(apperrors.ErrValidation, "address_line1 is required")
+	}
+
+	format := a.format()
+	country := a.Country
+	if format.name != "" {
+		country = format.name
+	}
+	if format.requireCity && a.City == "" {
+		return apperrors.Newf(apperrors.ErrValidation, "city is required for addresses in %s", country)
+	}
+	if format.requireRegion && a.Region == "" {
+		return apperrors.Newf(apperrors.ErrValidation, "%s is required for addresses in %s", format.regionLabel, country)
+	}
+	if format.requirePostal && a.PostalCode == "" {
+		return apperrors.Newf(apperrors.ErrValidation, "%s is required for addresses in %s", format.postalLabel, country)
+	}
+	if a.PostalCode != "" && format.postal != nil && !format.postal.MatchString(a.PostalCode) {
+		return apperrors.Newf(apperrors.ErrValidation, "%q is not a valid %s in %s", a.PostalCode, format.postalLabel, country)
+	}
+	return nil
+}
+
+// Lines lays the address out as printed on an envelope, below the
+// addressee. The country is printed last, in capitals, unless the letter is
+// posted from the same country; homeCountry may be empty.
+func (a *PostalAddress) Lines(homeCountry string) []string {
+	lines := []string{a.Line1}
+	if a.Line2 != "" {
+		lines = append(lines, a.Line2)
+	}
+
+	format := a.format()
+	fill := strings.NewReplacer("{city}", a.City, "{region}", a.Region, "{postal}", a.PostalCode)
+	for _, layout := range format.locality {
+		// Drop the separators left around fields that are empty
+		line := strings.Join(strings.Fields(fill.Replace(layout)), " ")
+		line = strings.TrimSpace(strings.Trim(strings.ReplaceAll(line, " ,", ","), ","))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if !strings.EqualFold(a.Country, homeCountry) {
+		if format.name != "" {
+			lines = append(lines, format.name)
+		} else {
+			lines = append(lines, a.Country)
+		}
+	}
+	return lines
+}
+
+// format returns the postal conventions of the address's country
+func (a *PostalAddress) format() addressFormat {
+	if format, ok := addressFormats[a.Country]; ok {
+		return format
+	}
+	return defaultFormat
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// Bulk actions applied to a selection of guests
+const (
+	BulkSendInvites   = "send_invites"
+	BulkSendReminders = "send_reminders"
+	BulkUpdate        = "update"
+	BulkDelete        = "delete"
+)
+
+// BulkAction is an action to apply to every guest a filter selects.
+// Update holds the merge patch applied by BulkUpdate and is ignored otherwise.
+type BulkAction struct {
+	Action string
+	Update *GuestPatch
+}
+
+// Validate checks the action and, for updates, the patch. Names, emails and
+// phone numbers identify a guest, so they cannot be set in bulk.
+func (a BulkAction) Validate() error {
+	switch a.Action {
+	case BulkSendInvites, BulkSendReminders, BulkDelete:
+		return nil
+	case BulkUpdate:
+		if a.Update == nil || a.Update.IsEmpty() {
+			return apperrors.New(apperrors.ErrValidation, "update requires at least one field to change")
+		}
+		if a.Update.Name != nil || a.Update.Email != nil || a.Update.Phone != nil {
+			return apperrors.New(apperrors.ErrValidation, "name, email and phone cannot be updated in bulk")
+		}
+		return a.Update.Validate()
+	case "":
+		return apperrors.New(apperrors.ErrValidation, "action is required")
+	}
+	return apperrors.Newf(apperrors.ErrValidation, "unknown action %q: use %s, %s, %s or %s", a.Action, BulkSendInvites, BulkSendReminders, BulkUpdate, BulkDelete)
+}
+
+// Applies reports whether the action affects the guest. Reminders only go to
+// guests who have not answered yet; every other action affects every guest.
+func (a BulkAction) Applies(g *Guest) bool {
+	if a.Action == BulkSendReminders {
+		return g.RSVPStatus == RSVPStatusPending
+	}
+	return true
+}
+
+// BulkPreview lists the guests a bulk action would affect, so the count can
+// be confirmed before the action runs
+type BulkPreview struct {
+	Action string  `json:"action"`
+	Count  int     `json:"count"`
+	Guests []Guest `json:"guests"`
+}
+
+// BulkResult reports how a bulk action went, guest by guest
+type BulkResult struct {
+	Action    string        `json:"action"`
+	Count     int           `json:"count"` // Guests the action was applied to
+	Succeeded int           `json:"succeeded"`
+	Failed    []BulkFailure `json:"failed"`
+}
+
+// BulkFailure records why the action failed for one guest
+type BulkFailure struct {
+	GuestID uuid.UUID `json:"guest_id"`
+	Name    string    `json:"name"`
+	Error   string    `json:"error"`
+}
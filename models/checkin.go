@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckIn records party members of a guest arriving on the day
+type CheckIn struct {
+	ID          uuid.UUID `json:"id"`
+	GuestID     uuid.UUID `json:"guest_id"`
+	Arrived     int       `json:"arrived"` // Party members arriving together
+	CheckedInAt time.Time `json:"checked_in_at"`
+}
+
+// Warning codes returned with a check-in for door staff to act on
+const (
+	CheckInWarningAlreadyCheckedIn = "already_checked_in"
+	CheckInWarningExceedsParty     = "exceeds_party_size"
+	CheckInWarningNotAttending     = "not_attending"
+	CheckInWarningRSVPPending      = "rsvp_pending"
+)
+
+// CheckInWarning flags something unusual about a check-in that was still recorded
+type CheckInWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CheckInResult describes a recorded check-in and the party's arrivals so far
+type CheckInResult struct {
+	Guest    *Guest           `json:"guest"`
+	CheckIn  CheckIn          `json:"check_in"`
+	Arrived  int              `json:"arrived"`  // Party members arrived, including this check-in
+	Expected int              `json:"expected"` // The guest's total_guests
+	Warnings []CheckInWarning `json:"warnings"`
+}
+
+// CheckInSummary compares arrivals with the guests expected to attend
+type CheckInSummary struct {
+	ExpectedParties     int        `json:"expected_parties"`     // Guests marked attending
+	ExpectedAttendees   int        `json:"expected_attendees"`   // Their total_guests summed
+	ArrivedParties      int        `json:"arrived_parties"`      // Guests with at least one check-in
+	ArrivedAttendees    int        `json:"arrived_attendees"`    // Party members checked in
+	RemainingAttendees  int        `json:"remaining_attendees"`  // Attending party members not yet arrived
+	UnexpectedAttendees int        `json:"unexpected_attendees"` // Arrivals beyond what attending guests announced
+	LastCheckInAt       *time.Time `json:"last_check_in_at,omitempty"`
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DefaultDuplicateScore is the score from which two guests are reported as
+// likely duplicates when the caller does not choose one
+const DefaultDuplicateScore = 0.6
+
+// Weights of each field in a duplicate score. A field only counts when both
+// guests have it, and the score is divided by the weights that counted.
+const (
+	nameWeight  = 0.6
+	emailWeight = 0.25
+	phoneWeight = 0.15
+)
+
+// Reasons given for a duplicate candidate
+const (
+	DuplicateReasonSameName     = "same_name"
+	DuplicateReasonSimilarName  = "similar_name"
+	DuplicateReasonSameEmail    = "same_email"
+	DuplicateReasonSimilarEmail = "similar_email"
+	DuplicateReasonSamePhone    = "same_phone"
+)
+
+// DuplicateCandidate is a pair of guests that are probably the same party
+// entered twice, with how alike they are from 0 to 1 and why
+type DuplicateCandidate struct {
+	Guests  [2]Guest `json:"guests"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// GuestMerge records one guest being folded into another, keeping both
+// records as they were before the merge
+type GuestMerge struct {
+	ID          uuid.UUID `json:"id"`
+	PrimaryID   uuid.UUID `json:"primary_id"`
+	DuplicateID uuid.UUID `json:"duplicate_id"`
+	Primary     Guest     `json:"primary"`   // The guest that was kept, before the merge
+	Duplicate   Guest     `json:"duplicate"` // The guest that was removed
+	MergedAt    time.Time `json:"merged_at"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+// ScoreDuplicate compares two guests by normalized name, email and phone.
+// Guests sharing an email or phone number are always reported, whatever
+// their names, so ok is true for them as well as for scores of at least minScore.
+func ScoreDuplicate(a, b *Guest, minScore float64) (candidate DuplicateCandidate, ok bool) {
+	var score, weights float64
+	var reasons []string
+	certain := false
+
+	if nameA, nameB := normalizeName(a.Name), normalizeName(b.Name); nameA != "" && nameB != "" {
+		alike := similarity(nameA, nameB)
+		score += nameWeight * alike
+		weights += nameWeight
+		switch {
+		case alike == 1:
+			reasons = append(reasons, DuplicateReasonSameName)
+		case alike >= 0.8:
+			reasons = append(reasons, DuplicateReasonSimilarName)
+		}
+	}
+
+	if emailA, emailB := normalizeEmail(a.Email), normalizeEmail(b.Email); emailA != "" && emailB != "" {
+		weights += emailWeight
+		switch {
+		case emailA == emailB:
+			score += emailWeight
+			reasons = append(reasons, DuplicateReasonSameEmail)
+			certain = true
+		case localPart(emailA) == localPart(emailB):
+			score += emailWeight * 0.8
+			reasons = append(reasons, DuplicateReasonSimilarEmail)
+		}
+	}
+
+	if phoneA, phoneB := normalizePhone(a.Phone), normalizePhone(b.Phone); phoneA != "" && phoneB != "" {
+		weights += phoneWeight
+		if phoneA == phoneB {
+			score += phoneWeight
+			reasons = append(reasons, DuplicateReasonSamePhone)
+			certain = true
+		}
+	}
+
+	if weights > 0 {
+		score = math.Round(score/weights*100) / 100
+	}
+	candidate = DuplicateCandidate{Guests: [2]Guest{*a, *b}, Score: score, Reasons: reasons}
+	return candidate, certain || score >= minScore
+}
+
+// Absorb folds what a duplicate record knows into the guest, which keeps
+// its own name, email and RSVP credentials. Blank details are filled in,
+// an answer replaces a pending RSVP and hongbao amounts are added up.
+// Tags, sub-event invitations and check-ins are merged by the repository.
+func (g *Guest) Absorb(duplicate *Guest) {
+	if g.FamilySide == "" {
+		g.FamilySide = duplicate.FamilySide
+	}
+	if g.PreferredLanguage == "" {
+		g.PreferredLanguage = duplicate.PreferredLanguage
+	}
+	if g.Phone == "" {
+		g.Phone = duplicate.Phone
+	}
+	if g.HouseholdID == nil && duplicate.HouseholdID != nil {
+		household := *duplicate.HouseholdID
+		g.HouseholdID = &household
+	}
+	if g.RSVPStatus == RSVPStatusPending && duplicate.RSVPStatus != RSVPStatusPending {
+		g.RSVPStatus = duplicate.RSVPStatus
+		g.TotalGuests = duplicate.TotalGuests
+	}
+	g.Hongbao += duplicate.Hongbao
+	for _, tag := range duplicate.Tags {
+		if !g.HasTag(tag) {
+			g.Tags = append(g.Tags, tag)
+		}
+	}
+	slices.SortFunc(g.Tags, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+}
+
+// honorifics are dropped from names before comparing them, so "Aunt May
+// Tan" matches "May Tan"
+var honorifics = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true,
+	"aunt": true, "auntie": true, "aunty": true, "uncle": true,
+	"tante": true, "om": true, "ibu": true, "bu": true, "pak": true, "bapak": true,
+}
+
+// accents folds common Latin letters with diacritics to their base letter
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// normalizeName lower-cases a name, folds accents, drops punctuation and
+// honorifics and sorts the remaining words, so word order does not matter
+func normalizeName(name string) string {
+	name = accents.Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	words = slices.DeleteFunc(words, func(w string) bool { return honorifics[w] })
+	slices.Sort(words)
+	return strings.Join(words, " ")
+}
+
+// normalizeEmail lower-cases an address and drops any +suffix, and for
+// Gmail the dots, which do not change where mail is delivered
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// localPart returns the part of a normalized address before the @
+func localPart(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+	return local
+}
+
+// normalizePhone keeps the last nine digits of a number, so "+62 812-3456-7890"
+// and "0812 3456 7890" compare equal whatever the country prefix
+func normalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) < 6 {
+		return ""
+	}
+	if len(digits) > 9 {
+		digits = digits[len(digits)-9:]
+	}
+	return digits
+}
+
+// similarity is one minus the edit distance between a and b divided by the
+// length of the longer, counted in runes
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein counts the insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -13,10 +13,33 @@ type Guest struct {
 	Hongbao     float64   `json:"hongbao"`
 	TotalGuests int       `json:"total_guests"`
 	RSVPStatus  string    `json:"rsvp_status"`
-	RSVPToken   string    `json:"rsvp_token"`
+	RSVPCode    string    `json:"rsvp_code"` // Short code printed on invitation cards; empty once revoked
+	Version     int       `json:"version"`   // Incremented on every write, used for optimistic locking
+
+	// PreferredLanguage is the language emails and the RSVP page use for the
+	// guest, one of i18n.Supported; empty when not known
+	PreferredLanguage string `json:"preferred_language"`
+	// Phone is an optional contact number, kept as entered
+	Phone string `json:"phone"`
+	// Tags lists the names of the guest's tags in alphabetical order; they
+	// are assigned through the tag endpoints, not by updating the guest
+	Tags []string `json:"tags,omitempty"`
+	// HouseholdID is the household whose address the guest's paper
+	// invitation is mailed to, if any
+	HouseholdID *uuid.UUID `json:"household_id"`
+
+	// RSVPToken is a signed magic-link token. It is only set on the value
+	// returned when a token is issued and is never stored.
+	RSVPToken string `json:"rsvp_token,omitempty"`
+	// RSVPTokenHash identifies the current magic link; empty once revoked
+	RSVPTokenHash string `json:"-"`
+	// LegacyRSVPTokenHash identifies a plaintext token issued before links
+	// were signed (see tokens.HashLegacy); the token itself is not stored
+	LegacyRSVPTokenHash string `json:"-"`
 }
 
-// NewGuest initializes a new Guest with a UUID
+// NewGuest initializes a new Guest with a UUID and RSVP code; the service
+// issues the signed RSVP token once the guest ID is known
 func NewGuest(name, email, familySide string, totalGuests int) *Guest {
 	return &Guest{
 		ID:          uuid.New(), // Generate a new UUID
@@ -25,7 +48,27 @@ func NewGuest(name, email, familySide string, totalGuests int) *Guest {
 		FamilySide:  familySide,
 		Hongbao:     0, // Default value, can be updated later
 		TotalGuests: totalGuests,
-		RSVPStatus:  "Pending",
-		RSVPToken:   uuid.New().String(), // Generate a unique RSVP token
+		RSVPStatus:  RSVPStatusPending,
+		RSVPCode:    NewRSVPCode(),
 	}
 }
+
+// PublicGuest is what anyone holding a guest's RSVP link may see of them:
+// enough to answer, and none of the contact details or gifts the couple keeps
+type PublicGuest struct {
+	Name        string `json:"name"`
+	RSVPStatus  string `json:"rsvp_status"`
+	TotalGuests int    `json:"total_guests"`
+}
+
+// Public returns the fields of the guest that may be shown on their RSVP page
+func (g *Guest) Public() PublicGuest {
+	return PublicGuest{Name: g.Name, RSVPStatus: g.RSVPStatus, TotalGuests: g.TotalGuests}
+}
+
+// RSVPSummary aggregates guests sharing one RSVP status
+type RSVPSummary struct {
+	RSVPStatus string `json:"rsvp_status"`
+	Guests     int    `json:"guests"`    // Number of guest records (invitations)
+	Attendees  int    `json:"attendees"` // Sum of total_guests across those records
+}
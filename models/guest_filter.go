@@ -0,0 +1,55 @@
+package models
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// GuestFilter selects guests for bulk operations such as printing invitations.
+// Empty fields match every guest; set fields must all match.
+type GuestFilter struct {
+	RSVPStatus string
+	FamilySide string
+	IDs        []uuid.UUID
+	Tags       []string // Tag names; a guest must carry every one of them
+}
+
+// IsEmpty reports whether the filter selects every guest
+func (f GuestFilter) IsEmpty() bool {
+	return f.RSVPStatus == "" && f.FamilySide == "" && len(f.IDs) == 0 && len(f.Tags) == 0
+}
+
+// Validate checks the filter values
+func (f GuestFilter) Validate() error {
+	if f.RSVPStatus != "" {
+		if err := validateRSVPStatus(f.RSVPStatus); err != nil {
+			return err
+		}
+	}
+	for _, tag := range f.Tags {
+		if _, err := NormalizeTagName(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the guest is selected by the filter
+func (f GuestFilter) Matches(g *Guest) bool {
+	if f.RSVPStatus != "" && g.RSVPStatus != f.RSVPStatus {
+		return false
+	}
+	if f.FamilySide != "" && g.FamilySide != f.FamilySide {
+		return false
+	}
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, g.ID) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !g.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
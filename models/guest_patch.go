@@ -0,0 +1,306 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/i18n"
+)
+
+// RSVP status values accepted by the API
+const (
+	RSVPStatusPending      = "Pending"
+	RSVPStatusAttending    = "Attending"
+	RSVPStatusNotAttending = "Not Attending"
+)
+
+// IsValidRSVPStatus reports whether status is one of the known RSVP statuses
+func IsValidRSVPStatus(status string) bool {
+	switch status {
+	case RSVPStatusPending, RSVPStatusAttending, RSVPStatusNotAttending:
+		return true
+	}
+	return false
+}
+
+// GuestPatch describes a partial update to a guest (JSON Merge Patch, RFC 7396).
+// A nil field means "leave unchanged"; a non-nil field is the new value.
+// The read-only fields of a guest are listed in readOnlyGuestFields.
+type GuestPatch struct {
+	Name        *string
+	Email       *string
+	FamilySide  *string
+	Hongbao     *float64
+	TotalGuests *int
+	RSVPStatus  *string
+
+	PreferredLanguage *string
+	Phone             *string
+}
+
+// readOnlyGuestFields are members of a guest as it is read that a patch
+// cannot change, so a client may send back what it fetched. They are changed
+// elsewhere: the RSVP link and code by regenerating them, tags and households
+// through their own endpoints, and the version by every write.
+var readOnlyGuestFields = map[string]bool{
+	"id":           true,
+	"rsvp_token":   true,
+	"rsvp_code":    true,
+	"version":      true,
+	"tags":         true,
+	"household_id": true,
+}
+
+// UnmarshalJSON decodes a merge patch document. Members set to null clear
+// optional fields back to their zero value; null is rejected for required fields.
+// Read-only members are ignored.
+func (p *GuestPatch) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return apperrors.New(apperrors.ErrValidation, "merge patch must be a JSON object")
+	}
+
+	// Iterate in a stable order so error messages are deterministic
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := doc[key]
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		var err error
+		switch key {
+		case "name":
+			p.Name, err = decodeRequired[string](key, raw, isNull)
+		case "email":
+			p.Email, err = decodeRequired[string](key, raw, isNull)
+		case "family_side":
+			p.FamilySide, err = decodeClearable[string](key, raw, isNull)
+		case "hongbao":
+			p.Hongbao, err = decodeClearable[float64](key, raw, isNull)
+		case "total_guests":
+			p.TotalGuests, err = decodeRequired[int](key, raw, isNull)
+		case "rsvp_status":
+			p.RSVPStatus, err = decodeRequired[string](key, raw, isNull)
+		case "preferred_language":
+			p.PreferredLanguage, err = decodeClearable[string](key, raw, isNull)
+		case "phone":
+			p.Phone, err = decodeClearable[string](key, raw, isNull)
+		default:
+			if !readOnlyGuestFields[key] {
+				err = apperrors.Newf(apperrors.ErrValidation, "unknown field %q", key)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeRequired decodes a member that may not be cleared with null
+func decodeRequired[T any](key string, raw json.RawMessage, isNull bool) (*T, error) {
+	if isNull {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "%s cannot be null", key)
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid value for %s", key)
+	}
+	return &v, nil
+}
+
+// decodeClearable decodes a member where null resets the field to its zero value
+func decodeClearable[T any](key string, raw json.RawMessage, isNull bool) (*T, error) {
+	var v T
+	if isNull {
+		return &v, nil
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid value for %s", key)
+	}
+	return &v, nil
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p *GuestPatch) IsEmpty() bool {
+	return p.Name == nil && p.Email == nil && p.FamilySide == nil &&
+		p.Hongbao == nil && p.TotalGuests == nil && p.RSVPStatus == nil && p.PreferredLanguage == nil && p.Phone == nil
+}
+
+// Validate checks every field present in the patch
+func (p *GuestPatch) Validate() error {
+	if p.Name != nil {
+		if err := validateName(*p.Name); err != nil {
+			return err
+		}
+	}
+	if p.Email != nil {
+		if err := validateEmail(*p.Email); err != nil {
+			return err
+		}
+	}
+	if p.Hongbao != nil {
+		if err := validateHongbao(*p.Hongbao); err != nil {
+			return err
+		}
+	}
+	if p.TotalGuests != nil {
+		if err := validateTotalGuests(*p.TotalGuests); err != nil {
+			return err
+		}
+	}
+	if p.RSVPStatus != nil {
+		if err := validateRSVPStatus(*p.RSVPStatus); err != nil {
+			return err
+		}
+	}
+	if p.PreferredLanguage != nil {
+		lang, err := NormalizeLanguage(*p.PreferredLanguage)
+		if err != nil {
+			return err
+		}
+		p.PreferredLanguage = &lang
+	}
+	if p.Phone != nil {
+		phone := strings.TrimSpace(*p.Phone)
+		if err := validatePhone(phone); err != nil {
+			return err
+		}
+		p.Phone = &phone
+	}
+	return nil
+}
+
+// Apply copies every field present in the patch onto the guest
+func (p *GuestPatch) Apply(guest *Guest) {
+	if p.Name != nil {
+		guest.Name = *p.Name
+	}
+	if p.Email != nil {
+		guest.Email = *p.Email
+	}
+	if p.FamilySide != nil {
+		guest.FamilySide = *p.FamilySide
+	}
+	if p.Hongbao != nil {
+		guest.Hongbao = *p.Hongbao
+	}
+	if p.TotalGuests != nil {
+		guest.TotalGuests = *p.TotalGuests
+	}
+	if p.RSVPStatus != nil {
+		guest.RSVPStatus = *p.RSVPStatus
+	}
+	if p.PreferredLanguage != nil {
+		guest.PreferredLanguage = *p.PreferredLanguage
+	}
+	if p.Phone != nil {
+		guest.Phone = *p.Phone
+	}
+}
+
+// Validate checks a complete guest record, as used for full replacement
+func (g *Guest) Validate() error {
+	if err := validateName(g.Name); err != nil {
+		return err
+	}
+	if err := validateEmail(g.Email); err != nil {
+		return err
+	}
+	if err := validateHongbao(g.Hongbao); err != nil {
+		return err
+	}
+	if err := validateTotalGuests(g.TotalGuests); err != nil {
+		return err
+	}
+	lang, err := NormalizeLanguage(g.PreferredLanguage)
+	if err != nil {
+		return err
+	}
+	g.PreferredLanguage = lang
+	g.Phone = strings.TrimSpace(g.Phone)
+	if err := validatePhone(g.Phone); err != nil {
+		return err
+	}
+	return validateRSVPStatus(g.RSVPStatus)
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return apperrors.New(apperrors.ErrValidation, "name cannot be empty")
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return apperrors.New(apperrors.ErrValidation, "email must be a valid address")
+	}
+	return nil
+}
+
+// validatePhone accepts empty numbers and those written with digits, spaces
+// and the usual punctuation, such as "+62 812-3456-7890" or "(021) 555 0123"
+func validatePhone(phone string) error {
+	if phone == "" {
+		return nil
+	}
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0, r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return apperrors.New(apperrors.ErrValidation, "phone may only contain digits, spaces, a leading + and - . ( )")
+		}
+	}
+	if digits < 6 || digits > 15 {
+		return apperrors.New(apperrors.ErrValidation, "phone must have between 6 and 15 digits")
+	}
+	return nil
+}
+
+func validateHongbao(hongbao float64) error {
+	if hongbao < 0 {
+		return apperrors.New(apperrors.ErrValidation, "hongbao cannot be negative")
+	}
+	return nil
+}
+
+func validateTotalGuests(totalGuests int) error {
+	if totalGuests <= 0 {
+		return apperrors.New(apperrors.ErrValidation, "total guests must be greater than zero")
+	}
+	return nil
+}
+
+func validateRSVPStatus(status string) error {
+	if !IsValidRSVPStatus(status) {
+		return apperrors.Newf(apperrors.ErrValidation, "rsvp status must be one of %q, %q or %q", RSVPStatusPending, RSVPStatusAttending, RSVPStatusNotAttending)
+	}
+	return nil
+}
+
+// NormalizeLanguage reduces a preferred language such as "zh-CN" to its
+// supported code; empty means not known and is kept
+func NormalizeLanguage(lang string) (string, error) {
+	if lang == "" {
+		return "", nil
+	}
+	normalized, ok := i18n.Normalize(lang)
+	if !ok {
+		return "", apperrors.Newf(apperrors.ErrValidation, "preferred language must be one of %s", strings.Join(i18n.Supported, ", "))
+	}
+	return normalized, nil
+}
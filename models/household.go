@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// Household is a postal address shared by guests who receive one paper
+// invitation, such as a family. Addressee is the name on the envelope.
+type Household struct {
+	ID        uuid.UUID `json:"id"`
+	Addressee string    `json:"addressee"` // e.g. "Mr. & Mrs. Tan and family"
+	PostalAddress
+	CreatedAt time.Time `json:"created_at"`
+	Guests    int       `json:"guests"` // Number of guests living there
+}
+
+// Validate trims and checks the household, including its address
+func (h *Household) Validate() error {
+	h.Addressee = strings.Join(strings.Fields(h.Addressee), " ")
+	if h.Addressee == "" {
+		return apperrors.New(apperrors.ErrValidation, "addressee is required")
+	}
+	h.PostalAddress.Normalize()
+	return h.PostalAddress.Validate()
+}
+
+// MailingLabel is the address block printed for one household
+type MailingLabel struct {
+	HouseholdID uuid.UUID
+	Addressee   string
+	Address     PostalAddress
+	Lines       []string // Addressee first, then the address as printed
+	Guests      []string // Names of the selected guests living there
+}
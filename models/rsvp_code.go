@@ -0,0 +1,60 @@
+package models
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// RSVPCodeLength is the number of characters in a short RSVP code
+const RSVPCodeLength = 8
+
+// rsvpCodeAlphabet leaves out characters that are easily confused when read
+// off a printed card (0/O, 1/I/L) as well as U, which avoids accidental words
+const rsvpCodeAlphabet = "ABCDEFGHJKMNPQRSTVWXYZ23456789"
+
+// NewRSVPCode generates a random short RSVP code
+func NewRSVPCode() string {
+	max := big.NewInt(int64(len(rsvpCodeAlphabet)))
+	code := make([]byte, RSVPCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand only fails if the OS entropy source is broken
+			panic("rsvp code: " + err.Error())
+		}
+		code[i] = rsvpCodeAlphabet[n.Int64()]
+	}
+	return string(code)
+}
+
+// NormalizeRSVPCode canonicalizes a code typed by a guest: case is ignored,
+// as are spaces and hyphens used to group characters. It reports false if
+// the input cannot be a code.
+func NormalizeRSVPCode(input string) (string, bool) {
+	code := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(input))
+
+	if len(code) != RSVPCodeLength {
+		return "", false
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(rsvpCodeAlphabet, r) {
+			return "", false
+		}
+	}
+	return code, true
+}
+
+// FormatRSVPCode groups a code into two halves for printing, e.g. "ABCD-2345".
+// NormalizeRSVPCode accepts the result as typed.
+func FormatRSVPCode(code string) string {
+	if len(code) != RSVPCodeLength {
+		return code
+	}
+	return code[:RSVPCodeLength/2] + "-" + code[RSVPCodeLength/2:]
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RSVPResponse is a guest's answer to their invitation, as reported in
+// confirmation and digest emails
+type RSVPResponse struct {
+	GuestID        uuid.UUID `json:"guest_id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	RSVPStatus     string    `json:"rsvp_status"`
+	TotalGuests    int       `json:"total_guests"`
+	PreviousStatus string    `json:"previous_status"` // Pending for a first answer
+	RespondedAt    time.Time `json:"responded_at"`
+	Language       string    `json:"language,omitempty"` // Guest's preferred language, if known
+}
+
+// Changed reports whether the guest had already answered and changed their mind
+func (r RSVPResponse) Changed() bool {
+	return r.PreviousStatus != RSVPStatusPending && r.PreviousStatus != r.RSVPStatus
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// SubEvent is a part of the wedding with its own guest list, such as the tea
+// ceremony, the banquet or the after-party
+type SubEvent struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Venue       string    `json:"venue"`
+	Address     string    `json:"address"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Translations holds the name and description in other languages, keyed
+	// by language; anything missing is shown as written above
+	Translations map[string]SubEventText `json:"translations,omitempty"`
+}
+
+// SubEventText is a sub-event's name and description in one language
+type SubEventText struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Localized returns the sub-event with its text in lang where translated
+func (e SubEvent) Localized(lang string) SubEvent {
+	text := e.Translations[lang]
+	if text.Name != "" {
+		e.Name = text.Name
+	}
+	if text.Description != "" {
+		e.Description = text.Description
+	}
+	e.Translations = nil
+	return e
+}
+
+// Validate checks the fields an admin provides
+func (e *SubEvent) Validate() error {
+	e.Name = strings.TrimSpace(e.Name)
+	if e.Name == "" {
+		return apperrors.New(apperrors.ErrValidation, "sub-event name is required")
+	}
+	if e.StartsAt.IsZero() || e.EndsAt.IsZero() {
+		return apperrors.New(apperrors.ErrValidation, "sub-event start and end times are required")
+	}
+	if !e.EndsAt.After(e.StartsAt) {
+		return apperrors.New(apperrors.ErrValidation, "sub-event must end after it starts")
+	}
+
+	// Key translations by supported language code, so "zh-CN" finds "zh"
+	translations := make(map[string]SubEventText, len(e.Translations))
+	for lang, text := range e.Translations {
+		normalized, err := NormalizeLanguage(lang)
+		if err != nil || normalized == "" {
+			return apperrors.Newf(apperrors.ErrValidation, "translation language %q is not supported", lang)
+		}
+		translations[normalized] = SubEventText{Name: strings.TrimSpace(text.Name), Description: text.Description}
+	}
+	e.Translations = translations
+	return nil
+}
+
+// Invitation says a guest is invited to a sub-event and holds their answer
+type Invitation struct {
+	SubEventID  uuid.UUID  `json:"sub_event_id"`
+	GuestID     uuid.UUID  `json:"guest_id"`
+	RSVPStatus  string     `json:"rsvp_status"`
+	Attendees   int        `json:"attendees"` // Party members coming; zero unless attending
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// SubEventAnswer is a guest's reply for one sub-event
+type SubEventAnswer struct {
+	SubEventID uuid.UUID `json:"sub_event_id"`
+	RSVPStatus string    `json:"rsvp_status"`
+	Attendees  int       `json:"attendees"`
+}
+
+// Validate checks the answer; attendees are required when attending
+func (a *SubEventAnswer) Validate() error {
+	switch a.RSVPStatus {
+	case RSVPStatusAttending:
+		if a.Attendees <= 0 {
+			return apperrors.New(apperrors.ErrValidation, "attendees must be greater than zero when attending")
+		}
+	case RSVPStatusNotAttending:
+		a.Attendees = 0
+	default:
+		return apperrors.Newf(apperrors.ErrValidation, "rsvp_status must be %q or %q", RSVPStatusAttending, RSVPStatusNotAttending)
+	}
+	return nil
+}
+
+// GuestSubEvent is a sub-event as shown to an invited guest, with their answer
+type GuestSubEvent struct {
+	SubEvent
+	RSVPStatus string `json:"rsvp_status"`
+	Attendees  int    `json:"attendees"`
+}
+
+// SubEventSummary counts the answers for one sub-event
+type SubEventSummary struct {
+	SubEventID        uuid.UUID `json:"sub_event_id"`
+	Name              string    `json:"name"`
+	Invited           int       `json:"invited"` // Invited guests (parties)
+	Pending           int       `json:"pending"`
+	Attending         int       `json:"attending"`
+	NotAttending      int       `json:"not_attending"`
+	ExpectedAttendees int       `json:"expected_attendees"` // Sum of attendees of attending guests
+}
+
+// InvitationMatrix lists which guest is invited to which sub-event
+type InvitationMatrix struct {
+	SubEvents []SubEvent       `json:"sub_events"`
+	Guests    []InvitationsRow `json:"guests"`
+}
+
+// InvitationsRow is one guest's row of the invitation matrix; Invitations
+// maps sub-event IDs to the guest's status and holds only the sub-events the
+// guest is invited to
+type InvitationsRow struct {
+	GuestID     uuid.UUID            `json:"guest_id"`
+	Name        string               `json:"name"`
+	Invitations map[uuid.UUID]string `json:"invitations"`
+}
+
+// RSVPPage is what a guest sees when opening their RSVP link: their
+// invitation, the sub-events they are invited to and the page's labels, all
+// in Language. The link is public, so only the guest's public fields are shown.
+type RSVPPage struct {
+	PublicGuest
+	Language  string            `json:"language"`
+	Labels    map[string]string `json:"labels"`
+	SubEvents []GuestSubEvent   `json:"sub_events"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// maxTagName bounds tag names, which are shown as chips in the admin UI
+const maxTagName = 50
+
+// Tag is an admin-defined label grouping guests, such as "college friends",
+// "overseas" or "VIP". A guest may carry any number of tags.
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"` // Unique regardless of case
+	CreatedAt time.Time `json:"created_at"`
+	Guests    int       `json:"guests"` // Number of guests carrying the tag
+}
+
+// NormalizeTagName trims a tag name and checks it is usable
+func NormalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", apperrors.New(apperrors.ErrValidation, "tag name is required")
+	}
+	if len([]rune(name)) > maxTagName {
+		return "", apperrors.Newf(apperrors.ErrValidation, "tag name must be at most %d characters", maxTagName)
+	}
+	if strings.Contains(name, ",") {
+		return "", apperrors.New(apperrors.ErrValidation, "tag name cannot contain commas")
+	}
+	return name, nil
+}
+
+// HasTag reports whether the guest carries the named tag, ignoring case
+func (g *Guest) HasTag(name string) bool {
+	for _, tag := range g.Tags {
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	// Published format information for level M, masks 0-7
+	wantFormat := []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+	for mask, want := range wantFormat {
+		if got := formatBits(mask); got != want {
+			t.Errorf("formatBits(%d) = %015b, want %015b", mask, got, want)
+		}
+	}
+	if got := versionBits(7); got != 0x07C94 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+
+	// Byte capacities at level M from the standard's tables
+	for version, want := range map[int]int{1: 14, 2: 26, 5: 84, 10: 213, 40: 2331} {
+		if got := dataCodewords(version) - (4+countBits(version)+7)/8; got != want {
+			t.Errorf("version %d holds %d bytes, want %d", version, got, want)
+		}
+	}
+	for version, want := range map[int][]int{1: nil, 2: {6, 18}, 7: {6, 22, 38}, 14: {6, 26, 46, 66}, 32: {6, 34, 60, 86, 112, 138}} {
+		if got := alignmentPositions(version); !slices.Equal(got, want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" at 1-M, a widely published worked example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"https://axeldaphne.com/rsvp/ABCD2345",
+		"https://axeldaphne.com/rsvp/v1.2026.qV0wz7dOQy2Xp3U4mZfJc1sAAAAAaQ2k0KXf5o7Gv8SJb9M.3rDq1Z7mJv0bXn8t5S4Y2Wc6a9LkQeHfGdPu",
+		strings.Repeat("x", 500),
+	} {
+		code, err := Encode([]byte(data))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(data), err)
+		}
+		if got := decode(t, code); got != data {
+			t.Errorf("decoded %q, want %q", got, data)
+		}
+	}
+
+	if _, err := Encode(make([]byte, 2332)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("oversized data: err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	code, _ := Encode([]byte("https://axeldaphne.com/rsvp/ABCD2345"))
+	var buf bytes.Buffer
+	if err := code.WritePNG(&buf, 4); err != nil {
+		t.Fatalf("WritePNG: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	width := (code.Size() + 2*QuietZone) * 4
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != width {
+		t.Fatalf("image is %v, want %dx%d", b, width, width)
+	}
+	// Quiet zone is light; the top-left finder corner is dark
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("quiet zone is dark")
+	}
+	if r, _, _, _ := img.At(QuietZone*4, QuietZone*4).RGBA(); r != 0 {
+		t.Error("finder corner is light")
+	}
+}
+
+// decode reads a symbol back independently of the drawing order used by Encode:
+// it checks the format information, removes the mask, collects the codewords,
+// verifies every block's error correction and parses the byte-mode segment
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+	size := code.Size()
+	version := (size - 17) / 4
+
+	// Both copies of the format information must agree
+	var first, second int
+	for i, p := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if code.Dark(p[0], p[1]) {
+			first |= 1 << i
+		}
+	}
+	for i := 0; i < 15; i++ {
+		x, y := 8, size-15+i
+		if i < 8 {
+			x, y = size-1-i, 8
+		}
+		if code.Dark(x, y) {
+			second |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == first {
+			mask = m
+		}
+	}
+	if mask < 0 || first != second || !code.Dark(8, size-8) {
+		t.Fatalf("bad format information %015b / %015b", first, second)
+	}
+
+	// Collect unmasked codewords from the data modules
+	layout := newCode(version)
+	layout.drawFunctionPatterns()
+	var bits bitBuffer
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !layout.function[y][x] {
+					bits = append(bits, code.Dark(x, y) != masked(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()[:rawDataModules(version)/8]
+
+	// De-interleave: data codewords column by column, long blocks having one more
+	blocks, eccLen := numBlocks[version], eccCodewordsPerBlock[version]
+	shortData := len(codewords)/blocks - eccLen
+	shortBlocks := blocks - len(codewords)%blocks
+	dataBlocks := make([][]byte, blocks)
+	k := 0
+	for i := 0; i <= shortData; i++ {
+		for j := range dataBlocks {
+			if i < shortData || j >= shortBlocks {
+				dataBlocks[j] = append(dataBlocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	eccBlocks := make([][]byte, blocks)
+	for i := 0; i < eccLen; i++ {
+		for j := range eccBlocks {
+			eccBlocks[j] = append(eccBlocks[j], codewords[k])
+			k++
+		}
+	}
+	var data []byte
+	for j := range dataBlocks {
+		if want := rsRemainder(dataBlocks[j], rsDivisor(eccLen)); !bytes.Equal(eccBlocks[j], want) {
+			t.Fatalf("block %d error correction mismatch", j)
+		}
+		data = append(data, dataBlocks[j]...)
+	}
+
+	// Parse the single byte-mode segment
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode indicator %x, want byte mode", data[0]>>4)
+	}
+	var stream bitBuffer
+	for _, b := range data {
+		stream.append(int(b), 8)
+	}
+	read := func(from, n int) int {
+		v := 0
+		for _, bit := range stream[from : from+n] {
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		return v
+	}
+	n := read(4, countBits(version))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(4+countBits(version)+8*i, 8))
+	}
+	return string(out)
+}
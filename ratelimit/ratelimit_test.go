@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{t: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = c.now
+	return s, c
+}
+
+func TestTakeRefills(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+	limit := Limit{Burst: 2, Period: time.Minute} // one token every 30s
+
+	for i := 0; i < 2; i++ {
+		if r, _ := store.Take(ctx, "k", limit, 1); !r.Allowed {
+			t.Fatalf("request %d rejected within burst", i+1)
+		}
+	}
+
+	r, _ := store.Take(ctx, "k", limit, 1)
+	if r.Allowed || r.RetryAfter != 30*time.Second {
+		t.Fatalf("over burst: got %+v, want rejection with 30s retry", r)
+	}
+
+	clock.t = clock.t.Add(30 * time.Second)
+	if r, _ := store.Take(ctx, "k", limit, 1); !r.Allowed {
+		t.Error("request rejected after refill")
+	}
+	if r, _ := store.Take(ctx, "other", limit, 1); !r.Allowed {
+		t.Error("buckets are not independent per key")
+	}
+}
+
+func TestZeroCostOnlyChecks(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore()
+	limit := Limit{Burst: 1, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		if r, _ := store.Take(ctx, "k", limit, 0); !r.Allowed {
+			t.Fatal("zero-cost check consumed tokens")
+		}
+	}
+	store.Take(ctx, "k", limit, 1)
+	if r, _ := store.Take(ctx, "k", limit, 0); r.Allowed {
+		t.Error("zero-cost check allowed an empty bucket")
+	}
+}
+
+func TestSweepDropsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+	limit := Limit{Burst: 1, Period: time.Minute}
+
+	store.Take(ctx, "idle", limit, 1)
+	clock.t = clock.t.Add(45 * time.Second)
+	store.Take(ctx, "busy", limit, 1)
+	clock.t = clock.t.Add(20 * time.Second)
+
+	store.Sweep()
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("refilled bucket was kept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Error("partially drained bucket was dropped")
+	}
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// checkInColumns lists the columns read for every check-in query, in scan order
+const checkInColumns = "id, guest_id, arrived, checked_in_at"
+
+// CreateCheckIn records an arrival and returns the guest's earlier check-ins,
+// oldest first. The guest row stays locked from reading the earlier check-ins
+// until the arrival is stored, so two scans of one party at the same moment
+// are counted one after the other. A zero checkIn.Arrived is filled in with
+// the rest of the party, and at least one. It fails with ErrGuestNotFound if
+// the guest does not exist.
+func (r *GuestRepository) CreateCheckIn(ctx context.Context, checkIn *models.CheckIn) ([]models.CheckIn, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	var partySize int
+	err = tx.QueryRowContext(ctx, "SELECT total_guests FROM guests WHERE id = $1 FOR UPDATE", checkIn.GuestID).Scan(&partySize)
+	if err == sql.ErrNoRows {
+		return nil, ErrGuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + checkInColumns + " FROM checkins WHERE guest_id = $1 ORDER BY checked_in_at, id"
+	previous, err := queryCheckIns(ctx, tx, query, checkIn.GuestID)
+	if err != nil {
+		return nil, err
+	}
+	if checkIn.Arrived == 0 {
+		checkIn.Arrived = restOfParty(partySize, previous)
+	}
+
+	query = "INSERT INTO checkins (" + checkInColumns + ") VALUES ($1, $2, $3, $4)"
+	if _, err := tx.ExecContext(ctx, query, checkIn.ID, checkIn.GuestID, checkIn.Arrived, checkIn.CheckedInAt); err != nil {
+		return nil, err
+	}
+	return previous, tx.Commit()
+}
+
+// GetCheckIns retrieves a guest's check-ins, oldest first
+func (r *GuestRepository) GetCheckIns(ctx context.Context, guestID uuid.UUID) ([]models.CheckIn, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + checkInColumns + " FROM checkins WHERE guest_id = $1 ORDER BY checked_in_at, id"
+	return queryCheckIns(ctx, r.DB, query, guestID)
+}
+
+// GetAllCheckIns retrieves every check-in, oldest first
+func (r *GuestRepository) GetAllCheckIns(ctx context.Context) ([]models.CheckIn, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + checkInColumns + " FROM checkins ORDER BY checked_in_at, id"
+	return queryCheckIns(ctx, r.DB, query)
+}
+
+// queryCheckIns runs a query selecting checkInColumns and collects the results
+func queryCheckIns(ctx context.Context, q querier, query string, args ...interface{}) ([]models.CheckIn, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkIns []models.CheckIn
+	for rows.Next() {
+		var c models.CheckIn
+		if err := rows.Scan(&c.ID, &c.GuestID, &c.Arrived, &c.CheckedInAt); err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, c)
+	}
+
+	return checkIns, rows.Err()
+}
+
+// restOfParty counts the party members who have not checked in yet, and at
+// least one: a party that has fully arrived is still being scanned for someone
+func restOfParty(partySize int, previous []models.CheckIn) int {
+	arrived := 0
+	for _, c := range previous {
+		arrived += c.Arrived
+	}
+	return max(partySize-arrived, 1)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// CheckInStore persists the arrivals recorded at the door
+type CheckInStore interface {
+	CreateCheckIn(ctx context.Context, checkIn *models.CheckIn) ([]models.CheckIn, error)
+	GetCheckIns(ctx context.Context, guestID uuid.UUID) ([]models.CheckIn, error)
+	GetAllCheckIns(ctx context.Context) ([]models.CheckIn, error)
+}
+
+// Compile-time checks that both implementations satisfy CheckInStore
+var (
+	_ CheckInStore = (*GuestRepository)(nil)
+	_ CheckInStore = (*MemoryGuestRepository)(nil)
+)
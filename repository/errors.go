@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrGuestNotFound is returned when no guest matches the lookup
+	ErrGuestNotFound = apperrors.New(apperrors.ErrNotFound, "guest not found")
+
+	// ErrInvalidToken is returned when no guest holds the given RSVP token
+	ErrInvalidToken = apperrors.New(apperrors.ErrNotFound, "invalid RSVP token")
+
+	// ErrVersionConflict is returned when a conditional write finds that the
+	// guest was modified since the caller last read it
+	ErrVersionConflict = apperrors.New(apperrors.ErrPreconditionFailed, "guest was modified by another request")
+
+	// ErrDuplicateGuest is returned when an insert collides with a unique key
+	ErrDuplicateGuest = apperrors.New(apperrors.ErrConflict, "guest already exists")
+
+	// ErrDuplicateCode is returned when a generated RSVP code is already taken;
+	// callers generate a new code and try again
+	ErrDuplicateCode = apperrors.New(apperrors.ErrConflict, "RSVP code already in use")
+
+	// ErrWebhookNotFound is returned when no webhook matches the lookup
+	ErrWebhookNotFound = apperrors.New(apperrors.ErrNotFound, "webhook not found")
+
+	// ErrSubEventNotFound is returned when no sub-event matches the lookup
+	ErrSubEventNotFound = apperrors.New(apperrors.ErrNotFound, "sub-event not found")
+
+	// ErrNotInvited is returned when a guest answers for a sub-event they are not invited to
+	ErrNotInvited = apperrors.New(apperrors.ErrForbidden, "guest is not invited to this sub-event")
+
+	// ErrTagNotFound is returned when no tag matches the lookup
+	ErrTagNotFound = apperrors.New(apperrors.ErrNotFound, "tag not found")
+
+	// ErrDuplicateTag is returned when a tag name is already taken, ignoring case
+	ErrDuplicateTag = apperrors.New(apperrors.ErrConflict, "a tag with this name already exists")
+
+	// ErrHouseholdNotFound is returned when no household matches the lookup
+	ErrHouseholdNotFound = apperrors.New(apperrors.ErrNotFound, "household not found")
+)
+
+// SQLSTATEs for constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// rsvpCodeIndex is the unique index guarding RSVP codes
+const rsvpCodeIndex = "guests_rsvp_code_key"
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// isForeignKeyViolation reports whether err references a row that does not exist
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
+
+// uniqueViolationError maps a unique violation to the matching sentinel
+func uniqueViolationError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && (pqErr.Constraint == rsvpCodeIndex || strings.Contains(pqErr.Message, rsvpCodeIndex)) {
+		return ErrDuplicateCode
+	}
+	return ErrDuplicateGuest
+}
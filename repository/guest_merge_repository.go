@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+)
+
+// guestMergeColumns lists the columns read for every merge query, in scan order
+const guestMergeColumns = "id, primary_id, duplicate_id, primary_guest, duplicate_guest, merged_at, request_id"
+
+// scanGuestMerge reads a merge selected with guestMergeColumns
+func scanGuestMerge(row rowScanner, m *models.GuestMerge) error {
+	var primary, duplicate []byte
+	if err := row.Scan(&m.ID, &m.PrimaryID, &m.DuplicateID, &primary, &duplicate, &m.MergedAt, &m.RequestID); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(primary, &m.Primary); err != nil {
+		return err
+	}
+	return json.Unmarshal(duplicate, &m.Duplicate)
+}
+
+// MergeGuests folds merge.Duplicate into the guest it was found to duplicate
+// in one transaction: the kept guest is saved as merged, honouring
+// merged.Version; the duplicate's tags, sub-event invitations and check-ins
+// move to it; the duplicate is deleted, honouring merge.Duplicate.Version;
+// and the merge is recorded. An answered invitation replaces a pending one
+// for the same sub-event.
+func (r *GuestRepository) MergeGuests(ctx context.Context, merged *models.Guest, merge *models.GuestMerge) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	primary, err := json.Marshal(merge.Primary)
+	if err != nil {
+		return err
+	}
+	duplicate, err := json.Marshal(merge.Duplicate)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	// Lock the duplicate so nothing is added to it while it is folded in
+	var version int
+	err = tx.QueryRowContext(ctx, "SELECT version FROM guests WHERE id = $1 FOR UPDATE", merge.DuplicateID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return ErrGuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if merge.Duplicate.Version != 0 && version != merge.Duplicate.Version {
+		return ErrVersionConflict
+	}
+
+	query := `
+		UPDATE guests
+		SET family_side = $1, hongbao = $2, total_guests = $3, rsvp_status = $4, preferred_language = $5,
+			phone = $6, household_id = $7, version = version + 1
+		WHERE id = $8 AND ($9 = 0 OR version = $9)
+		RETURNING version;
+	`
+	err = tx.QueryRowContext(ctx, query, merged.FamilySide, merged.Hongbao, merged.TotalGuests, merged.RSVPStatus,
+		merged.PreferredLanguage, merged.Phone, merged.HouseholdID, merged.ID, merged.Version).Scan(&merged.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return r.missOrConflict(ctx, merged.ID)
+		}
+		return fmt.Errorf("failed to update guest: %w", err)
+	}
+
+	statements := []string{
+		`INSERT INTO guest_tags (guest_id, tag_id)
+			SELECT $1, tag_id FROM guest_tags WHERE guest_id = $2
+			ON CONFLICT DO NOTHING`,
+		`UPDATE sub_event_invitations kept
+			SET rsvp_status = dup.rsvp_status, attendees = dup.attendees, responded_at = dup.responded_at
+			FROM sub_event_invitations dup
+			WHERE kept.guest_id = $1 AND dup.guest_id = $2 AND kept.sub_event_id = dup.sub_event_id
+				AND kept.rsvp_status = 'Pending' AND dup.rsvp_status <> 'Pending'`,
+		`INSERT INTO sub_event_invitations (sub_event_id, guest_id, rsvp_status, attendees, responded_at)
+			SELECT sub_event_id, $1, rsvp_status, attendees, responded_at FROM sub_event_invitations WHERE guest_id = $2
+			ON CONFLICT DO NOTHING`,
+		"UPDATE checkins SET guest_id = $1 WHERE guest_id = $2",
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, merge.PrimaryID, merge.DuplicateID); err != nil {
+			return err
+		}
+	}
+
+	// Whatever is left of the duplicate goes with it, as with any deleted guest
+	if _, err := tx.ExecContext(ctx, "DELETE FROM guests WHERE id = $1", merge.DuplicateID); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO guest_merges (` + guestMergeColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = tx.ExecContext(ctx, query, merge.ID, merge.PrimaryID, merge.DuplicateID, primary, duplicate, merge.MergedAt, merge.RequestID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetGuestMerges retrieves every recorded merge, newest first
+func (r *GuestRepository) GetGuestMerges(ctx context.Context) ([]models.GuestMerge, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+guestMergeColumns+" FROM guest_merges ORDER BY merged_at DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merges []models.GuestMerge
+	for rows.Next() {
+		var m models.GuestMerge
+		if err := scanGuestMerge(rows, &m); err != nil {
+			return nil, err
+		}
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+)
+
+// GuestMergeStore folds duplicate guests into the ones kept and records each merge
+type GuestMergeStore interface {
+	MergeGuests(ctx context.Context, merged *models.Guest, merge *models.GuestMerge) error
+	GetGuestMerges(ctx context.Context) ([]models.GuestMerge, error)
+}
+
+// Compile-time checks that both implementations satisfy GuestMergeStore
+var (
+	_ GuestMergeStore = (*GuestRepository)(nil)
+	_ GuestMergeStore = (*MemoryGuestRepository)(nil)
+)
@@ -1,70 +1,127 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/g4l1l10/rsvp-backend/models"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// guestColumns lists the columns read for every guest query, in scan order
+// Revoked credentials are stored as NULL and read back as empty strings.
+// The guest's tag names are gathered by a subquery, in alphabetical order.
+const guestColumns = "id, name, email, family_side, hongbao, total_guests, rsvp_status, COALESCE(legacy_rsvp_token_hash, ''), COALESCE(rsvp_token_hash, ''), COALESCE(rsvp_code, ''), version, preferred_language, phone, household_id, " +
+	"ARRAY(SELECT t.name FROM guest_tags gt JOIN tags t ON t.id = gt.tag_id WHERE gt.guest_id = guests.id ORDER BY lower(t.name))"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// scanGuest reads a guest selected with guestColumns
+func scanGuest(row rowScanner, guest *models.Guest) error {
+	return row.Scan(&guest.ID, &guest.Name, &guest.Email, &guest.FamilySide, &guest.Hongbao, &guest.TotalGuests, &guest.RSVPStatus, &guest.LegacyRSVPTokenHash, &guest.RSVPTokenHash, &guest.RSVPCode, &guest.Version, &guest.PreferredLanguage, &guest.Phone, &guest.HouseholdID, pq.Array(&guest.Tags))
+}
+
+// DefaultQueryTimeout bounds each repository call unless overridden
+const DefaultQueryTimeout = 5 * time.Second
+
 // GuestRepository handles database operations for guests
 type GuestRepository struct {
 	DB *sql.DB
+
+	// QueryTimeout caps how long a single repository call may run,
+	// on top of any deadline already carried by the caller's context
+	QueryTimeout time.Duration
 }
 
 // NewGuestRepository initializes a new repository instance
 func NewGuestRepository(db *sql.DB) *GuestRepository {
-	return &GuestRepository{DB: db}
+	return &GuestRepository{DB: db, QueryTimeout: DefaultQueryTimeout}
+}
+
+// withTimeout derives the context used for one repository call
+func (r *GuestRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.QueryTimeout)
 }
 
 // CreateGuest inserts a new guest into the database securely
-func (r *GuestRepository) CreateGuest(guest *models.Guest) error {
+func (r *GuestRepository) CreateGuest(ctx context.Context, guest *models.Guest) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		INSERT INTO guests (id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id;
+		INSERT INTO guests (id, name, email, family_side, hongbao, total_guests, rsvp_status, legacy_rsvp_token_hash, rsvp_token_hash, rsvp_code, preferred_language, phone, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12, 1)
+		RETURNING id, version;
 	`
-	err := r.DB.QueryRow(query, guest.ID, guest.Name, guest.Email, guest.FamilySide, guest.Hongbao, guest.TotalGuests, guest.RSVPStatus, guest.RSVPToken).Scan(&guest.ID)
+	err := r.DB.QueryRowContext(ctx, query, guest.ID, guest.Name, guest.Email, guest.FamilySide, guest.Hongbao, guest.TotalGuests, guest.RSVPStatus, guest.LegacyRSVPTokenHash, guest.RSVPTokenHash, guest.RSVPCode, guest.PreferredLanguage, guest.Phone).Scan(&guest.ID, &guest.Version)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return uniqueViolationError(err)
+		}
 		return err
 	}
 	return nil
 }
 
 // GetAllGuests retrieves all guests from the database
-func (r *GuestRepository) GetAllGuests() ([]models.Guest, error) {
-	query := "SELECT id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests"
-	rows, err := r.DB.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *GuestRepository) GetAllGuests(ctx context.Context) ([]models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
-	var guests []models.Guest
-	for rows.Next() {
-		var g models.Guest
-		if err := rows.Scan(&g.ID, &g.Name, &g.Email, &g.FamilySide, &g.Hongbao, &g.TotalGuests, &g.RSVPStatus, &g.RSVPToken); err != nil {
-			return nil, err
+	query := "SELECT " + guestColumns + " FROM guests"
+	return r.queryGuests(ctx, query)
+}
+
+// GetGuestByID fetches a single guest securely using a UUID
+func (r *GuestRepository) GetGuestByID(ctx context.Context, id uuid.UUID) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + guestColumns + " FROM guests WHERE id = $1"
+	row := r.DB.QueryRowContext(ctx, query, id)
+
+	var guest models.Guest
+	err := scanGuest(row, &guest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrGuestNotFound
 		}
-		guests = append(guests, g)
+		return nil, err
 	}
 
-	return guests, nil
+	return &guest, nil
 }
 
-// GetGuestByID fetches a single guest securely using a UUID
-func (r *GuestRepository) GetGuestByID(id uuid.UUID) (*models.Guest, error) {
-	query := "SELECT id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests WHERE id = $1"
-	row := r.DB.QueryRow(query, id)
+// GetGuestByLegacyTokenHash fetches a guest using the hash of a plaintext
+// RSVP token issued before links were signed
+func (r *GuestRepository) GetGuestByLegacyTokenHash(ctx context.Context, tokenHash string) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + guestColumns + " FROM guests WHERE legacy_rsvp_token_hash = $1"
+	row := r.DB.QueryRowContext(ctx, query, tokenHash)
 
 	var guest models.Guest
-	err := row.Scan(&guest.ID, &guest.Name, &guest.Email, &guest.FamilySide, &guest.Hongbao, &guest.TotalGuests, &guest.RSVPStatus, &guest.RSVPToken)
+	err := scanGuest(row, &guest)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("guest not found")
+			return nil, ErrInvalidToken
 		}
 		return nil, err
 	}
@@ -72,16 +129,19 @@ func (r *GuestRepository) GetGuestByID(id uuid.UUID) (*models.Guest, error) {
 	return &guest, nil
 }
 
-// GetGuestByToken fetches a guest using their unique RSVP token
-func (r *GuestRepository) GetGuestByToken(token string) (*models.Guest, error) {
-	query := "SELECT id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests WHERE rsvp_token = $1"
-	row := r.DB.QueryRow(query, token)
+// GetGuestByCode fetches a guest using their short RSVP code
+func (r *GuestRepository) GetGuestByCode(ctx context.Context, code string) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + guestColumns + " FROM guests WHERE rsvp_code = $1"
+	row := r.DB.QueryRowContext(ctx, query, code)
 
 	var guest models.Guest
-	err := row.Scan(&guest.ID, &guest.Name, &guest.Email, &guest.FamilySide, &guest.Hongbao, &guest.TotalGuests, &guest.RSVPStatus, &guest.RSVPToken)
+	err := scanGuest(row, &guest)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("invalid RSVP token")
+			return nil, ErrInvalidToken
 		}
 		return nil, err
 	}
@@ -90,15 +150,18 @@ func (r *GuestRepository) GetGuestByToken(token string) (*models.Guest, error) {
 }
 
 // GetGuestByEmail fetches a guest using their email
-func (r *GuestRepository) GetGuestByEmail(email string) (*models.Guest, error) {
-	query := "SELECT id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests WHERE email = $1"
-	row := r.DB.QueryRow(query, email)
+func (r *GuestRepository) GetGuestByEmail(ctx context.Context, email string) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + guestColumns + " FROM guests WHERE email = $1"
+	row := r.DB.QueryRowContext(ctx, query, email)
 
 	var guest models.Guest
-	err := row.Scan(&guest.ID, &guest.Name, &guest.Email, &guest.FamilySide, &guest.Hongbao, &guest.TotalGuests, &guest.RSVPStatus, &guest.RSVPToken)
+	err := scanGuest(row, &guest)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("guest not found with provided email")
+			return nil, ErrGuestNotFound
 		}
 		return nil, err
 	}
@@ -107,9 +170,41 @@ func (r *GuestRepository) GetGuestByEmail(email string) (*models.Guest, error) {
 }
 
 // GetGuestByRSVP retrieves guests based on their RSVP status (e.g., "Attending", "Not Attending", "Pending")
-func (r *GuestRepository) GetGuestByRSVP(status string) ([]models.Guest, error) {
-	query := "SELECT id, name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests WHERE rsvp_status = $1"
-	rows, err := r.DB.Query(query, status)
+func (r *GuestRepository) GetGuestByRSVP(ctx context.Context, status string) ([]models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + guestColumns + " FROM guests WHERE rsvp_status = $1"
+	return r.queryGuests(ctx, query, status)
+}
+
+// GetRSVPSummary counts guests and expected attendees per RSVP status
+func (r *GuestRepository) GetRSVPSummary(ctx context.Context) ([]models.RSVPSummary, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT rsvp_status, COUNT(*), COALESCE(SUM(total_guests), 0) FROM guests GROUP BY rsvp_status"
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []models.RSVPSummary
+	for rows.Next() {
+		var s models.RSVPSummary
+		if err := rows.Scan(&s.RSVPStatus, &s.Guests, &s.Attendees); err != nil {
+			return nil, err
+		}
+		summary = append(summary, s)
+	}
+
+	return summary, rows.Err()
+}
+
+// queryGuests runs a query selecting guestColumns and collects the results
+func (r *GuestRepository) queryGuests(ctx context.Context, query string, args ...interface{}) ([]models.Guest, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,67 +213,177 @@ func (r *GuestRepository) GetGuestByRSVP(status string) ([]models.Guest, error)
 	var guests []models.Guest
 	for rows.Next() {
 		var g models.Guest
-		if err := rows.Scan(&g.ID, &g.Name, &g.Email, &g.FamilySide, &g.Hongbao, &g.TotalGuests, &g.RSVPStatus, &g.RSVPToken); err != nil {
+		if err := scanGuest(rows, &g); err != nil {
 			return nil, err
 		}
 		guests = append(guests, g)
 	}
 
-	return guests, nil
+	return guests, rows.Err()
 }
 
-// UpdateGuest updates a guest's information securely
-func (r *GuestRepository) UpdateGuest(guest *models.Guest) error {
-	// Fetch the existing guest details
-	var existingGuest models.Guest
-	query := "SELECT name, email, family_side, hongbao, total_guests, rsvp_status, rsvp_token FROM guests WHERE id = $1"
-	err := r.DB.QueryRow(query, guest.ID).Scan(
-		&existingGuest.Name,
-		&existingGuest.Email,
-		&existingGuest.FamilySide,
-		&existingGuest.Hongbao,
-		&existingGuest.TotalGuests,
-		&existingGuest.RSVPStatus,
-		&existingGuest.RSVPToken,
-	)
+// UpdateGuest replaces every editable field of a guest with the given values.
+// When guest.Version is non-zero the write only succeeds while the stored version
+// still equals it; on success guest.Version is advanced to the new version.
+// The RSVP token is server-managed and is never overwritten here.
+func (r *GuestRepository) UpdateGuest(ctx context.Context, guest *models.Guest) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE guests
+		SET name = $1, email = $2, family_side = $3, hongbao = $4, total_guests = $5, rsvp_status = $6, preferred_language = $9, phone = $10, version = version + 1
+		WHERE id = $7 AND ($8 = 0 OR version = $8)
+		RETURNING version;
+	`
+	err := r.DB.QueryRowContext(ctx, query, guest.Name, guest.Email, guest.FamilySide, guest.Hongbao, guest.TotalGuests, guest.RSVPStatus, guest.ID, guest.Version, guest.PreferredLanguage, guest.Phone).Scan(&guest.Version)
 	if err != nil {
-		return fmt.Errorf("failed to fetch existing guest: %v", err)
+		if err == sql.ErrNoRows {
+			return r.missOrConflict(ctx, guest.ID)
+		}
+		return fmt.Errorf("failed to update guest: %w", err)
 	}
 
-	// Preserve existing values if fields are not provided in the request
-	if guest.Name == "" {
-		guest.Name = existingGuest.Name
+	return nil
+}
+
+// PatchGuest updates only the fields present in the patch and returns the updated guest.
+// When expectedVersion is non-zero the write only succeeds if it matches the stored version.
+func (r *GuestRepository) PatchGuest(ctx context.Context, id uuid.UUID, patch *models.GuestPatch, expectedVersion int) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// Build the SET clause from the fields present in the patch
+	sets := []string{"version = version + 1"}
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if patch.Name != nil {
+		set("name", *patch.Name)
+	}
+	if patch.Email != nil {
+		set("email", *patch.Email)
 	}
-	if guest.Email == "" {
-		guest.Email = existingGuest.Email
+	if patch.FamilySide != nil {
+		set("family_side", *patch.FamilySide)
 	}
-	if guest.FamilySide == "" {
-		guest.FamilySide = existingGuest.FamilySide
+	if patch.Hongbao != nil {
+		set("hongbao", *patch.Hongbao)
 	}
-	if guest.RSVPStatus == "" {
-		guest.RSVPStatus = existingGuest.RSVPStatus
+	if patch.TotalGuests != nil {
+		set("total_guests", *patch.TotalGuests)
 	}
-	if guest.RSVPToken == "" {
-		guest.RSVPToken = existingGuest.RSVPToken
+	if patch.RSVPStatus != nil {
+		set("rsvp_status", *patch.RSVPStatus)
+	}
+	if patch.PreferredLanguage != nil {
+		set("preferred_language", *patch.PreferredLanguage)
+	}
+	if patch.Phone != nil {
+		set("phone", *patch.Phone)
 	}
 
-	// Update query
-	updateQuery := `
+	args = append(args, id)
+	where := fmt.Sprintf("id = $%d", len(args))
+	if expectedVersion != 0 {
+		args = append(args, expectedVersion)
+		where += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
 		UPDATE guests
-		SET name = $1, email = $2, family_side = $3, hongbao = $4, total_guests = $5, rsvp_status = $6, rsvp_token = $7
-		WHERE id = $8;
-	`
-	_, err = r.DB.Exec(updateQuery, guest.Name, guest.Email, guest.FamilySide, guest.Hongbao, guest.TotalGuests, guest.RSVPStatus, guest.RSVPToken, guest.ID)
+		SET %s
+		WHERE %s
+		RETURNING %s;
+	`, strings.Join(sets, ", "), where, guestColumns)
+
+	var guest models.Guest
+	err := scanGuest(r.DB.QueryRowContext(ctx, query, args...), &guest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to patch guest: %w", err)
+	}
+
+	return &guest, nil
+}
+
+// SetRSVPToken stores the hash identifying a guest's current RSVP link; an
+// empty hash revokes it. Any legacy token hash is always discarded.
+// When expectedVersion is non-zero the write only succeeds if it matches the stored version.
+func (r *GuestRepository) SetRSVPToken(ctx context.Context, id uuid.UUID, tokenHash string, expectedVersion int) (*models.Guest, error) {
+	return r.setCredential(ctx, "rsvp_token_hash = NULLIF($1, ''), legacy_rsvp_token_hash = NULL", id, tokenHash, expectedVersion)
+}
+
+// SetRSVPCode replaces a guest's RSVP code; an empty code revokes it.
+// When expectedVersion is non-zero the write only succeeds if it matches the stored version.
+func (r *GuestRepository) SetRSVPCode(ctx context.Context, id uuid.UUID, code string, expectedVersion int) (*models.Guest, error) {
+	return r.setCredential(ctx, "rsvp_code = NULLIF($1, '')", id, code, expectedVersion)
+}
+
+// setCredential applies an assignment of $1 to the RSVP credential columns
+func (r *GuestRepository) setCredential(ctx context.Context, assignment string, id uuid.UUID, value string, expectedVersion int) (*models.Guest, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		UPDATE guests
+		SET %s, version = version + 1
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+		RETURNING %s;
+	`, assignment, guestColumns)
+
+	var guest models.Guest
+	err := scanGuest(r.DB.QueryRowContext(ctx, query, value, id, expectedVersion), &guest)
 	if err != nil {
-		return fmt.Errorf("failed to update guest: %v", err)
+		if err == sql.ErrNoRows {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		if isUniqueViolation(err) {
+			return nil, uniqueViolationError(err)
+		}
+		return nil, fmt.Errorf("failed to update RSVP credentials: %w", err)
+	}
+
+	return &guest, nil
+}
+
+// DeleteGuest removes a guest securely from the database.
+// When expectedVersion is non-zero the delete only succeeds if it matches the stored version.
+func (r *GuestRepository) DeleteGuest(ctx context.Context, id uuid.UUID, expectedVersion int) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM guests WHERE id = $1 AND ($2 = 0 OR version = $2)"
+	result, err := r.DB.ExecContext(ctx, query, id, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return r.missOrConflict(ctx, id)
 	}
 
 	return nil
 }
 
-// DeleteGuest removes a guest securely from the database
-func (r *GuestRepository) DeleteGuest(id uuid.UUID) error {
-	query := "DELETE FROM guests WHERE id = $1"
-	_, err := r.DB.Exec(query, id)
-	return err
+// missOrConflict explains why a conditional write matched no rows:
+// either the guest does not exist or its version has moved on.
+func (r *GuestRepository) missOrConflict(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM guests WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrGuestNotFound
+	}
+	return ErrVersionConflict
 }
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// GuestStore is the persistence contract the service layer depends on.
+// GuestRepository implements it on top of Postgres/CockroachDB and
+// MemoryGuestRepository keeps everything in process for tests and local runs.
+// Every method honours cancellation and deadlines carried by ctx.
+type GuestStore interface {
+	CreateGuest(ctx context.Context, guest *models.Guest) error
+	GetAllGuests(ctx context.Context) ([]models.Guest, error)
+	GetGuestByID(ctx context.Context, id uuid.UUID) (*models.Guest, error)
+	GetGuestByLegacyTokenHash(ctx context.Context, tokenHash string) (*models.Guest, error)
+	GetGuestByCode(ctx context.Context, code string) (*models.Guest, error)
+	GetGuestByEmail(ctx context.Context, email string) (*models.Guest, error)
+	GetGuestByRSVP(ctx context.Context, status string) ([]models.Guest, error)
+	UpdateGuest(ctx context.Context, guest *models.Guest) error
+	PatchGuest(ctx context.Context, id uuid.UUID, patch *models.GuestPatch, expectedVersion int) (*models.Guest, error)
+	SetRSVPToken(ctx context.Context, id uuid.UUID, tokenHash string, expectedVersion int) (*models.Guest, error)
+	SetRSVPCode(ctx context.Context, id uuid.UUID, code string, expectedVersion int) (*models.Guest, error)
+	DeleteGuest(ctx context.Context, id uuid.UUID, expectedVersion int) error
+	GetRSVPSummary(ctx context.Context) ([]models.RSVPSummary, error)
+}
+
+// Compile-time checks that both implementations satisfy GuestStore
+var (
+	_ GuestStore = (*GuestRepository)(nil)
+	_ GuestStore = (*MemoryGuestRepository)(nil)
+)
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// householdColumns lists the columns read for every household query, in
+// scan order. The guest count is gathered by a subquery.
+const householdColumns = "id, addressee, address_line1, address_line2, city, region, postal_code, country, created_at, " +
+	"(SELECT count(*) FROM guests g WHERE g.household_id = households.id)"
+
+// scanHousehold reads a household selected with householdColumns
+func scanHousehold(row rowScanner, h *models.Household) error {
+	return row.Scan(&h.ID, &h.Addressee, &h.Line1, &h.Line2, &h.City, &h.Region, &h.PostalCode, &h.Country, &h.CreatedAt, &h.Guests)
+}
+
+// CreateHousehold stores a household
+func (r *GuestRepository) CreateHousehold(ctx context.Context, h *models.Household) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO households (id, addressee, address_line1, address_line2, city, region, postal_code, country, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.DB.ExecContext(ctx, query, h.ID, h.Addressee, h.Line1, h.Line2, h.City, h.Region, h.PostalCode, h.Country, h.CreatedAt)
+	return err
+}
+
+// GetHouseholds retrieves every household ordered by addressee, with guest counts
+func (r *GuestRepository) GetHouseholds(ctx context.Context) ([]models.Household, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+householdColumns+" FROM households ORDER BY lower(addressee), id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var households []models.Household
+	for rows.Next() {
+		var h models.Household
+		if err := scanHousehold(rows, &h); err != nil {
+			return nil, err
+		}
+		households = append(households, h)
+	}
+	return households, rows.Err()
+}
+
+// GetHousehold retrieves a household by ID, with its guest count
+func (r *GuestRepository) GetHousehold(ctx context.Context, id uuid.UUID) (*models.Household, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var h models.Household
+	err := scanHousehold(r.DB.QueryRowContext(ctx, "SELECT "+householdColumns+" FROM households WHERE id = $1", id), &h)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHouseholdNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// UpdateHousehold replaces a household's addressee and address
+func (r *GuestRepository) UpdateHousehold(ctx context.Context, h *models.Household) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE households SET addressee = $2, address_line1 = $3, address_line2 = $4, city = $5, region = $6, postal_code = $7, country = $8
+		WHERE id = $1`
+	result, err := r.DB.ExecContext(ctx, query, h.ID, h.Addressee, h.Line1, h.Line2, h.City, h.Region, h.PostalCode, h.Country)
+	return affectedOrMissing(result, err, ErrHouseholdNotFound)
+}
+
+// DeleteHousehold removes a household; its guests are kept without one
+func (r *GuestRepository) DeleteHousehold(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM households WHERE id = $1", id)
+	return affectedOrMissing(result, err, ErrHouseholdNotFound)
+}
+
+// MoveToHousehold makes guests members of a household, taking them out of
+// any other, and returns how many were not members already. Nothing changes
+// if any guest does not exist. Each moved guest's version is advanced.
+func (r *GuestRepository) MoveToHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	var found int
+	ids := uuidArray(guestIDs)
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM guests WHERE id = ANY($1::uuid[])", ids).Scan(&found); err != nil {
+		return 0, err
+	}
+	if found < len(uniqueIDs(guestIDs)) {
+		return 0, ErrGuestNotFound
+	}
+
+	query := `UPDATE guests SET household_id = $1, version = version + 1
+		WHERE id = ANY($2::uuid[]) AND household_id IS DISTINCT FROM $1`
+	result, err := tx.ExecContext(ctx, query, householdID, ids)
+	if isForeignKeyViolation(err) {
+		return 0, ErrHouseholdNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(moved), tx.Commit()
+}
+
+// RemoveFromHousehold takes guests out of a household and returns how many were members
+func (r *GuestRepository) RemoveFromHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE guests SET household_id = NULL, version = version + 1 WHERE household_id = $1 AND id = ANY($2::uuid[])"
+	result, err := r.DB.ExecContext(ctx, query, householdID, uuidArray(guestIDs))
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// uniqueIDs returns the distinct IDs, in order of first appearance
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
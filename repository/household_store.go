@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// HouseholdStore persists households, their postal addresses and which guests belong to them
+type HouseholdStore interface {
+	CreateHousehold(ctx context.Context, household *models.Household) error
+	GetHouseholds(ctx context.Context) ([]models.Household, error)
+	GetHousehold(ctx context.Context, id uuid.UUID) (*models.Household, error)
+	UpdateHousehold(ctx context.Context, household *models.Household) error
+	DeleteHousehold(ctx context.Context, id uuid.UUID) error
+	MoveToHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error)
+	RemoveFromHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error)
+}
+
+// Compile-time checks that both implementations satisfy HouseholdStore
+var (
+	_ HouseholdStore = (*GuestRepository)(nil)
+	_ HouseholdStore = (*MemoryGuestRepository)(nil)
+)
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateCheckIn records an arrival and returns the guest's earlier check-ins,
+// oldest first, under one lock so concurrent scans of a party are counted one
+// after the other. A zero checkIn.Arrived is filled in with the rest of the
+// party, and at least one. It fails with ErrGuestNotFound if the guest does
+// not exist.
+func (r *MemoryGuestRepository) CreateCheckIn(ctx context.Context, checkIn *models.CheckIn) ([]models.CheckIn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	guest, ok := r.guests[checkIn.GuestID]
+	if !ok {
+		return nil, ErrGuestNotFound
+	}
+	var previous []models.CheckIn
+	for _, c := range r.checkIns {
+		if c.GuestID == checkIn.GuestID {
+			previous = append(previous, c)
+		}
+	}
+	if checkIn.Arrived == 0 {
+		checkIn.Arrived = restOfParty(guest.TotalGuests, previous)
+	}
+	r.checkIns = append(r.checkIns, *checkIn)
+	return previous, nil
+}
+
+// GetCheckIns returns copies of a guest's check-ins, oldest first
+func (r *MemoryGuestRepository) GetCheckIns(ctx context.Context, guestID uuid.UUID) ([]models.CheckIn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var checkIns []models.CheckIn
+	for _, c := range r.checkIns {
+		if c.GuestID == guestID {
+			checkIns = append(checkIns, c)
+		}
+	}
+	return checkIns, nil
+}
+
+// GetAllCheckIns returns copies of every check-in, oldest first
+func (r *MemoryGuestRepository) GetAllCheckIns(ctx context.Context) ([]models.CheckIn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.CheckIn(nil), r.checkIns...), nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// MergeGuests folds merge.Duplicate into the guest it was found to duplicate
+// all at once: the kept guest is saved as merged, honouring merged.Version;
+// the duplicate's tags, sub-event invitations and check-ins move to it; the
+// duplicate is deleted, honouring merge.Duplicate.Version; and the merge is
+// recorded. An answered invitation replaces a pending one for the same sub-event.
+func (r *MemoryGuestRepository) MergeGuests(ctx context.Context, merged *models.Guest, merge *models.GuestMerge) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lockedForWrite(merge.DuplicateID, merge.Duplicate.Version); err != nil {
+		return err
+	}
+	stored, err := r.lockedForWrite(merged.ID, merged.Version)
+	if err != nil {
+		return err
+	}
+
+	stored.FamilySide = merged.FamilySide
+	stored.Hongbao = merged.Hongbao
+	stored.TotalGuests = merged.TotalGuests
+	stored.RSVPStatus = merged.RSVPStatus
+	stored.PreferredLanguage = merged.PreferredLanguage
+	stored.Phone = merged.Phone
+	stored.HouseholdID = nil
+	if merged.HouseholdID != nil {
+		household := *merged.HouseholdID
+		stored.HouseholdID = &household
+	}
+	stored.Version++
+	merged.Version = stored.Version
+
+	for _, gt := range r.guestTags {
+		if gt.GuestID == merge.DuplicateID && !slices.Contains(r.guestTags, guestTag{GuestID: merge.PrimaryID, TagID: gt.TagID}) {
+			r.guestTags = append(r.guestTags, guestTag{GuestID: merge.PrimaryID, TagID: gt.TagID})
+		}
+	}
+	for _, inv := range r.invitations {
+		if inv.GuestID != merge.DuplicateID {
+			continue
+		}
+		moved := copyInvitation(&inv)
+		moved.GuestID = merge.PrimaryID
+		i := r.lockedInvitationIndex(inv.SubEventID, merge.PrimaryID)
+		switch {
+		case i < 0:
+			r.invitations = append(r.invitations, moved)
+		case r.invitations[i].RSVPStatus == models.RSVPStatusPending && inv.RSVPStatus != models.RSVPStatusPending:
+			r.invitations[i] = moved
+		}
+	}
+	for i := range r.checkIns {
+		if r.checkIns[i].GuestID == merge.DuplicateID {
+			r.checkIns[i].GuestID = merge.PrimaryID
+		}
+	}
+
+	// Whatever is left of the duplicate goes with it, as with any deleted guest
+	delete(r.guests, merge.DuplicateID)
+	r.order = slices.DeleteFunc(r.order, func(id uuid.UUID) bool { return id == merge.DuplicateID })
+	r.invitations = slices.DeleteFunc(r.invitations, func(inv models.Invitation) bool { return inv.GuestID == merge.DuplicateID })
+	r.guestTags = slices.DeleteFunc(r.guestTags, func(gt guestTag) bool { return gt.GuestID == merge.DuplicateID })
+
+	r.merges = append(r.merges, copyGuestMerge(merge))
+	return nil
+}
+
+// GetGuestMerges returns copies of every recorded merge, newest first
+func (r *MemoryGuestRepository) GetGuestMerges(ctx context.Context) ([]models.GuestMerge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	merges := make([]models.GuestMerge, 0, len(r.merges))
+	for i := len(r.merges) - 1; i >= 0; i-- {
+		merges = append(merges, copyGuestMerge(&r.merges[i]))
+	}
+	return merges, nil
+}
+
+// copyGuestMerge returns a merge that shares no memory with m
+func copyGuestMerge(m *models.GuestMerge) models.GuestMerge {
+	c := *m
+	c.Primary = copyGuest(&m.Primary)
+	c.Duplicate = copyGuest(&m.Duplicate)
+	return c
+}
+
+// copyGuest returns a guest that shares no memory with g
+func copyGuest(g *models.Guest) models.Guest {
+	c := *g
+	c.Tags = slices.Clone(g.Tags)
+	if g.HouseholdID != nil {
+		household := *g.HouseholdID
+		c.HouseholdID = &household
+	}
+	return c
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryGuestRepository is a thread-safe, in-process GuestStore.
+// It mirrors the behaviour of GuestRepository, including version checks,
+// RSVP token uniqueness and context cancellation, without needing a database.
+type MemoryGuestRepository struct {
+	mu     sync.RWMutex
+	guests map[uuid.UUID]*models.Guest
+	order  []uuid.UUID // insertion order, so listings are deterministic
+
+	checkIns []models.CheckIn // in the order they were recorded
+
+	webhooks   []models.Webhook         // in the order they were registered
+	deliveries []models.WebhookDelivery // in the order they were created
+
+	subEvents   []models.SubEvent   // in the order they were created
+	invitations []models.Invitation // in the order they were made
+
+	tags      []models.Tag // in the order they were created
+	guestTags []guestTag   // in the order they were assigned
+
+	households []models.Household // in the order they were created
+
+	merges []models.GuestMerge // in the order they were made
+}
+
+// NewMemoryGuestRepository initializes an empty in-memory repository
+func NewMemoryGuestRepository() *MemoryGuestRepository {
+	return &MemoryGuestRepository{guests: make(map[uuid.UUID]*models.Guest)}
+}
+
+// CreateGuest stores a copy of the guest
+func (r *MemoryGuestRepository) CreateGuest(ctx context.Context, guest *models.Guest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.guests[guest.ID]; exists {
+		return ErrDuplicateGuest
+	}
+	if err := r.lockedCheckCredentials(uuid.Nil, guest.LegacyRSVPTokenHash, guest.RSVPCode); err != nil {
+		return err
+	}
+
+	guest.Version = 1
+	stored := *guest
+	stored.RSVPToken = ""    // issued tokens are never stored
+	stored.Tags = nil        // tags are assigned with TagGuests
+	stored.HouseholdID = nil // and households with MoveToHousehold
+	r.guests[guest.ID] = &stored
+	r.order = append(r.order, guest.ID)
+	return nil
+}
+
+// GetAllGuests returns copies of every guest in insertion order
+func (r *MemoryGuestRepository) GetAllGuests(ctx context.Context) ([]models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.filter(func(*models.Guest) bool { return true }), nil
+}
+
+// GetGuestByID returns a copy of the guest with the given ID
+func (r *MemoryGuestRepository) GetGuestByID(ctx context.Context, id uuid.UUID) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	g, ok := r.guests[id]
+	if !ok {
+		return nil, ErrGuestNotFound
+	}
+	guest := r.lockedGuestCopy(g)
+	return &guest, nil
+}
+
+// GetGuestByLegacyTokenHash returns a copy of the guest holding the legacy RSVP token with this hash
+func (r *MemoryGuestRepository) GetGuestByLegacyTokenHash(ctx context.Context, tokenHash string) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	guests := r.filter(func(g *models.Guest) bool { return tokenHash != "" && g.LegacyRSVPTokenHash == tokenHash })
+	if len(guests) == 0 {
+		return nil, ErrInvalidToken
+	}
+	return &guests[0], nil
+}
+
+// GetGuestByCode returns a copy of the guest holding the RSVP code
+func (r *MemoryGuestRepository) GetGuestByCode(ctx context.Context, code string) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	guests := r.filter(func(g *models.Guest) bool { return code != "" && g.RSVPCode == code })
+	if len(guests) == 0 {
+		return nil, ErrInvalidToken
+	}
+	return &guests[0], nil
+}
+
+// GetGuestByEmail returns a copy of the first guest with the given email
+func (r *MemoryGuestRepository) GetGuestByEmail(ctx context.Context, email string) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	guests := r.filter(func(g *models.Guest) bool { return g.Email == email })
+	if len(guests) == 0 {
+		return nil, ErrGuestNotFound
+	}
+	return &guests[0], nil
+}
+
+// GetGuestByRSVP returns copies of all guests with the given RSVP status
+func (r *MemoryGuestRepository) GetGuestByRSVP(ctx context.Context, status string) ([]models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.filter(func(g *models.Guest) bool { return g.RSVPStatus == status }), nil
+}
+
+// UpdateGuest replaces the editable fields of a stored guest, honouring guest.Version
+func (r *MemoryGuestRepository) UpdateGuest(ctx context.Context, guest *models.Guest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.lockedForWrite(guest.ID, guest.Version)
+	if err != nil {
+		return err
+	}
+
+	stored.Name = guest.Name
+	stored.Email = guest.Email
+	stored.FamilySide = guest.FamilySide
+	stored.Hongbao = guest.Hongbao
+	stored.TotalGuests = guest.TotalGuests
+	stored.RSVPStatus = guest.RSVPStatus
+	stored.PreferredLanguage = guest.PreferredLanguage
+	stored.Phone = guest.Phone
+	stored.Version++
+	guest.Version = stored.Version
+	return nil
+}
+
+// PatchGuest applies the fields present in the patch, honouring expectedVersion
+func (r *MemoryGuestRepository) PatchGuest(ctx context.Context, id uuid.UUID, patch *models.GuestPatch, expectedVersion int) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.lockedForWrite(id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	patch.Apply(stored)
+	stored.Version++
+	guest := r.lockedGuestCopy(stored)
+	return &guest, nil
+}
+
+// SetRSVPToken replaces or, when empty, revokes the hash of a guest's RSVP link
+// and discards any legacy token, honouring expectedVersion
+func (r *MemoryGuestRepository) SetRSVPToken(ctx context.Context, id uuid.UUID, tokenHash string, expectedVersion int) (*models.Guest, error) {
+	return r.setCredential(ctx, id, expectedVersion, "", func(g *models.Guest) {
+		g.RSVPTokenHash = tokenHash
+		g.LegacyRSVPTokenHash = ""
+	})
+}
+
+// SetRSVPCode replaces or, when empty, revokes a guest's RSVP code, honouring expectedVersion
+func (r *MemoryGuestRepository) SetRSVPCode(ctx context.Context, id uuid.UUID, code string, expectedVersion int) (*models.Guest, error) {
+	return r.setCredential(ctx, id, expectedVersion, code, func(g *models.Guest) { g.RSVPCode = code })
+}
+
+// setCredential checks uniqueness of the new code, if any, and applies set
+func (r *MemoryGuestRepository) setCredential(ctx context.Context, id uuid.UUID, expectedVersion int, code string, set func(*models.Guest)) (*models.Guest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.lockedForWrite(id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.lockedCheckCredentials(id, "", code); err != nil {
+		return nil, err
+	}
+
+	set(stored)
+	stored.Version++
+	guest := r.lockedGuestCopy(stored)
+	return &guest, nil
+}
+
+// lockedCheckCredentials mirrors the unique indexes on legacy RSVP token hashes and codes,
+// ignoring the guest being updated and empty (revoked) values.
+// The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedCheckCredentials(self uuid.UUID, tokenHash, code string) error {
+	for id, g := range r.guests {
+		if id == self {
+			continue
+		}
+		if code != "" && g.RSVPCode == code {
+			return ErrDuplicateCode
+		}
+		if tokenHash != "" && g.LegacyRSVPTokenHash == tokenHash {
+			return ErrDuplicateGuest
+		}
+	}
+	return nil
+}
+
+// DeleteGuest removes a guest, honouring expectedVersion
+func (r *MemoryGuestRepository) DeleteGuest(ctx context.Context, id uuid.UUID, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lockedForWrite(id, expectedVersion); err != nil {
+		return err
+	}
+
+	delete(r.guests, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+
+	// Check-ins go with the guest, as with ON DELETE CASCADE
+	kept := r.checkIns[:0]
+	for _, c := range r.checkIns {
+		if c.GuestID != id {
+			kept = append(kept, c)
+		}
+	}
+	r.checkIns = kept
+	r.invitations = slices.DeleteFunc(r.invitations, func(inv models.Invitation) bool { return inv.GuestID == id })
+	r.guestTags = slices.DeleteFunc(r.guestTags, func(gt guestTag) bool { return gt.GuestID == id })
+	return nil
+}
+
+// GetRSVPSummary counts guests and expected attendees per RSVP status
+func (r *MemoryGuestRepository) GetRSVPSummary(ctx context.Context) ([]models.RSVPSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var summary []models.RSVPSummary
+	index := make(map[string]int)
+	for _, g := range r.filter(func(*models.Guest) bool { return true }) {
+		i, ok := index[g.RSVPStatus]
+		if !ok {
+			i = len(summary)
+			index[g.RSVPStatus] = i
+			summary = append(summary, models.RSVPSummary{RSVPStatus: g.RSVPStatus})
+		}
+		summary[i].Guests++
+		summary[i].Attendees += g.TotalGuests
+	}
+	return summary, nil
+}
+
+// lockedForWrite returns the stored guest if it exists and matches the
+// expected version (0 matches any). The caller must hold the write lock.
+func (r *MemoryGuestRepository) lockedForWrite(id uuid.UUID, expectedVersion int) (*models.Guest, error) {
+	stored, ok := r.guests[id]
+	if !ok {
+		return nil, ErrGuestNotFound
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	return stored, nil
+}
+
+// filter returns copies of the guests matching keep, in insertion order
+func (r *MemoryGuestRepository) filter(keep func(*models.Guest) bool) []models.Guest {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var guests []models.Guest
+	for _, id := range r.order {
+		if g := r.guests[id]; keep(g) {
+			guests = append(guests, r.lockedGuestCopy(g))
+		}
+	}
+	return guests
+}
+
+// lockedGuestCopy returns a copy of the stored guest that shares no memory
+// with it, carrying the names of its tags in alphabetical order, as
+// guestColumns does. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedGuestCopy(g *models.Guest) models.Guest {
+	c := *g
+	if g.HouseholdID != nil {
+		household := *g.HouseholdID
+		c.HouseholdID = &household
+	}
+	c.Tags = nil
+	for _, gt := range r.guestTags {
+		if gt.GuestID != g.ID {
+			continue
+		}
+		if i := r.lockedTagIndex(gt.TagID); i >= 0 {
+			c.Tags = append(c.Tags, r.tags[i].Name)
+		}
+	}
+	slices.SortFunc(c.Tags, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	return c
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// ctx is the background context shared by tests that do not exercise cancellation
+var ctx = context.Background()
+
+func TestMemoryGuestRepositoryConcurrentPatches(t *testing.T) {
+	repo := NewMemoryGuestRepository()
+	guest := models.NewGuest("Uncle Bob", "bob@example.com", "Groom", 1)
+	if err := repo.CreateGuest(ctx, guest); err != nil {
+		t.Fatalf("CreateGuest: %v", err)
+	}
+
+	// Every writer races with the same expected version; exactly one may win
+	const writers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wins, conflicts := 0, 0
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			total := n + 1
+			_, err := repo.PatchGuest(ctx, guest.ID, &models.GuestPatch{TotalGuests: &total}, guest.Version)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				wins++
+			case errors.Is(err, ErrVersionConflict):
+				conflicts++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if wins != 1 || conflicts != writers-1 {
+		t.Fatalf("wins = %d, conflicts = %d", wins, conflicts)
+	}
+	stored, _ := repo.GetGuestByID(ctx, guest.ID)
+	if stored.Version != 2 {
+		t.Errorf("version = %d, want 2", stored.Version)
+	}
+}
+
+func TestMemoryGuestRepositoryConcurrentCheckIns(t *testing.T) {
+	repo := NewMemoryGuestRepository()
+	guest := models.NewGuest("Aunt May", "may@example.com", "Bride", 2)
+	if err := repo.CreateGuest(ctx, guest); err != nil {
+		t.Fatalf("CreateGuest: %v", err)
+	}
+
+	// Every scan checks in the rest of the party; only the first finds anyone left
+	const scans = 10
+	var wg sync.WaitGroup
+	for i := 0; i < scans; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkIn := models.CheckIn{ID: uuid.New(), GuestID: guest.ID, CheckedInAt: time.Now()}
+			if _, err := repo.CreateCheckIn(ctx, &checkIn); err != nil {
+				t.Errorf("CreateCheckIn: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	checkIns, _ := repo.GetCheckIns(ctx, guest.ID)
+	arrived := 0
+	for _, c := range checkIns {
+		arrived += c.Arrived
+	}
+	if len(checkIns) != scans || arrived != guest.TotalGuests+scans-1 {
+		t.Errorf("%d check-ins for %d arrivals, want %d for %d", len(checkIns), arrived, scans, guest.TotalGuests+scans-1)
+	}
+}
+
+func TestMemoryGuestRepositoryReturnsCopies(t *testing.T) {
+	repo := NewMemoryGuestRepository()
+	guest := models.NewGuest("Cousin Li", "li@example.com", "Bride", 1)
+	if err := repo.CreateGuest(ctx, guest); err != nil {
+		t.Fatalf("CreateGuest: %v", err)
+	}
+
+	fetched, _ := repo.GetGuestByID(ctx, guest.ID)
+	fetched.Name = "changed"
+
+	again, _ := repo.GetGuestByID(ctx, guest.ID)
+	if again.Name != "Cousin Li" {
+		t.Errorf("stored guest was mutated through a returned value")
+	}
+
+	duplicate := models.NewGuest("Other", "other@example.com", "Bride", 1)
+	duplicate.RSVPCode = guest.RSVPCode
+	if err := repo.CreateGuest(ctx, duplicate); !errors.Is(err, ErrDuplicateCode) {
+		t.Errorf("duplicate RSVP code: err = %v, want ErrDuplicateCode", err)
+	}
+}
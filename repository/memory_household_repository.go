@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateHousehold stores a copy of the household
+func (r *MemoryGuestRepository) CreateHousehold(ctx context.Context, h *models.Household) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *h
+	stored.Guests = 0
+	r.households = append(r.households, stored)
+	return nil
+}
+
+// GetHouseholds returns copies of every household ordered by addressee, with guest counts
+func (r *MemoryGuestRepository) GetHouseholds(ctx context.Context) ([]models.Household, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	households := make([]models.Household, len(r.households))
+	for i := range r.households {
+		households[i] = r.lockedHouseholdCopy(&r.households[i])
+	}
+	slices.SortStableFunc(households, func(a, b models.Household) int {
+		return strings.Compare(strings.ToLower(a.Addressee), strings.ToLower(b.Addressee))
+	})
+	return households, nil
+}
+
+// GetHousehold returns a copy of the household with the given ID, with its guest count
+func (r *MemoryGuestRepository) GetHousehold(ctx context.Context, id uuid.UUID) (*models.Household, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.lockedHouseholdIndex(id)
+	if i < 0 {
+		return nil, ErrHouseholdNotFound
+	}
+	h := r.lockedHouseholdCopy(&r.households[i])
+	return &h, nil
+}
+
+// UpdateHousehold replaces a household's addressee and address
+func (r *MemoryGuestRepository) UpdateHousehold(ctx context.Context, h *models.Household) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedHouseholdIndex(h.ID)
+	if i < 0 {
+		return ErrHouseholdNotFound
+	}
+	r.households[i].Addressee = h.Addressee
+	r.households[i].PostalAddress = h.PostalAddress
+	return nil
+}
+
+// DeleteHousehold removes a household; its guests are kept without one, as
+// with ON DELETE SET NULL
+func (r *MemoryGuestRepository) DeleteHousehold(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedHouseholdIndex(id)
+	if i < 0 {
+		return ErrHouseholdNotFound
+	}
+	r.households = slices.Delete(r.households, i, i+1)
+	for _, g := range r.guests {
+		if g.HouseholdID != nil && *g.HouseholdID == id {
+			g.HouseholdID = nil
+		}
+	}
+	return nil
+}
+
+// MoveToHousehold makes guests members of a household, taking them out of
+// any other, and returns how many were not members already. Nothing changes
+// if any guest does not exist. Each moved guest's version is advanced.
+func (r *MemoryGuestRepository) MoveToHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockedHouseholdIndex(householdID) < 0 {
+		return 0, ErrHouseholdNotFound
+	}
+	for _, id := range guestIDs {
+		if _, ok := r.guests[id]; !ok {
+			return 0, ErrGuestNotFound
+		}
+	}
+
+	moved := 0
+	for _, id := range uniqueIDs(guestIDs) {
+		g := r.guests[id]
+		if g.HouseholdID != nil && *g.HouseholdID == householdID {
+			continue
+		}
+		household := householdID
+		g.HouseholdID = &household
+		g.Version++
+		moved++
+	}
+	return moved, nil
+}
+
+// RemoveFromHousehold takes guests out of a household and returns how many were members
+func (r *MemoryGuestRepository) RemoveFromHousehold(ctx context.Context, householdID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for _, id := range uniqueIDs(guestIDs) {
+		g, ok := r.guests[id]
+		if !ok || g.HouseholdID == nil || *g.HouseholdID != householdID {
+			continue
+		}
+		g.HouseholdID = nil
+		g.Version++
+		removed++
+	}
+	return removed, nil
+}
+
+// lockedHouseholdIndex returns the position of a household, or -1. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedHouseholdIndex(id uuid.UUID) int {
+	return slices.IndexFunc(r.households, func(h models.Household) bool { return h.ID == id })
+}
+
+// lockedHouseholdCopy returns a copy of the household with its guest count. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedHouseholdCopy(h *models.Household) models.Household {
+	c := *h
+	c.Guests = 0
+	for _, g := range r.guests {
+		if g.HouseholdID != nil && *g.HouseholdID == h.ID {
+			c.Guests++
+		}
+	}
+	return c
+}
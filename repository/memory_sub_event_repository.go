@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateSubEvent stores a copy of the sub-event
+func (r *MemoryGuestRepository) CreateSubEvent(ctx context.Context, e *models.SubEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subEvents = append(r.subEvents, copySubEvent(e))
+	return nil
+}
+
+// GetSubEvents returns copies of every sub-event in chronological order
+func (r *MemoryGuestRepository) GetSubEvents(ctx context.Context) ([]models.SubEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subEvents := make([]models.SubEvent, len(r.subEvents))
+	for i := range r.subEvents {
+		subEvents[i] = copySubEvent(&r.subEvents[i])
+	}
+	slices.SortStableFunc(subEvents, func(a, b models.SubEvent) int { return a.StartsAt.Compare(b.StartsAt) })
+	return subEvents, nil
+}
+
+// GetSubEvent returns a copy of the sub-event with the given ID
+func (r *MemoryGuestRepository) GetSubEvent(ctx context.Context, id uuid.UUID) (*models.SubEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.lockedSubEventIndex(id)
+	if i < 0 {
+		return nil, ErrSubEventNotFound
+	}
+	e := copySubEvent(&r.subEvents[i])
+	return &e, nil
+}
+
+// UpdateSubEvent replaces a sub-event's details
+func (r *MemoryGuestRepository) UpdateSubEvent(ctx context.Context, e *models.SubEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedSubEventIndex(e.ID)
+	if i < 0 {
+		return ErrSubEventNotFound
+	}
+	createdAt := r.subEvents[i].CreatedAt
+	r.subEvents[i] = copySubEvent(e)
+	r.subEvents[i].CreatedAt = createdAt
+	return nil
+}
+
+// DeleteSubEvent removes a sub-event along with its invitations
+func (r *MemoryGuestRepository) DeleteSubEvent(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedSubEventIndex(id)
+	if i < 0 {
+		return ErrSubEventNotFound
+	}
+	r.subEvents = slices.Delete(r.subEvents, i, i+1)
+	r.invitations = slices.DeleteFunc(r.invitations, func(inv models.Invitation) bool { return inv.SubEventID == id })
+	return nil
+}
+
+// InviteGuests invites guests to a sub-event and returns how many were not
+// invited already. Existing invitations, and the answers in them, are kept.
+func (r *MemoryGuestRepository) InviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockedSubEventIndex(subEventID) < 0 {
+		return 0, ErrSubEventNotFound
+	}
+	for _, id := range guestIDs {
+		if _, ok := r.guests[id]; !ok {
+			return 0, ErrGuestNotFound
+		}
+	}
+
+	invited := 0
+	for _, id := range guestIDs {
+		if r.lockedInvitationIndex(subEventID, id) >= 0 {
+			continue
+		}
+		r.invitations = append(r.invitations, models.Invitation{SubEventID: subEventID, GuestID: id, RSVPStatus: models.RSVPStatusPending})
+		invited++
+	}
+	return invited, nil
+}
+
+// UninviteGuests withdraws invitations to a sub-event and returns how many existed
+func (r *MemoryGuestRepository) UninviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := len(r.invitations)
+	r.invitations = slices.DeleteFunc(r.invitations, func(inv models.Invitation) bool {
+		return inv.SubEventID == subEventID && slices.Contains(guestIDs, inv.GuestID)
+	})
+	return before - len(r.invitations), nil
+}
+
+// GetSubEventInvitations returns copies of the invitations to a sub-event
+func (r *MemoryGuestRepository) GetSubEventInvitations(ctx context.Context, subEventID uuid.UUID) ([]models.Invitation, error) {
+	return r.invitationsWhere(ctx, func(inv *models.Invitation) bool { return inv.SubEventID == subEventID })
+}
+
+// GetGuestInvitations returns copies of the invitations a guest holds
+func (r *MemoryGuestRepository) GetGuestInvitations(ctx context.Context, guestID uuid.UUID) ([]models.Invitation, error) {
+	return r.invitationsWhere(ctx, func(inv *models.Invitation) bool { return inv.GuestID == guestID })
+}
+
+// GetAllInvitations returns copies of every invitation
+func (r *MemoryGuestRepository) GetAllInvitations(ctx context.Context) ([]models.Invitation, error) {
+	return r.invitationsWhere(ctx, func(*models.Invitation) bool { return true })
+}
+
+// invitationsWhere returns copies of the invitations matching keep, in the order they were made
+func (r *MemoryGuestRepository) invitationsWhere(ctx context.Context, keep func(*models.Invitation) bool) ([]models.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var invitations []models.Invitation
+	for i := range r.invitations {
+		if keep(&r.invitations[i]) {
+			invitations = append(invitations, copyInvitation(&r.invitations[i]))
+		}
+	}
+	return invitations, nil
+}
+
+// RespondToSubEvents records a guest's answers and overall RSVP all at once,
+// honouring guest.Version; nothing changes if any answer is for a sub-event
+// the guest is not invited to
+func (r *MemoryGuestRepository) RespondToSubEvents(ctx context.Context, guest *models.Guest, answers []models.SubEventAnswer, respondedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	indexes := make([]int, len(answers))
+	for i, answer := range answers {
+		indexes[i] = r.lockedInvitationIndex(answer.SubEventID, guest.ID)
+		if indexes[i] < 0 {
+			return ErrNotInvited
+		}
+	}
+	stored, err := r.lockedForWrite(guest.ID, guest.Version)
+	if err != nil {
+		return err
+	}
+
+	for i, answer := range answers {
+		inv := &r.invitations[indexes[i]]
+		inv.RSVPStatus = answer.RSVPStatus
+		inv.Attendees = answer.Attendees
+		at := respondedAt
+		inv.RespondedAt = &at
+	}
+	stored.RSVPStatus = guest.RSVPStatus
+	stored.TotalGuests = guest.TotalGuests
+	stored.Version++
+	guest.Version = stored.Version
+	return nil
+}
+
+// lockedSubEventIndex returns the position of a sub-event, or -1. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedSubEventIndex(id uuid.UUID) int {
+	return slices.IndexFunc(r.subEvents, func(e models.SubEvent) bool { return e.ID == id })
+}
+
+// lockedInvitationIndex returns the position of an invitation, or -1. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedInvitationIndex(subEventID, guestID uuid.UUID) int {
+	return slices.IndexFunc(r.invitations, func(inv models.Invitation) bool {
+		return inv.SubEventID == subEventID && inv.GuestID == guestID
+	})
+}
+
+// copySubEvent returns a sub-event that shares no memory with e
+func copySubEvent(e *models.SubEvent) models.SubEvent {
+	c := *e
+	c.Translations = maps.Clone(e.Translations)
+	return c
+}
+
+// copyInvitation returns an invitation that shares no memory with inv
+func copyInvitation(inv *models.Invitation) models.Invitation {
+	c := *inv
+	if inv.RespondedAt != nil {
+		at := *inv.RespondedAt
+		c.RespondedAt = &at
+	}
+	return c
+}
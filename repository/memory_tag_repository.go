@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// guestTag assigns a tag to a guest, like a row of guest_tags
+type guestTag struct {
+	GuestID uuid.UUID
+	TagID   uuid.UUID
+}
+
+// CreateTag stores a copy of the tag; names are unique regardless of case
+func (r *MemoryGuestRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockedTagNameTaken(uuid.Nil, tag.Name) {
+		return ErrDuplicateTag
+	}
+	stored := *tag
+	stored.Guests = 0
+	r.tags = append(r.tags, stored)
+	return nil
+}
+
+// GetTags returns copies of every tag in alphabetical order, with guest counts
+func (r *MemoryGuestRepository) GetTags(ctx context.Context) ([]models.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]models.Tag, len(r.tags))
+	for i := range r.tags {
+		tags[i] = r.lockedTagCopy(&r.tags[i])
+	}
+	slices.SortStableFunc(tags, func(a, b models.Tag) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return tags, nil
+}
+
+// GetTag returns a copy of the tag with the given ID, with its guest count
+func (r *MemoryGuestRepository) GetTag(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.lockedTagIndex(id)
+	if i < 0 {
+		return nil, ErrTagNotFound
+	}
+	tag := r.lockedTagCopy(&r.tags[i])
+	return &tag, nil
+}
+
+// UpdateTag renames a tag
+func (r *MemoryGuestRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedTagIndex(tag.ID)
+	if i < 0 {
+		return ErrTagNotFound
+	}
+	if r.lockedTagNameTaken(tag.ID, tag.Name) {
+		return ErrDuplicateTag
+	}
+	r.tags[i].Name = tag.Name
+	return nil
+}
+
+// DeleteTag removes a tag from every guest and then deletes it
+func (r *MemoryGuestRepository) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedTagIndex(id)
+	if i < 0 {
+		return ErrTagNotFound
+	}
+	r.tags = slices.Delete(r.tags, i, i+1)
+	r.guestTags = slices.DeleteFunc(r.guestTags, func(gt guestTag) bool { return gt.TagID == id })
+	return nil
+}
+
+// TagGuests assigns a tag to guests and returns the IDs of those that did not
+// carry it already, advancing their versions. Nothing changes if the tag or
+// any guest does not exist.
+func (r *MemoryGuestRepository) TagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockedTagIndex(tagID) < 0 {
+		return nil, ErrTagNotFound
+	}
+	for _, id := range guestIDs {
+		if _, ok := r.guests[id]; !ok {
+			return nil, ErrGuestNotFound
+		}
+	}
+
+	var tagged []uuid.UUID
+	for _, id := range guestIDs {
+		gt := guestTag{GuestID: id, TagID: tagID}
+		if slices.Contains(r.guestTags, gt) {
+			continue
+		}
+		r.guestTags = append(r.guestTags, gt)
+		r.guests[id].Version++
+		tagged = append(tagged, id)
+	}
+	return tagged, nil
+}
+
+// UntagGuests removes a tag from guests and returns the IDs of those that
+// carried it, advancing their versions
+func (r *MemoryGuestRepository) UntagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var untagged []uuid.UUID
+	r.guestTags = slices.DeleteFunc(r.guestTags, func(gt guestTag) bool {
+		if gt.TagID != tagID || !slices.Contains(guestIDs, gt.GuestID) {
+			return false
+		}
+		r.guests[gt.GuestID].Version++
+		untagged = append(untagged, gt.GuestID)
+		return true
+	})
+	return untagged, nil
+}
+
+// lockedTagIndex returns the position of a tag, or -1. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedTagIndex(id uuid.UUID) int {
+	return slices.IndexFunc(r.tags, func(t models.Tag) bool { return t.ID == id })
+}
+
+// lockedTagNameTaken mirrors the unique index on lower(name), ignoring the
+// tag being renamed. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedTagNameTaken(self uuid.UUID, name string) bool {
+	return slices.ContainsFunc(r.tags, func(t models.Tag) bool { return t.ID != self && strings.EqualFold(t.Name, name) })
+}
+
+// lockedTagCopy returns a copy of the tag with its guest count. The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedTagCopy(tag *models.Tag) models.Tag {
+	c := *tag
+	c.Guests = 0
+	for _, gt := range r.guestTags {
+		if gt.TagID == tag.ID {
+			c.Guests++
+		}
+	}
+	return c
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// copyWebhook returns a webhook that shares no memory with w
+func copyWebhook(w *models.Webhook) models.Webhook {
+	c := *w
+	c.EventTypes = slices.Clone(w.EventTypes)
+	return c
+}
+
+// CreateWebhook stores a copy of the webhook
+func (r *MemoryGuestRepository) CreateWebhook(ctx context.Context, w *models.Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.webhooks = append(r.webhooks, copyWebhook(w))
+	return nil
+}
+
+// GetWebhooks returns copies of every webhook, oldest first
+func (r *MemoryGuestRepository) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	for i := range r.webhooks {
+		webhooks = append(webhooks, copyWebhook(&r.webhooks[i]))
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a copy of the webhook with the given ID
+func (r *MemoryGuestRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.lockedWebhookIndex(id)
+	if i < 0 {
+		return nil, ErrWebhookNotFound
+	}
+	w := copyWebhook(&r.webhooks[i])
+	return &w, nil
+}
+
+// UpdateWebhook replaces a webhook's settings and secret
+func (r *MemoryGuestRepository) UpdateWebhook(ctx context.Context, w *models.Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedWebhookIndex(w.ID)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	createdAt := r.webhooks[i].CreatedAt
+	r.webhooks[i] = copyWebhook(w)
+	r.webhooks[i].CreatedAt = createdAt
+	return nil
+}
+
+// DeleteWebhook removes a webhook along with its delivery log
+func (r *MemoryGuestRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.lockedWebhookIndex(id)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	r.webhooks = slices.Delete(r.webhooks, i, i+1)
+	// Deliveries go with the webhook, as with ON DELETE CASCADE
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d models.WebhookDelivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+// CreateWebhookDelivery records a delivery; it fails with ErrWebhookNotFound if the webhook does not exist
+func (r *MemoryGuestRepository) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockedWebhookIndex(d.WebhookID) < 0 {
+		return ErrWebhookNotFound
+	}
+	r.deliveries = append(r.deliveries, *d)
+	return nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries due at now,
+// postponing their next attempt to now+lease
+func (r *MemoryGuestRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*models.WebhookDelivery
+	for i := range r.deliveries {
+		d := &r.deliveries[i]
+		if d.Status == models.WebhookDeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *models.WebhookDelivery) int { return a.NextAttemptAt.Compare(*b.NextAttemptAt) })
+
+	var claimed []models.WebhookDelivery
+	leased := now.Add(lease)
+	for _, d := range due[:min(limit, len(due))] {
+		d.NextAttemptAt = &leased
+		claimed = append(claimed, *d)
+	}
+	return claimed, nil
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (r *MemoryGuestRepository) UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		stored := &r.deliveries[i]
+		if stored.ID == d.ID {
+			stored.Status = d.Status
+			stored.Attempts = d.Attempts
+			stored.NextAttemptAt = d.NextAttemptAt
+			stored.LastAttemptAt = d.LastAttemptAt
+			stored.ResponseStatus = d.ResponseStatus
+			stored.LastError = d.LastError
+			return nil
+		}
+	}
+	return ErrWebhookNotFound
+}
+
+// GetWebhookDeliveries returns copies of a webhook's most recent deliveries, newest first
+func (r *MemoryGuestRepository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []models.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if d := r.deliveries[i]; d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+// lockedWebhookIndex returns the position of a webhook, or -1.
+// The caller must hold the lock.
+func (r *MemoryGuestRepository) lockedWebhookIndex(id uuid.UUID) int {
+	return slices.IndexFunc(r.webhooks, func(w models.Webhook) bool { return w.ID == id })
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// subEventColumns lists the columns read for every sub-event query, in scan order
+const subEventColumns = "id, name, description, venue, address, starts_at, ends_at, created_at, translations"
+
+// invitationColumns lists the columns read for every invitation query, in scan order
+const invitationColumns = "sub_event_id, guest_id, rsvp_status, attendees, responded_at"
+
+// subEventForeignKey is the foreign key from invitations to their sub-event
+const subEventForeignKey = "sub_event_invitations_sub_event_id_fkey"
+
+// scanSubEvent reads a sub-event selected with subEventColumns
+func scanSubEvent(row rowScanner, e *models.SubEvent) error {
+	var translations []byte
+	if err := row.Scan(&e.ID, &e.Name, &e.Description, &e.Venue, &e.Address, &e.StartsAt, &e.EndsAt, &e.CreatedAt, &translations); err != nil {
+		return err
+	}
+	return json.Unmarshal(translations, &e.Translations)
+}
+
+// subEventTranslations encodes a sub-event's translations for the JSONB column
+func subEventTranslations(e *models.SubEvent) ([]byte, error) {
+	if e.Translations == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(e.Translations)
+}
+
+// scanInvitation reads an invitation selected with invitationColumns
+func scanInvitation(row rowScanner, inv *models.Invitation) error {
+	return row.Scan(&inv.SubEventID, &inv.GuestID, &inv.RSVPStatus, &inv.Attendees, &inv.RespondedAt)
+}
+
+// CreateSubEvent stores a sub-event
+func (r *GuestRepository) CreateSubEvent(ctx context.Context, e *models.SubEvent) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	translations, err := subEventTranslations(e)
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO sub_events (" + subEventColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	_, err = r.DB.ExecContext(ctx, query, e.ID, e.Name, e.Description, e.Venue, e.Address, e.StartsAt, e.EndsAt, e.CreatedAt, translations)
+	return err
+}
+
+// GetSubEvents retrieves every sub-event in chronological order
+func (r *GuestRepository) GetSubEvents(ctx context.Context) ([]models.SubEvent, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+subEventColumns+" FROM sub_events ORDER BY starts_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subEvents []models.SubEvent
+	for rows.Next() {
+		var e models.SubEvent
+		if err := scanSubEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		subEvents = append(subEvents, e)
+	}
+	return subEvents, rows.Err()
+}
+
+// GetSubEvent retrieves a sub-event by ID
+func (r *GuestRepository) GetSubEvent(ctx context.Context, id uuid.UUID) (*models.SubEvent, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var e models.SubEvent
+	err := scanSubEvent(r.DB.QueryRowContext(ctx, "SELECT "+subEventColumns+" FROM sub_events WHERE id = $1", id), &e)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// UpdateSubEvent replaces a sub-event's details
+func (r *GuestRepository) UpdateSubEvent(ctx context.Context, e *models.SubEvent) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	translations, err := subEventTranslations(e)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE sub_events SET name = $2, description = $3, venue = $4, address = $5, starts_at = $6, ends_at = $7, translations = $8 WHERE id = $1"
+	result, err := r.DB.ExecContext(ctx, query, e.ID, e.Name, e.Description, e.Venue, e.Address, e.StartsAt, e.EndsAt, translations)
+	return affectedOrMissing(result, err, ErrSubEventNotFound)
+}
+
+// DeleteSubEvent removes a sub-event along with its invitations
+func (r *GuestRepository) DeleteSubEvent(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM sub_events WHERE id = $1", id)
+	return affectedOrMissing(result, err, ErrSubEventNotFound)
+}
+
+// InviteGuests invites guests to a sub-event and returns how many were not
+// invited already. Existing invitations, and the answers in them, are kept.
+func (r *GuestRepository) InviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO sub_event_invitations (sub_event_id, guest_id, rsvp_status)
+		SELECT $1, guest_id, $3 FROM unnest($2::uuid[]) AS guest_id
+		ON CONFLICT DO NOTHING
+	`
+	result, err := r.DB.ExecContext(ctx, query, subEventID, uuidArray(guestIDs), models.RSVPStatusPending)
+	if isForeignKeyViolation(err) {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == subEventForeignKey {
+			return 0, ErrSubEventNotFound
+		}
+		return 0, ErrGuestNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	invited, err := result.RowsAffected()
+	return int(invited), err
+}
+
+// UninviteGuests withdraws invitations to a sub-event and returns how many existed
+func (r *GuestRepository) UninviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM sub_event_invitations WHERE sub_event_id = $1 AND guest_id = ANY($2::uuid[])"
+	result, err := r.DB.ExecContext(ctx, query, subEventID, uuidArray(guestIDs))
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// GetSubEventInvitations retrieves the invitations to a sub-event
+func (r *GuestRepository) GetSubEventInvitations(ctx context.Context, subEventID uuid.UUID) ([]models.Invitation, error) {
+	return r.queryInvitations(ctx, "SELECT "+invitationColumns+" FROM sub_event_invitations WHERE sub_event_id = $1 ORDER BY guest_id", subEventID)
+}
+
+// GetGuestInvitations retrieves the invitations a guest holds
+func (r *GuestRepository) GetGuestInvitations(ctx context.Context, guestID uuid.UUID) ([]models.Invitation, error) {
+	return r.queryInvitations(ctx, "SELECT "+invitationColumns+" FROM sub_event_invitations WHERE guest_id = $1 ORDER BY sub_event_id", guestID)
+}
+
+// GetAllInvitations retrieves every invitation
+func (r *GuestRepository) GetAllInvitations(ctx context.Context) ([]models.Invitation, error) {
+	return r.queryInvitations(ctx, "SELECT "+invitationColumns+" FROM sub_event_invitations ORDER BY sub_event_id, guest_id")
+}
+
+// queryInvitations runs a query selecting invitationColumns and collects the results
+func (r *GuestRepository) queryInvitations(ctx context.Context, query string, args ...interface{}) ([]models.Invitation, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		var inv models.Invitation
+		if err := scanInvitation(rows, &inv); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// RespondToSubEvents records a guest's answers for the sub-events they are
+// invited to and saves the guest's overall RSVP status and party size, all
+// in one transaction. It fails with ErrNotInvited if any answer is for a
+// sub-event the guest is not invited to, and honours guest.Version like
+// UpdateGuest; on success guest.Version is advanced to the new version.
+func (r *GuestRepository) RespondToSubEvents(ctx context.Context, guest *models.Guest, answers []models.SubEventAnswer, respondedAt time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	for _, answer := range answers {
+		query := `UPDATE sub_event_invitations SET rsvp_status = $3, attendees = $4, responded_at = $5
+			WHERE sub_event_id = $1 AND guest_id = $2`
+		result, err := tx.ExecContext(ctx, query, answer.SubEventID, guest.ID, answer.RSVPStatus, answer.Attendees, respondedAt)
+		if err := affectedOrMissing(result, err, ErrNotInvited); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE guests
+		SET rsvp_status = $1, total_guests = $2, version = version + 1
+		WHERE id = $3 AND ($4 = 0 OR version = $4)
+		RETURNING version;
+	`
+	err = tx.QueryRowContext(ctx, query, guest.RSVPStatus, guest.TotalGuests, guest.ID, guest.Version).Scan(&guest.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return r.missOrConflict(ctx, guest.ID)
+		}
+		return fmt.Errorf("failed to update RSVP: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// uuidArray converts IDs to a value Postgres accepts as uuid[]
+func uuidArray(ids []uuid.UUID) interface{} {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return pq.StringArray(values)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// SubEventStore persists sub-events and the invitations and answers of the guests invited to them
+type SubEventStore interface {
+	CreateSubEvent(ctx context.Context, subEvent *models.SubEvent) error
+	GetSubEvents(ctx context.Context) ([]models.SubEvent, error)
+	GetSubEvent(ctx context.Context, id uuid.UUID) (*models.SubEvent, error)
+	UpdateSubEvent(ctx context.Context, subEvent *models.SubEvent) error
+	DeleteSubEvent(ctx context.Context, id uuid.UUID) error
+	InviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error)
+	UninviteGuests(ctx context.Context, subEventID uuid.UUID, guestIDs []uuid.UUID) (int, error)
+	GetSubEventInvitations(ctx context.Context, subEventID uuid.UUID) ([]models.Invitation, error)
+	GetGuestInvitations(ctx context.Context, guestID uuid.UUID) ([]models.Invitation, error)
+	GetAllInvitations(ctx context.Context) ([]models.Invitation, error)
+	RespondToSubEvents(ctx context.Context, guest *models.Guest, answers []models.SubEventAnswer, respondedAt time.Time) error
+}
+
+// Compile-time checks that both implementations satisfy SubEventStore
+var (
+	_ SubEventStore = (*GuestRepository)(nil)
+	_ SubEventStore = (*MemoryGuestRepository)(nil)
+)
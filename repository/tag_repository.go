@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// tagColumns lists the columns read for every tag query, in scan order.
+// The guest count is gathered by a subquery.
+const tagColumns = "id, name, created_at, (SELECT count(*) FROM guest_tags gt WHERE gt.tag_id = tags.id)"
+
+// tagForeignKey is the foreign key from guest tags to their tag
+const tagForeignKey = "guest_tags_tag_id_fkey"
+
+// scanTag reads a tag selected with tagColumns
+func scanTag(row rowScanner, tag *models.Tag) error {
+	return row.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.Guests)
+}
+
+// CreateTag stores a tag; names are unique regardless of case
+func (r *GuestRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO tags (id, name, created_at) VALUES ($1, $2, $3)", tag.ID, tag.Name, tag.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateTag
+	}
+	return err
+}
+
+// GetTags retrieves every tag in alphabetical order, with its guest count
+func (r *GuestRepository) GetTags(ctx context.Context) ([]models.Tag, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+tagColumns+" FROM tags ORDER BY lower(name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := scanTag(rows, &tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetTag retrieves a tag by ID, with its guest count
+func (r *GuestRepository) GetTag(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var tag models.Tag
+	err := scanTag(r.DB.QueryRowContext(ctx, "SELECT "+tagColumns+" FROM tags WHERE id = $1", id), &tag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// UpdateTag renames a tag
+func (r *GuestRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "UPDATE tags SET name = $2 WHERE id = $1", tag.ID, tag.Name)
+	if isUniqueViolation(err) {
+		return ErrDuplicateTag
+	}
+	return affectedOrMissing(result, err, ErrTagNotFound)
+}
+
+// DeleteTag removes a tag from every guest and then deletes it
+func (r *GuestRepository) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", id)
+	return affectedOrMissing(result, err, ErrTagNotFound)
+}
+
+// TagGuests assigns a tag to guests and returns the IDs of those that did not
+// carry it already. Their versions are advanced, since a guest's tags are part
+// of the guest. Nothing changes if the tag or any guest does not exist.
+func (r *GuestRepository) TagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	query := `
+		INSERT INTO guest_tags (tag_id, guest_id)
+		SELECT $1, guest_id FROM unnest($2::uuid[]) AS guest_id
+		ON CONFLICT DO NOTHING
+		RETURNING guest_id
+	`
+	tagged, err := queryIDs(ctx, tx, query, tagID, uuidArray(guestIDs))
+	if isForeignKeyViolation(err) {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == tagForeignKey {
+			return nil, ErrTagNotFound
+		}
+		return nil, ErrGuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := advanceVersions(ctx, tx, tagged); err != nil {
+		return nil, err
+	}
+	return tagged, tx.Commit()
+}
+
+// UntagGuests removes a tag from guests and returns the IDs of those that
+// carried it, whose versions are advanced
+func (r *GuestRepository) UntagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	query := "DELETE FROM guest_tags WHERE tag_id = $1 AND guest_id = ANY($2::uuid[]) RETURNING guest_id"
+	untagged, err := queryIDs(ctx, tx, query, tagID, uuidArray(guestIDs))
+	if err != nil {
+		return nil, err
+	}
+	if err := advanceVersions(ctx, tx, untagged); err != nil {
+		return nil, err
+	}
+	return untagged, tx.Commit()
+}
+
+// queryIDs runs a query selecting one UUID per row
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// advanceVersions bumps the version of guests changed by a write to another table
+func advanceVersions(ctx context.Context, tx *sql.Tx, guestIDs []uuid.UUID) error {
+	if len(guestIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "UPDATE guests SET version = version + 1 WHERE id = ANY($1::uuid[])", uuidArray(guestIDs))
+	return err
+}
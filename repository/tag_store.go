@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// TagStore persists tags and which guests carry them
+type TagStore interface {
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	GetTags(ctx context.Context) ([]models.Tag, error)
+	GetTag(ctx context.Context, id uuid.UUID) (*models.Tag, error)
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, id uuid.UUID) error
+	TagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error)
+	UntagGuests(ctx context.Context, tagID uuid.UUID, guestIDs []uuid.UUID) ([]uuid.UUID, error)
+}
+
+// Compile-time checks that both implementations satisfy TagStore
+var (
+	_ TagStore = (*GuestRepository)(nil)
+	_ TagStore = (*MemoryGuestRepository)(nil)
+)
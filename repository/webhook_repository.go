@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// webhookColumns lists the columns read for every webhook query, in scan order
+const webhookColumns = "id, url, secret, event_types, description, active, created_at, updated_at"
+
+// webhookDeliveryColumns lists the columns read for every delivery query, in scan order
+const webhookDeliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at"
+
+// scanWebhook reads a webhook selected with webhookColumns
+func scanWebhook(row rowScanner, w *models.Webhook) error {
+	return row.Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.EventTypes), &w.Description, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// scanWebhookDelivery reads a delivery selected with webhookDeliveryColumns
+func scanWebhookDelivery(row rowScanner, d *models.WebhookDelivery) error {
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt)
+	d.Payload = payload
+	return err
+}
+
+// CreateWebhook registers a webhook
+func (r *GuestRepository) CreateWebhook(ctx context.Context, w *models.Webhook) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO webhooks (" + webhookColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err := r.DB.ExecContext(ctx, query, w.ID, w.URL, w.Secret, pq.Array(w.EventTypes), w.Description, w.Active, w.CreatedAt, w.UpdatedAt)
+	return err
+}
+
+// GetWebhooks retrieves every webhook, oldest first
+func (r *GuestRepository) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook retrieves a webhook by ID
+func (r *GuestRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var w models.Webhook
+	err := scanWebhook(r.DB.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id), &w)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// UpdateWebhook replaces a webhook's settings and secret
+func (r *GuestRepository) UpdateWebhook(ctx context.Context, w *models.Webhook) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE webhooks SET url = $2, secret = $3, event_types = $4, description = $5, active = $6, updated_at = $7 WHERE id = $1"
+	result, err := r.DB.ExecContext(ctx, query, w.ID, w.URL, w.Secret, pq.Array(w.EventTypes), w.Description, w.Active, w.UpdatedAt)
+	return affectedOrMissing(result, err, ErrWebhookNotFound)
+}
+
+// DeleteWebhook removes a webhook along with its delivery log
+func (r *GuestRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	return affectedOrMissing(result, err, ErrWebhookNotFound)
+}
+
+// CreateWebhookDelivery records a delivery; it fails with ErrWebhookNotFound if the webhook does not exist
+func (r *GuestRepository) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO webhook_deliveries (" + webhookDeliveryColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)"
+	_, err := r.DB.ExecContext(ctx, query, d.ID, d.WebhookID, d.EventID, d.EventType, []byte(d.Payload), d.Status, d.Attempts,
+		d.NextAttemptAt, d.LastAttemptAt, d.ResponseStatus, d.LastError, d.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries due at now,
+// postponing their next attempt to now+lease so that no other dispatcher
+// picks them up meanwhile. A dispatcher that dies mid-attempt leaves the
+// delivery to be retried once the lease runs out.
+func (r *GuestRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $4 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+	rows, err := r.DB.QueryContext(ctx, query, now, now.Add(lease), limit, models.WebhookDeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhookDeliveries(rows)
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (r *GuestRepository) UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+		response_status = $6, last_error = $7 WHERE id = $1`
+	result, err := r.DB.ExecContext(ctx, query, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastAttemptAt, d.ResponseStatus, d.LastError)
+	return affectedOrMissing(result, err, ErrWebhookNotFound)
+}
+
+// GetWebhookDeliveries retrieves a webhook's most recent deliveries, newest first
+func (r *GuestRepository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC, id LIMIT $2"
+	rows, err := r.DB.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhookDeliveries(rows)
+}
+
+// collectWebhookDeliveries scans and closes rows selecting webhookDeliveryColumns
+func collectWebhookDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// affectedOrMissing turns a write that matched no rows into missing
+func affectedOrMissing(result sql.Result, err error, missing error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return missing
+	}
+	return nil
+}
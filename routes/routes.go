@@ -1,36 +1,141 @@
 package routes
 
 import (
+	"net/http"
+
+	"github.com/g4l1l10/rsvp-backend/config"
 	"github.com/g4l1l10/rsvp-backend/handlers"
+	"github.com/g4l1l10/rsvp-backend/metrics"
 	"github.com/g4l1l10/rsvp-backend/middlewares"
+	"github.com/g4l1l10/rsvp-backend/ratelimit"
 
 	"github.com/g4l1l10/rsvp-backend/health"
 	"github.com/gin-gonic/gin"
 )
 
+// Handlers groups the handlers of each subsystem
+type Handlers struct {
+	Guests     *handlers.GuestHandler
+	Events     *handlers.EventsHandler
+	Webhooks   *handlers.WebhookHandler
+	SubEvents  *handlers.SubEventHandler
+	Tags       *handlers.TagHandler
+	Households *handlers.HouseholdHandler
+	CheckIns   *handlers.CheckInHandler
+	Duplicates *handlers.DuplicateHandler
+}
+
 // SetupRoutes registers API endpoints
-func SetupRoutes(router *gin.Engine, guestHandler *handlers.GuestHandler) {
-	// Health check route
-	router.GET("/status", health.HealthCheckHandler)
+func SetupRoutes(router *gin.Engine, cfg *config.Config, h Handlers, healthRegistry *health.Registry, rateStore ratelimit.Store) {
+	// Tag every request with an ID and a request-scoped logger, then log it once it completes
+	router.Use(middlewares.RequestID())
+	router.Use(middlewares.AccessLog())
+
+	// Record request metrics; registered before the handlers so it sees the final status of every request
+	router.Use(middlewares.MetricsMiddleware())
+
+	// Render errors recorded by handlers as problem+json responses
+	router.Use(middlewares.ErrorHandler())
+
+	// Convert panics into 500 problem responses logged with the request ID
+	router.Use(middlewares.Recovery())
+
+	// Health check routes: liveness never touches dependencies, readiness does
+	router.GET("/healthz", health.LivenessHandler)
+	router.GET("/readyz", healthRegistry.ReadinessHandler)
+	router.GET("/status", healthRegistry.ReadinessHandler) // Kept for existing monitors
+
+	// Prometheus scrape endpoint
+	router.GET("/metrics", metrics.Default.Handler(cfg.Metrics.Token.Reveal()))
 
 	// Public RSVP Routes (Guests can only submit their RSVP)
 	rsvpRoutes := router.Group("/rsvp")
+	rsvpRoutes.Use(middlewares.CORS(cfg.CORS.RSVP, http.MethodGet, http.MethodPost))
+	rsvpRoutes.Use(middlewares.RateLimit(cfg.RateLimit, rateStore)) // Throttle token guessing and floods
 	{
-		rsvpRoutes.POST("/", guestHandler.SubmitRSVP)
-		rsvpRoutes.GET("/:token", guestHandler.GetGuestByToken)
+		rsvpRoutes.OPTIONS("/*path", middlewares.Preflight)
+		rsvpRoutes.POST("/", h.Guests.SubmitRSVP)
+		rsvpRoutes.GET("/:token", h.Guests.RSVPPage) // Accepts the token or the short code
+		rsvpRoutes.GET("/:token/calendar.ics", h.Guests.GuestCalendar)
+		rsvpRoutes.GET("/:token/sub-events", h.SubEvents.GuestSubEvents)
 	}
 
 	// Admin Guest Management (Protected)
 	adminRoutes := router.Group("/admin")
-	adminRoutes.Use(middlewares.AuthMiddleware()) // Require JWT authentication
+	// CORS runs first so preflights, which carry no credentials, are answered before authentication
+	adminRoutes.Use(middlewares.CORS(cfg.CORS.Admin, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete))
+	adminRoutes.Use(middlewares.AuthMiddleware(cfg.Auth.ServiceURL)) // Require JWT authentication
 	{
-		adminRoutes.POST("/invite", guestHandler.SendInvite)
-
-		adminRoutes.GET("/guests", guestHandler.GetAllGuests)
-		adminRoutes.GET("/guests/:id", guestHandler.GetGuestByID)
-		adminRoutes.GET("/guests/email/:email", guestHandler.GetGuestByEmail)
-		adminRoutes.GET("/guests/rsvp/:token", guestHandler.GetGuestByToken)
-		adminRoutes.PUT("/guests/:id", guestHandler.UpdateGuest)
-		adminRoutes.DELETE("/guests/:id", guestHandler.DeleteGuest)
+		adminRoutes.OPTIONS("/*path", middlewares.Preflight)
+
+		adminRoutes.POST("/invite", h.Guests.SendInvite)
+
+		adminRoutes.GET("/guests", h.Guests.GetAllGuests)
+		adminRoutes.GET("/guests/:id", h.Guests.GetGuestByID)
+		adminRoutes.GET("/guests/email/:email", h.Guests.GetGuestByEmail)
+		adminRoutes.GET("/guests/rsvp/:token", h.Guests.GetGuestByToken)
+		adminRoutes.POST("/guests/bulk", h.Guests.BulkGuests)
+		adminRoutes.GET("/guests/duplicates", h.Duplicates.FindDuplicateGuests)
+		adminRoutes.POST("/guests/merge", h.Duplicates.MergeGuests)
+		adminRoutes.GET("/guests/merges", h.Duplicates.GetGuestMerges)
+		adminRoutes.PUT("/guests/:id", h.Guests.UpdateGuest)
+		adminRoutes.PATCH("/guests/:id", h.Guests.PatchGuest)
+		adminRoutes.DELETE("/guests/:id", h.Guests.DeleteGuest)
+
+		// Replace or disable leaked RSVP credentials
+		adminRoutes.POST("/guests/:id/rsvp-token", h.Guests.RegenerateRSVPToken)
+		adminRoutes.DELETE("/guests/:id/rsvp-token", h.Guests.RevokeRSVPToken)
+		adminRoutes.POST("/guests/:id/rsvp-code", h.Guests.RegenerateRSVPCode)
+		adminRoutes.DELETE("/guests/:id/rsvp-code", h.Guests.RevokeRSVPCode)
+
+		// Paper invitations: a QR code per guest, printable card sheets and mailing labels
+		adminRoutes.GET("/guests/:id/qr.png", h.Guests.GuestQRCode)
+		adminRoutes.GET("/invitations.pdf", h.Guests.InvitationsPDF)
+		adminRoutes.GET("/labels.csv", h.Households.MailingLabelsCSV)
+		adminRoutes.GET("/labels.pdf", h.Households.MailingLabelsPDF)
+
+		// Day-of check-in at the door
+		adminRoutes.POST("/checkin", h.CheckIns.CheckIn)
+		adminRoutes.GET("/checkin/summary", h.CheckIns.CheckInSummary)
+
+		// Live guest changes for the dashboard, as Server-Sent Events
+		adminRoutes.GET("/events/stream", h.Events.Stream)
+
+		// Outgoing webhooks and their delivery log
+		adminRoutes.GET("/webhooks", h.Webhooks.GetWebhooks)
+		adminRoutes.POST("/webhooks", h.Webhooks.CreateWebhook)
+		adminRoutes.GET("/webhooks/:id", h.Webhooks.GetWebhook)
+		adminRoutes.PUT("/webhooks/:id", h.Webhooks.UpdateWebhook)
+		adminRoutes.DELETE("/webhooks/:id", h.Webhooks.DeleteWebhook)
+		adminRoutes.POST("/webhooks/:id/secret", h.Webhooks.RotateWebhookSecret)
+		adminRoutes.POST("/webhooks/:id/test", h.Webhooks.TestWebhook)
+		adminRoutes.GET("/webhooks/:id/deliveries", h.Webhooks.GetWebhookDeliveries)
+
+		// Sub-events with their own guest lists: tea ceremony, banquet, after-party
+		adminRoutes.GET("/sub-events", h.SubEvents.GetSubEvents)
+		adminRoutes.POST("/sub-events", h.SubEvents.CreateSubEvent)
+		adminRoutes.GET("/sub-events/matrix", h.SubEvents.InvitationMatrix)
+		adminRoutes.GET("/sub-events/summary", h.SubEvents.SubEventSummary)
+		adminRoutes.GET("/sub-events/:id", h.SubEvents.GetSubEvent)
+		adminRoutes.PUT("/sub-events/:id", h.SubEvents.UpdateSubEvent)
+		adminRoutes.DELETE("/sub-events/:id", h.SubEvents.DeleteSubEvent)
+		adminRoutes.GET("/sub-events/:id/invitations", h.SubEvents.GetSubEventInvitations)
+		adminRoutes.POST("/sub-events/:id/invitations", h.SubEvents.ChangeInvitations)
+
+		// Tags grouping guests for filtering and bulk actions
+		adminRoutes.GET("/tags", h.Tags.GetTags)
+		adminRoutes.POST("/tags", h.Tags.CreateTag)
+		adminRoutes.GET("/tags/:id", h.Tags.GetTag)
+		adminRoutes.PUT("/tags/:id", h.Tags.RenameTag)
+		adminRoutes.DELETE("/tags/:id", h.Tags.DeleteTag)
+		adminRoutes.POST("/tags/:id/guests", h.Tags.ChangeTagging)
+
+		// Households and the postal addresses paper invitations are mailed to
+		adminRoutes.GET("/households", h.Households.GetHouseholds)
+		adminRoutes.POST("/households", h.Households.CreateHousehold)
+		adminRoutes.GET("/households/:id", h.Households.GetHousehold)
+		adminRoutes.PUT("/households/:id", h.Households.UpdateHousehold)
+		adminRoutes.DELETE("/households/:id", h.Households.DeleteHousehold)
+		adminRoutes.POST("/households/:id/guests", h.Households.ChangeHouseholdMembers)
 	}
 }
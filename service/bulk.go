@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+)
+
+// PreviewBulk lists the guests a bulk action on the filter's selection would
+// affect. Its count is what RunBulk expects to be confirmed.
+func (s *GuestService) PreviewBulk(ctx context.Context, filter models.GuestFilter, action models.BulkAction) (*models.BulkPreview, error) {
+	if err := action.Validate(); err != nil {
+		return nil, err
+	}
+	targets, err := s.bulkTargets(ctx, filter, action)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BulkPreview{Action: action.Action, Count: len(targets), Guests: targets}, nil
+}
+
+// RunBulk applies a bulk action to the guests the filter selects, guest by
+// guest. confirmedCount must equal the count of the latest preview; if the
+// selection has changed since, nothing is done and the caller should preview
+// again. A failure for one guest is reported without stopping the others.
+func (s *GuestService) RunBulk(ctx context.Context, filter models.GuestFilter, action models.BulkAction, confirmedCount int) (*models.BulkResult, error) {
+	if err := action.Validate(); err != nil {
+		return nil, err
+	}
+	sendsEmail := action.Action == models.BulkSendInvites || action.Action == models.BulkSendReminders
+	if sendsEmail && s.Mailer == nil {
+		return nil, apperrors.New(apperrors.ErrUpstream, "email delivery is not configured")
+	}
+	targets, err := s.bulkTargets(ctx, filter, action)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) != confirmedCount {
+		return nil, apperrors.Newf(apperrors.ErrPreconditionFailed, "the selection now has %d guests, not %d; preview it again", len(targets), confirmedCount)
+	}
+
+	result := &models.BulkResult{Action: action.Action, Count: len(targets), Failed: []models.BulkFailure{}}
+	for i := range targets {
+		guest := &targets[i]
+		if err := s.applyBulk(ctx, guest, action); err != nil {
+			logging.FromContext(ctx).Warn("bulk action failed for guest",
+				slog.String("action", action.Action), slog.String("guest_id", guest.ID.String()), slog.Any("error", err))
+			detail := apperrors.Detail(err)
+			if detail == "" {
+				detail = "internal error"
+			}
+			result.Failed = append(result.Failed, models.BulkFailure{GuestID: guest.ID, Name: guest.Name, Error: detail})
+			continue
+		}
+		result.Succeeded++
+	}
+	logging.FromContext(ctx).Info("bulk action applied", slog.String("action", action.Action),
+		slog.Int("count", result.Count), slog.Int("failed", len(result.Failed)))
+	return result, nil
+}
+
+// bulkTargets returns the guests the filter selects that the action affects
+func (s *GuestService) bulkTargets(ctx context.Context, filter models.GuestFilter, action models.BulkAction) ([]models.Guest, error) {
+	guests, err := s.FindGuests(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	targets := []models.Guest{}
+	for i := range guests {
+		if action.Applies(&guests[i]) {
+			targets = append(targets, guests[i])
+		}
+	}
+	return targets, nil
+}
+
+// applyBulk applies the action to one guest
+func (s *GuestService) applyBulk(ctx context.Context, guest *models.Guest, action models.BulkAction) error {
+	switch action.Action {
+	case models.BulkSendInvites:
+		credential, err := s.emailCredential(ctx, guest)
+		if err != nil {
+			return err
+		}
+		guest.RSVPToken = credential
+		return s.SendInvitation(ctx, guest)
+	case models.BulkSendReminders:
+		credential, err := s.emailCredential(ctx, guest)
+		if err != nil {
+			return err
+		}
+		if err := s.Mailer.SendReminder(ctx, guest.Name, guest.Email, credential, guest.PreferredLanguage); err != nil {
+			return apperrors.Wrap(apperrors.ErrUpstream, err, "failed to send reminder")
+		}
+		return nil
+	case models.BulkUpdate:
+		_, err := s.PatchGuest(ctx, guest.ID, action.Update, 0)
+		return err
+	case models.BulkDelete:
+		return s.DeleteGuest(ctx, guest.ID, 0)
+	}
+	return apperrors.Newf(apperrors.ErrValidation, "unknown action %q", action.Action)
+}
+
+// emailCredential returns the credential to put in an email to a stored
+// guest. Links are only kept as hashes, so the printed code is used when the
+// guest has one; otherwise a new link is issued, replacing the old one.
+func (s *GuestService) emailCredential(ctx context.Context, guest *models.Guest) (string, error) {
+	if guest.RSVPCode != "" {
+		return guest.RSVPCode, nil
+	}
+	updated, err := s.RegenerateRSVPToken(ctx, guest.ID, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to issue RSVP link: %w", err)
+	}
+	return updated.RSVPToken, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/calendar"
+	"github.com/g4l1l10/rsvp-backend/i18n"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+)
+
+// GuestCalendar returns the iCalendar file for the guest holding the RSVP
+// credential, listing the celebrations they are invited to
+func (s *GuestService) GuestCalendar(ctx context.Context, credential string) ([]byte, error) {
+	guest, err := s.lookupCredential(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guest: %w", err)
+	}
+	// Not a 404: calendar apps keep polling a subscribed feed, and unknown
+	// credentials are what the rate limiter charges as guessing
+	if guest.RSVPStatus == models.RSVPStatusNotAttending {
+		return nil, apperrors.New(apperrors.ErrConflict, "guest replied that they are not attending")
+	}
+
+	ics, err := s.guestCalendar(ctx, guest, s.guestLink(guest, credential))
+	if err != nil {
+		return nil, err
+	}
+	if ics == nil {
+		return nil, apperrors.New(apperrors.ErrNotFound, "the wedding schedule has not been announced yet")
+	}
+	return ics, nil
+}
+
+// guestCalendar builds a guest's calendar, in their preferred language: the
+// wedding, when its date is configured, and the sub-events the guest is
+// invited to and has not declined. It returns nil when there is nothing to
+// put in it.
+// link leads back to the guest's RSVP page.
+func (s *GuestService) guestCalendar(ctx context.Context, guest *models.Guest, link string) ([]byte, error) {
+	lang := i18n.Resolve(guest.PreferredLanguage)
+	subEvents, err := s.invitedSubEvents(ctx, guest.ID, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	rsvp := i18n.T(lang, "calendar.rsvp") + link
+	var entries []calendar.Event
+	if s.Wedding.Scheduled() {
+		entries = append(entries, calendar.Event{
+			UID:         s.calendarUID("wedding"),
+			Summary:     s.Wedding.Title,
+			Description: joinParagraphs(s.Wedding.Description, rsvp),
+			Location:    joinLocation(s.Wedding.Venue, s.Wedding.Address),
+			URL:         link,
+			Start:       s.Wedding.Start,
+			End:         s.Wedding.End,
+		})
+	}
+	for _, e := range subEvents {
+		if e.RSVPStatus == models.RSVPStatusNotAttending {
+			continue
+		}
+		entries = append(entries, calendar.Event{
+			UID:         s.calendarUID(e.ID.String()),
+			Summary:     e.Name,
+			Description: joinParagraphs(e.Description, rsvp),
+			Location:    joinLocation(e.Venue, e.Address),
+			URL:         link,
+			Start:       e.StartsAt,
+			End:         e.EndsAt,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	calendar.Write(&buf, time.Now(), entries) // writes to a buffer cannot fail
+	return buf.Bytes(), nil
+}
+
+// attachableCalendar is guestCalendar for email attachments, where a
+// missing calendar must not stop the email from being sent
+func (s *GuestService) attachableCalendar(ctx context.Context, guest *models.Guest, link string) []byte {
+	ics, err := s.guestCalendar(ctx, guest, link)
+	if err != nil {
+		logging.FromContext(ctx).Warn("calendar left out of email", slog.String("guest_id", guest.ID.String()), slog.Any("error", err))
+	}
+	return ics
+}
+
+// joinLocation formats a venue and its address, either of which may be empty
+func joinLocation(venue, address string) string {
+	if address == "" {
+		return venue
+	}
+	return strings.TrimPrefix(venue+", "+address, ", ")
+}
+
+// joinParagraphs joins the non-empty paragraphs with blank lines
+func joinParagraphs(paragraphs ...string) string {
+	var kept []string
+	for _, p := range paragraphs {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// calendarUID returns a globally unique, stable ID for a calendar entry,
+// scoped by the public site's host
+func (s *GuestService) calendarUID(key string) string {
+	host := "rsvp-backend"
+	if u, err := url.Parse(s.PublicURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return key + "@" + host
+}
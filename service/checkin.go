@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// CheckInService records guests arriving at the door on the day
+type CheckInService struct {
+	Store repository.CheckInStore
+
+	// Guests looks up arriving guests and publishes their check-ins
+	Guests *GuestService
+}
+
+// NewCheckInService initializes a new check-in service
+func NewCheckInService(store repository.CheckInStore, guests *GuestService) *CheckInService {
+	return &CheckInService{Store: store, Guests: guests}
+}
+
+// CheckInRequest identifies an arriving guest by RSVP credential or ID
+type CheckInRequest struct {
+	// Credential is an RSVP token, a short code or a scanned RSVP link
+	Credential string
+	GuestID    uuid.UUID
+	// Arrived is the number of party members arriving; zero means the rest of the party
+	Arrived int
+}
+
+// CheckIn records arriving party members at the door. Nobody is turned away:
+// repeat scans, arrivals beyond the party size and guests who did not say
+// they were coming are recorded and returned as warnings for door staff.
+func (s *CheckInService) CheckIn(ctx context.Context, req CheckInRequest) (*models.CheckInResult, error) {
+	if (req.Credential == "") == (req.GuestID == uuid.Nil) {
+		return nil, apperrors.New(apperrors.ErrValidation, "provide either an RSVP token or a guest ID")
+	}
+	if req.Arrived < 0 {
+		return nil, apperrors.New(apperrors.ErrValidation, "arrived cannot be negative")
+	}
+
+	guest, err := s.checkInGuest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find arriving guest: %w", err)
+	}
+
+	// The store counts earlier arrivals and records this one atomically, so
+	// two scans of one party at the same moment cannot both see it absent
+	checkIn := models.CheckIn{ID: uuid.New(), GuestID: guest.ID, Arrived: req.Arrived, CheckedInAt: time.Now().UTC()}
+	previous, err := s.Store.CreateCheckIn(ctx, &checkIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record check-in: %w", err)
+	}
+	arrivedBefore := 0
+	for _, c := range previous {
+		arrivedBefore += c.Arrived
+	}
+	arrived := checkIn.Arrived
+
+	var warnings []models.CheckInWarning
+	if arrivedBefore > 0 {
+		last := previous[len(previous)-1].CheckedInAt
+		warnings = append(warnings, models.CheckInWarning{
+			Code:    models.CheckInWarningAlreadyCheckedIn,
+			Message: fmt.Sprintf("%d of %d already checked in, last at %s", arrivedBefore, guest.TotalGuests, last.Format(time.RFC3339)),
+		})
+	}
+	if total := arrivedBefore + arrived; total > guest.TotalGuests {
+		warnings = append(warnings, models.CheckInWarning{
+			Code:    models.CheckInWarningExceedsParty,
+			Message: fmt.Sprintf("%d arrived for a party of %d", total, guest.TotalGuests),
+		})
+	}
+	switch guest.RSVPStatus {
+	case models.RSVPStatusNotAttending:
+		warnings = append(warnings, models.CheckInWarning{Code: models.CheckInWarningNotAttending, Message: "guest replied that they are not attending"})
+	case models.RSVPStatusPending:
+		warnings = append(warnings, models.CheckInWarning{Code: models.CheckInWarningRSVPPending, Message: "guest never replied to the invitation"})
+	}
+
+	logging.FromContext(ctx).Info("guest checked in",
+		slog.String("guest_id", guest.ID.String()), slog.Int("arrived", arrived), slog.Int("warnings", len(warnings)))
+
+	result := &models.CheckInResult{
+		Guest:    guest,
+		CheckIn:  checkIn,
+		Arrived:  arrivedBefore + arrived,
+		Expected: guest.TotalGuests,
+		Warnings: warnings,
+	}
+	s.Guests.publishCheckIn(ctx, result)
+	return result, nil
+}
+
+// checkInGuest resolves the guest named by a check-in request
+func (s *CheckInService) checkInGuest(ctx context.Context, req CheckInRequest) (*models.Guest, error) {
+	if req.GuestID != uuid.Nil {
+		return s.Guests.Repo.GetGuestByID(ctx, req.GuestID)
+	}
+	return s.Guests.lookupCredential(ctx, scannedCredential(req.Credential))
+}
+
+// scannedCredential extracts the credential from a scanned RSVP link such as
+// https://axeldaphne.com/rsvp/ABCD2345; other input is returned trimmed
+func scannedCredential(scan string) string {
+	scan = strings.TrimSpace(scan)
+	u, err := url.Parse(scan)
+	if err != nil || u.Host == "" || !strings.Contains(u.Path, "/rsvp/") {
+		return scan
+	}
+	return path.Base(u.Path)
+}
+
+// GetCheckInSummary compares arrivals so far with the guests expected to attend
+func (s *CheckInService) GetCheckInSummary(ctx context.Context) (*models.CheckInSummary, error) {
+	guests, err := s.Guests.Repo.GetAllGuests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guests: %w", err)
+	}
+	checkIns, err := s.Store.GetAllCheckIns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch check-ins: %w", err)
+	}
+
+	var summary models.CheckInSummary
+	arrivals := make(map[uuid.UUID]int)
+	for _, c := range checkIns {
+		arrivals[c.GuestID] += c.Arrived
+		summary.ArrivedAttendees += c.Arrived
+		if summary.LastCheckInAt == nil || c.CheckedInAt.After(*summary.LastCheckInAt) {
+			at := c.CheckedInAt
+			summary.LastCheckInAt = &at
+		}
+	}
+	summary.ArrivedParties = len(arrivals)
+
+	for _, g := range guests {
+		arrived := arrivals[g.ID]
+		if g.RSVPStatus != models.RSVPStatusAttending {
+			summary.UnexpectedAttendees += arrived
+			continue
+		}
+		summary.ExpectedParties++
+		summary.ExpectedAttendees += g.TotalGuests
+		summary.RemainingAttendees += max(g.TotalGuests-arrived, 0)
+		summary.UnexpectedAttendees += max(arrived-g.TotalGuests, 0)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateService finds guests entered twice and merges them
+type DuplicateService struct {
+	Store repository.GuestMergeStore
+
+	// Guests reads the guests compared and publishes the changes a merge makes
+	Guests *GuestService
+}
+
+// NewDuplicateService initializes a new duplicate service
+func NewDuplicateService(store repository.GuestMergeStore, guests *GuestService) *DuplicateService {
+	return &DuplicateService{Store: store, Guests: guests}
+}
+
+// FindDuplicates compares every pair of guests and returns those scoring at
+// least minScore, or sharing an email or phone number, most alike first.
+// Each pair lists the guest added first first.
+func (s *DuplicateService) FindDuplicates(ctx context.Context, minScore float64) ([]models.DuplicateCandidate, error) {
+	if minScore <= 0 || minScore > 1 {
+		return nil, apperrors.New(apperrors.ErrValidation, "minimum score must be greater than 0 and at most 1")
+	}
+	guests, err := s.Guests.Repo.GetAllGuests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guests: %w", err)
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for i := range guests {
+		for j := i + 1; j < len(guests); j++ {
+			if candidate, ok := models.ScoreDuplicate(&guests[i], &guests[j], minScore); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b models.DuplicateCandidate) int { return cmp.Compare(b.Score, a.Score) })
+	return candidates, nil
+}
+
+// MergeGuests folds a duplicate guest into the primary one and deletes the
+// duplicate, recording both as they were. The primary keeps its name, email
+// and RSVP link and code; see models.Guest.Absorb for how the rest combine.
+// A non-zero version must match the guest's current one.
+func (s *DuplicateService) MergeGuests(ctx context.Context, primaryID, duplicateID uuid.UUID, primaryVersion, duplicateVersion int) (*models.Guest, *models.GuestMerge, error) {
+	if primaryID == duplicateID {
+		return nil, nil, apperrors.New(apperrors.ErrValidation, "a guest cannot be merged into itself")
+	}
+	primary, err := s.Guests.Repo.GetGuestByID(ctx, primaryID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch guest: %w", err)
+	}
+	duplicate, err := s.Guests.Repo.GetGuestByID(ctx, duplicateID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch duplicate guest: %w", err)
+	}
+	if (primaryVersion != 0 && primary.Version != primaryVersion) || (duplicateVersion != 0 && duplicate.Version != duplicateVersion) {
+		return nil, nil, repository.ErrVersionConflict
+	}
+
+	merge := &models.GuestMerge{
+		ID:          uuid.New(),
+		PrimaryID:   primaryID,
+		DuplicateID: duplicateID,
+		Primary:     *primary,
+		Duplicate:   *duplicate,
+		MergedAt:    time.Now().UTC(),
+		RequestID:   logging.RequestIDFromContext(ctx),
+	}
+	merged := *primary
+	merged.Tags = slices.Clone(primary.Tags)
+	merged.Absorb(duplicate)
+
+	// The versions just read guard against edits made since
+	if err := s.Store.MergeGuests(ctx, &merged, merge); err != nil {
+		return nil, nil, fmt.Errorf("failed to merge guests: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("guests merged", slog.String("guest_id", primaryID.String()),
+		slog.String("duplicate_id", duplicateID.String()), slog.String("merge_id", merge.ID.String()))
+	s.Guests.publishGuest(ctx, events.GuestUpdated, &merged)
+	s.Guests.publish(ctx, events.GuestDeleted, duplicateID, map[string]uuid.UUID{"id": duplicateID})
+	return &merged, merge, nil
+}
+
+// GetGuestMerges lists the merges made so far, newest first
+func (s *DuplicateService) GetGuestMerges(ctx context.Context) ([]models.GuestMerge, error) {
+	merges, err := s.Store.GetGuestMerges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guest merges: %w", err)
+	}
+	return merges, nil
+}
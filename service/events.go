@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// publish notifies event stream subscribers and queues deliveries to
+// subscribed webhooks. The change has already been saved, so a webhook
+// queueing failure is logged rather than failing the request.
+func (s *GuestService) publish(ctx context.Context, eventType string, guestID uuid.UUID, data any) {
+	if s.Events != nil {
+		s.Events.Publish(eventType, guestID, data)
+	}
+	if s.Webhooks != nil {
+		if err := s.Webhooks.Enqueue(ctx, eventType, data); err != nil {
+			logging.FromContext(ctx).Error("failed to queue webhook deliveries",
+				slog.String("event_type", eventType), slog.String("guest_id", guestID.String()), slog.Any("error", err))
+		}
+	}
+}
+
+// publishGuest publishes a snapshot of the guest. The RSVP token is left out:
+// it is only ever shown to the admin who issued it.
+func (s *GuestService) publishGuest(ctx context.Context, eventType string, guest *models.Guest) {
+	snapshot := *guest
+	snapshot.RSVPToken = ""
+	s.publish(ctx, eventType, guest.ID, &snapshot)
+}
+
+// publishCheckIn publishes a recorded check-in with the party's arrivals so far
+func (s *GuestService) publishCheckIn(ctx context.Context, result *models.CheckInResult) {
+	snapshot := *result
+	guest := *result.Guest
+	guest.RSVPToken = ""
+	snapshot.Guest = &guest
+	s.publish(ctx, events.GuestCheckedIn, guest.ID, &snapshot)
+}
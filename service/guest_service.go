@@ -1,150 +1,448 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/invitations"
+	"github.com/g4l1l10/rsvp-backend/logging"
 	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/qrcode"
 	"github.com/g4l1l10/rsvp-backend/repository"
-	"github.com/g4l1l10/rsvp-backend/utils"
+	"github.com/g4l1l10/rsvp-backend/tokens"
+	"github.com/g4l1l10/rsvp-backend/webhooks"
 
 	"github.com/google/uuid"
 )
 
+// maxCodeAttempts bounds retries when a freshly generated RSVP code collides
+const maxCodeAttempts = 5
+
+// Mailer delivers guest emails
+type Mailer interface {
+	SendInvitation(ctx context.Context, guestName, guestEmail, rsvpToken, lang string, ics []byte) error
+	SendReminder(ctx context.Context, guestName, guestEmail, rsvpToken, lang string) error
+}
+
 // GuestService defines business logic for guest management
 type GuestService struct {
-	Repo *repository.GuestRepository
+	Repo   repository.GuestStore
+	Mailer Mailer
+	Tokens *tokens.Signer
+
+	// AcceptLegacyTokens keeps plaintext tokens issued before links were signed working
+	AcceptLegacyTokens bool
+	// PublicURL is the guest-facing site that printed RSVP links point at
+	PublicURL string
+	// Events, when set, receives a notification for every guest change
+	Events *events.Broker
+	// Webhooks, when set, delivers guest events to registered endpoints
+	Webhooks *webhooks.Dispatcher
+	// Notifier, when set, emails confirmations and digests of RSVPs
+	Notifier *RSVPNotifier
+	// SubEvents, when set, lists the sub-events a guest is invited to on their
+	// RSVP page and calendar, and takes their answers for each
+	SubEvents *SubEventService
+	// Tags, when set, rejects guest filters naming tags that do not exist
+	Tags *TagService
+	// Wedding supplies the calendar entries offered to guests
+	Wedding config.WeddingConfig
 }
 
 // NewGuestService initializes a new guest service
-func NewGuestService(repo *repository.GuestRepository) *GuestService {
-	return &GuestService{Repo: repo}
+func NewGuestService(repo repository.GuestStore, mailer Mailer, signer *tokens.Signer) *GuestService {
+	return &GuestService{Repo: repo, Mailer: mailer, Tokens: signer}
 }
 
-// AddGuest validates input and creates a new guest
-// AddGuest validates input and creates a new guest
-func (s *GuestService) AddGuest(name, email, familySide string, totalGuests int) (*models.Guest, error) {
+// AddGuest validates input and creates a new guest; preferredLanguage may be
+// empty when the guest's language is not known
+func (s *GuestService) AddGuest(ctx context.Context, name, email, familySide string, totalGuests int, preferredLanguage string) (*models.Guest, error) {
 	// Validate inputs
 	if name == "" || email == "" || familySide == "" || totalGuests <= 0 {
-		return nil, errors.New("invalid input: all fields must be provided and total guests must be greater than zero")
+		return nil, apperrors.New(apperrors.ErrValidation, "invalid input: all fields must be provided and total guests must be greater than zero")
+	}
+	lang, err := models.NormalizeLanguage(preferredLanguage)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create a new guest with UUID and unique RSVP token
+	// Create a new guest with UUID and a signed RSVP link; only the link's hash is stored
 	guest := models.NewGuest(name, email, familySide, totalGuests)
-
-	// Store guest in database
-	err := s.Repo.CreateGuest(guest)
+	guest.PreferredLanguage = lang
+	issued, err := s.issueToken(guest.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add guest: %v", err)
+		return nil, err
 	}
+	guest.RSVPToken, guest.RSVPTokenHash = issued.Token, issued.SecretHash
 
-	log.Println("✅ Guest successfully added:", guest.Email)
+	// Store guest in database, drawing a new code if the random one is taken
+	err = s.Repo.CreateGuest(ctx, guest)
+	for attempt := 1; errors.Is(err, repository.ErrDuplicateCode) && attempt < maxCodeAttempts; attempt++ {
+		guest.RSVPCode = models.NewRSVPCode()
+		err = s.Repo.CreateGuest(ctx, guest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add guest: %w", err)
+	}
 
-	// ❌ Removed automatic email sending here
+	logging.FromContext(ctx).Info("guest created", slog.String("guest_id", guest.ID.String()), slog.String("email", guest.Email))
+	s.publishGuest(ctx, events.GuestCreated, guest)
 	return guest, nil
 }
 
 // SendInvitation sends an RSVP invitation email to the guest
-func (s *GuestService) SendInvitation(guest *models.Guest) error {
+func (s *GuestService) SendInvitation(ctx context.Context, guest *models.Guest) error {
 	if guest == nil {
-		return fmt.Errorf("guest cannot be nil")
+		return apperrors.New(apperrors.ErrValidation, "guest cannot be nil")
+	}
+
+	if s.Mailer == nil {
+		return apperrors.New(apperrors.ErrUpstream, "email delivery is not configured")
 	}
 
-	// Send the invitation email
-	err := utils.SendEmail(guest.Name, guest.Email, guest.RSVPToken)
+	// Send the invitation email, with the date to save when it is known
+	ics := s.attachableCalendar(ctx, guest, s.RSVPLink(guest.RSVPToken))
+	err := s.Mailer.SendInvitation(ctx, guest.Name, guest.Email, guest.RSVPToken, guest.PreferredLanguage, ics)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+		return apperrors.Wrap(apperrors.ErrUpstream, err, "failed to send invitation")
 	}
 
 	return nil
 }
 
 // GetAllGuests retrieves all guests
-func (s *GuestService) GetAllGuests() ([]models.Guest, error) {
-	guests, err := s.Repo.GetAllGuests()
+func (s *GuestService) GetAllGuests(ctx context.Context) ([]models.Guest, error) {
+	guests, err := s.Repo.GetAllGuests(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch guests: %v", err)
+		return nil, fmt.Errorf("failed to fetch guests: %w", err)
 	}
 	return guests, nil
 }
 
 // GetGuestByID retrieves a guest by UUID
-func (s *GuestService) GetGuestByID(id uuid.UUID) (*models.Guest, error) {
-	guest, err := s.Repo.GetGuestByID(id)
+func (s *GuestService) GetGuestByID(ctx context.Context, id uuid.UUID) (*models.Guest, error) {
+	guest, err := s.Repo.GetGuestByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving guest: %v", err)
+		return nil, fmt.Errorf("error retrieving guest: %w", err)
 	}
 	return guest, nil
 }
 
-// GetGuestByToken retrieves a guest by their RSVP token
-func (s *GuestService) GetGuestByToken(token string) (*models.Guest, error) {
-	guest, err := s.Repo.GetGuestByToken(token)
+// GetGuestByToken retrieves a guest by their RSVP token or short RSVP code
+func (s *GuestService) GetGuestByToken(ctx context.Context, token string) (*models.Guest, error) {
+	guest, err := s.lookupCredential(ctx, token)
 	if err != nil {
-		return nil, fmt.Errorf("invalid RSVP token: %v", err)
+		return nil, fmt.Errorf("error retrieving guest by token: %w", err)
 	}
 	return guest, nil
 }
 
+// lookupCredential finds the guest holding an RSVP token or a short code.
+// Codes are recognised by shape, so a guest may type one in any case. Signed
+// tokens are verified before the database is consulted, so forged and expired
+// links cost nothing but a hash.
+func (s *GuestService) lookupCredential(ctx context.Context, credential string) (*models.Guest, error) {
+	if code, ok := models.NormalizeRSVPCode(credential); ok {
+		return s.Repo.GetGuestByCode(ctx, code)
+	}
+
+	if tokens.LooksSigned(credential) && s.Tokens != nil {
+		claims, err := s.Tokens.Verify(credential)
+		if err != nil {
+			return nil, err
+		}
+		guest, err := s.Repo.GetGuestByID(ctx, claims.GuestID)
+		if errors.Is(err, repository.ErrGuestNotFound) {
+			return nil, repository.ErrInvalidToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		// A regenerated or revoked link no longer matches the stored hash
+		if !claims.MatchesHash(guest.RSVPTokenHash) {
+			return nil, repository.ErrInvalidToken
+		}
+		return guest, nil
+	}
+
+	// Legacy tokens are stored only as a hash, like the secrets of signed links
+	if _, err := uuid.Parse(credential); err == nil && s.AcceptLegacyTokens {
+		return s.Repo.GetGuestByLegacyTokenHash(ctx, tokens.HashLegacy(credential))
+	}
+	return nil, repository.ErrInvalidToken
+}
+
+// issueToken signs a new RSVP link for the guest
+func (s *GuestService) issueToken(id uuid.UUID) (tokens.Issued, error) {
+	if s.Tokens == nil {
+		return tokens.Issued{}, apperrors.Newf(apperrors.ErrInternal, "cannot issue RSVP link for guest %s: signing is not configured", id)
+	}
+	issued, err := s.Tokens.Issue(id)
+	if err != nil {
+		return tokens.Issued{}, apperrors.Wrap(apperrors.ErrInternal, err, "failed to issue RSVP token")
+	}
+	return issued, nil
+}
+
+// FindGuests retrieves the guests selected by filter
+func (s *GuestService) FindGuests(ctx context.Context, filter models.GuestFilter) ([]models.Guest, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if s.Tags != nil {
+		if err := s.Tags.checkTagNames(ctx, filter.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	guests, err := s.Repo.GetAllGuests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guests: %w", err)
+	}
+	selected := guests[:0]
+	for i := range guests {
+		if filter.Matches(&guests[i]) {
+			selected = append(selected, guests[i])
+		}
+	}
+	return selected, nil
+}
+
+// guestLink returns the RSVP page of a guest who signed in with credential,
+// preferring the printed code: it is short and does not expire
+func (s *GuestService) guestLink(guest *models.Guest, credential string) string {
+	if guest.RSVPCode != "" {
+		return s.RSVPLink(guest.RSVPCode)
+	}
+	return s.RSVPLink(credential)
+}
+
+// RSVPLink returns the public RSVP page for a token or short code
+func (s *GuestService) RSVPLink(credential string) string {
+	return strings.TrimSuffix(s.PublicURL, "/") + "/rsvp/" + url.PathEscape(credential)
+}
+
+// InvitationQRCode encodes the guest's printed RSVP link as a QR code.
+// Printed links carry the short code rather than a signed token, so printing
+// never invalidates the link in an invitation email.
+func (s *GuestService) InvitationQRCode(ctx context.Context, id uuid.UUID) (*qrcode.Code, error) {
+	guest, err := s.Repo.GetGuestByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving guest: %w", err)
+	}
+	if guest.RSVPCode == "" {
+		return nil, apperrors.New(apperrors.ErrConflict, "guest has no RSVP code; generate one before printing")
+	}
+	code, err := qrcode.Encode([]byte(s.RSVPLink(guest.RSVPCode)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return code, nil
+}
+
+// InvitationCards builds printable cards for the guests selected by filter.
+// Guests whose RSVP code was revoked cannot be printed and are counted in skipped.
+func (s *GuestService) InvitationCards(ctx context.Context, filter models.GuestFilter) (cards []invitations.Card, skipped int, err error) {
+	guests, err := s.FindGuests(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, guest := range guests {
+		if guest.RSVPCode == "" {
+			skipped++
+			continue
+		}
+		cards = append(cards, invitations.Card{
+			Name: guest.Name,
+			Code: models.FormatRSVPCode(guest.RSVPCode),
+			Link: s.RSVPLink(guest.RSVPCode),
+		})
+	}
+	if len(cards) == 0 {
+		return nil, skipped, apperrors.New(apperrors.ErrNotFound, "no printable guests match the filter")
+	}
+	return cards, skipped, nil
+}
+
 // GetGuestByEmail retrieves a guest by email
-func (s *GuestService) GetGuestByEmail(email string) (*models.Guest, error) {
-	guest, err := s.Repo.GetGuestByEmail(email)
+func (s *GuestService) GetGuestByEmail(ctx context.Context, email string) (*models.Guest, error) {
+	guest, err := s.Repo.GetGuestByEmail(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving guest by email: %v", err)
+		return nil, fmt.Errorf("error retrieving guest by email: %w", err)
 	}
 	return guest, nil
 }
 
 // GetGuestsByRSVP retrieves all guests with a specific RSVP status
-func (s *GuestService) GetGuestsByRSVP(status string) ([]models.Guest, error) {
-	guests, err := s.Repo.GetGuestByRSVP(status)
+func (s *GuestService) GetGuestsByRSVP(ctx context.Context, status string) ([]models.Guest, error) {
+	guests, err := s.Repo.GetGuestByRSVP(ctx, status)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving guests with RSVP status %s: %v", status, err)
+		return nil, fmt.Errorf("error retrieving guests with RSVP status %s: %w", status, err)
 	}
 	return guests, nil
 }
 
-// UpdateGuest updates an existing guest's details
-func (s *GuestService) UpdateGuest(guest *models.Guest) error {
+// GetRSVPSummary returns guest and attendee counts per RSVP status
+func (s *GuestService) GetRSVPSummary(ctx context.Context) ([]models.RSVPSummary, error) {
+	summary, err := s.Repo.GetRSVPSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error summarizing RSVPs: %w", err)
+	}
+	return summary, nil
+}
+
+// UpdateGuest replaces an existing guest's details
+func (s *GuestService) UpdateGuest(ctx context.Context, guest *models.Guest) error {
 	// Validate guest data before updating
 	if guest.ID == uuid.Nil {
-		return errors.New("invalid guest ID")
+		return apperrors.New(apperrors.ErrValidation, "invalid guest ID")
+	}
+	if err := guest.Validate(); err != nil {
+		return fmt.Errorf("invalid guest: %w", err)
 	}
 
-	err := s.Repo.UpdateGuest(guest)
+	err := s.Repo.UpdateGuest(ctx, guest)
 	if err != nil {
-		return fmt.Errorf("failed to update guest: %v", err)
+		return fmt.Errorf("failed to update guest: %w", err)
 	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
 	return nil
 }
 
-// DeleteGuest removes a guest from the system
-func (s *GuestService) DeleteGuest(id uuid.UUID) error {
-	err := s.Repo.DeleteGuest(id)
+// PatchGuest applies a partial update to an existing guest and returns the result.
+// A non-zero expectedVersion makes the update conditional on the stored version.
+func (s *GuestService) PatchGuest(ctx context.Context, id uuid.UUID, patch *models.GuestPatch, expectedVersion int) (*models.Guest, error) {
+	if id == uuid.Nil {
+		return nil, apperrors.New(apperrors.ErrValidation, "invalid guest ID")
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid guest: %w", err)
+	}
+
+	guest, err := s.Repo.PatchGuest(ctx, id, patch, expectedVersion)
 	if err != nil {
-		return fmt.Errorf("failed to delete guest: %v", err)
+		return nil, fmt.Errorf("failed to patch guest: %w", err)
 	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
+	return guest, nil
+}
+
+// DeleteGuest removes a guest from the system.
+// A non-zero expectedVersion makes the delete conditional on the stored version.
+func (s *GuestService) DeleteGuest(ctx context.Context, id uuid.UUID, expectedVersion int) error {
+	err := s.Repo.DeleteGuest(ctx, id, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to delete guest: %w", err)
+	}
+	s.publish(ctx, events.GuestDeleted, id, map[string]uuid.UUID{"id": id})
 	return nil
 }
 
 // UpdateRSVP updates a guest's RSVP status based on their RSVP token
-func (s *GuestService) UpdateRSVP(rsvpToken, rsvpStatus string, totalGuests int) error {
-	// Fetch guest using the RSVP token
-	guest, err := s.Repo.GetGuestByToken(rsvpToken)
-	if err != nil {
-		return fmt.Errorf("failed to fetch guest: %v", err)
+func (s *GuestService) UpdateRSVP(ctx context.Context, rsvpToken, rsvpStatus string, totalGuests int) error {
+	// Validate the submitted answer before touching the database
+	answer := models.GuestPatch{RSVPStatus: &rsvpStatus, TotalGuests: &totalGuests}
+	if err := answer.Validate(); err != nil {
+		return fmt.Errorf("invalid RSVP: %w", err)
 	}
 
-	// Update guest details
-	guest.RSVPStatus = rsvpStatus
-	guest.TotalGuests = totalGuests
+	// Fetch guest using the RSVP token or code
+	guest, err := s.lookupCredential(ctx, rsvpToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch guest: %w", err)
+	}
 
-	// Save updates to the database
-	err = s.Repo.UpdateGuest(guest)
+	// Only the answer is written, whatever the version: a guest has no way to
+	// resolve a conflict with an edit the couple made since the page loaded
+	previousStatus := guest.RSVPStatus
+	guest, err = s.Repo.PatchGuest(ctx, guest.ID, &answer, 0)
 	if err != nil {
-		return fmt.Errorf("failed to update RSVP: %v", err)
+		return fmt.Errorf("failed to update RSVP: %w", err)
 	}
+	s.publishGuest(ctx, events.GuestRSVP, guest)
+	s.notifyRSVP(ctx, guest, rsvpToken, previousStatus)
 
 	return nil
 }
+
+// notifyRSVP queues the guest's confirmation email and their entry in the
+// couple's digest, attaching the calendar when the guest is attending
+func (s *GuestService) notifyRSVP(ctx context.Context, guest *models.Guest, credential, previousStatus string) {
+	if s.Notifier == nil {
+		return
+	}
+	link := s.guestLink(guest, credential)
+	var ics []byte
+	if guest.RSVPStatus == models.RSVPStatusAttending {
+		ics = s.attachableCalendar(ctx, guest, link)
+	}
+	s.Notifier.Notify(ctx, models.RSVPResponse{
+		GuestID:        guest.ID,
+		Name:           guest.Name,
+		Email:          guest.Email,
+		RSVPStatus:     guest.RSVPStatus,
+		TotalGuests:    guest.TotalGuests,
+		PreviousStatus: previousStatus,
+		RespondedAt:    time.Now().UTC(),
+		Language:       guest.PreferredLanguage,
+	}, link, ics)
+}
+
+// RegenerateRSVPToken issues a new signed RSVP link, invalidating the old one.
+// The returned guest carries the new token; it cannot be retrieved again later.
+// A non-zero expectedVersion makes the change conditional on the stored version.
+func (s *GuestService) RegenerateRSVPToken(ctx context.Context, id uuid.UUID, expectedVersion int) (*models.Guest, error) {
+	issued, err := s.issueToken(id)
+	if err != nil {
+		return nil, err
+	}
+	guest, err := s.Repo.SetRSVPToken(ctx, id, issued.SecretHash, expectedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate RSVP token: %w", err)
+	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
+	guest.RSVPToken = issued.Token
+	return guest, nil
+}
+
+// RevokeRSVPToken removes a guest's RSVP token so links using it stop working
+func (s *GuestService) RevokeRSVPToken(ctx context.Context, id uuid.UUID, expectedVersion int) (*models.Guest, error) {
+	guest, err := s.Repo.SetRSVPToken(ctx, id, "", expectedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke RSVP token: %w", err)
+	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
+	return guest, nil
+}
+
+// RegenerateRSVPCode issues a new short RSVP code, invalidating the old one.
+// A non-zero expectedVersion makes the change conditional on the stored version.
+func (s *GuestService) RegenerateRSVPCode(ctx context.Context, id uuid.UUID, expectedVersion int) (*models.Guest, error) {
+	guest, err := s.Repo.SetRSVPCode(ctx, id, models.NewRSVPCode(), expectedVersion)
+	for attempt := 1; errors.Is(err, repository.ErrDuplicateCode) && attempt < maxCodeAttempts; attempt++ {
+		guest, err = s.Repo.SetRSVPCode(ctx, id, models.NewRSVPCode(), expectedVersion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate RSVP code: %w", err)
+	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
+	return guest, nil
+}
+
+// RevokeRSVPCode removes a guest's short RSVP code so it can no longer be used
+func (s *GuestService) RevokeRSVPCode(ctx context.Context, id uuid.UUID, expectedVersion int) (*models.Guest, error) {
+	guest, err := s.Repo.SetRSVPCode(ctx, id, "", expectedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke RSVP code: %w", err)
+	}
+	s.publishGuest(ctx, events.GuestUpdated, guest)
+	return guest, nil
+}
@@ -0,0 +1,1060 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/i18n"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+	"github.com/g4l1l10/rsvp-backend/tokens"
+
+	"github.com/google/uuid"
+)
+
+// ctx is the background context shared by tests that do not exercise cancellation
+var ctx = context.Background()
+
+// newTestSigner signs RSVP links with a fixed test key
+func newTestSigner(t *testing.T) *tokens.Signer {
+	t.Helper()
+	signer, err := tokens.NewSigner([]tokens.Key{{ID: "test", Secret: []byte(strings.Repeat("k", tokens.MinKeyLength))}}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return signer
+}
+
+func newTestService(t *testing.T) (*GuestService, *models.Guest) {
+	t.Helper()
+	repo := repository.NewMemoryGuestRepository()
+	svc := NewGuestService(repo, nil, newTestSigner(t))
+	svc.SubEvents = NewSubEventService(repo, svc)
+	svc.Tags = NewTagService(repo, svc)
+	svc.PublicURL = "https://axeldaphne.com"
+	guest, err := svc.AddGuest(ctx, "Aunt May", "may@example.com", "Bride", 2, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	return svc, guest
+}
+
+func TestAddGuest(t *testing.T) {
+	tests := []struct {
+		name        string
+		guestName   string
+		email       string
+		familySide  string
+		totalGuests int
+		language    string
+		wantErr     bool
+	}{
+		{"valid guest", "Ben", "ben@example.com", "Groom", 1, "", false},
+		{"preferred language", "Ben", "ben@example.com", "Groom", 1, "zh-CN", false},
+		{"missing name", "", "ben@example.com", "Groom", 1, "", true},
+		{"missing email", "Ben", "", "Groom", 1, "", true},
+		{"missing family side", "Ben", "ben@example.com", "", 1, "", true},
+		{"zero guests", "Ben", "ben@example.com", "Groom", 0, "", true},
+		{"unsupported language", "Ben", "ben@example.com", "Groom", 1, "fr", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewGuestService(repository.NewMemoryGuestRepository(), nil, newTestSigner(t))
+			guest, err := svc.AddGuest(ctx, tt.guestName, tt.email, tt.familySide, tt.totalGuests, tt.language)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddGuest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if guest.RSVPStatus != models.RSVPStatusPending || guest.RSVPToken == "" || guest.Version != 1 {
+				t.Errorf("unexpected new guest: %+v", guest)
+			}
+			if tt.language != "" && guest.PreferredLanguage != i18n.Mandarin {
+				t.Errorf("preferred language = %q, want it normalized to %q", guest.PreferredLanguage, i18n.Mandarin)
+			}
+		})
+	}
+}
+
+func TestUpdateGuest(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(g *models.Guest)
+		wantErr error
+		invalid bool
+	}{
+		{"full replacement", func(g *models.Guest) { g.FamilySide = ""; g.Hongbao = 0 }, nil, false},
+		{"unconditional", func(g *models.Guest) { g.Version = 0 }, nil, false},
+		{"stale version", func(g *models.Guest) { g.Version = 42 }, repository.ErrVersionConflict, false},
+		{"unknown guest", func(g *models.Guest) { g.ID = uuid.New() }, repository.ErrGuestNotFound, false},
+		{"invalid status", func(g *models.Guest) { g.RSVPStatus = "Maybe" }, nil, true},
+		{"negative hongbao", func(g *models.Guest) { g.Hongbao = -1 }, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, guest := newTestService(t)
+			update := *guest
+			tt.mutate(&update)
+
+			err := svc.UpdateGuest(ctx, &update)
+			switch {
+			case tt.invalid:
+				if err == nil {
+					t.Fatal("expected validation error")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateGuest() error = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("UpdateGuest() error = %v", err)
+				}
+				stored, _ := svc.GetGuestByID(ctx, guest.ID)
+				if stored.FamilySide != update.FamilySide || stored.Version != guest.Version+1 {
+					t.Errorf("stored guest not replaced: %+v", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchGuest(t *testing.T) {
+	empty := ""
+	attending := models.RSVPStatusAttending
+	zero := 0
+
+	tests := []struct {
+		name    string
+		patch   models.GuestPatch
+		version func(g *models.Guest) int
+		check   func(t *testing.T, g *models.Guest)
+		wantErr error
+		invalid bool
+	}{
+		{
+			name:    "clears optional field",
+			patch:   models.GuestPatch{FamilySide: &empty},
+			version: func(g *models.Guest) int { return g.Version },
+			check: func(t *testing.T, g *models.Guest) {
+				if g.FamilySide != "" || g.Name != "Aunt May" || g.TotalGuests != 2 {
+					t.Errorf("unexpected guest after patch: %+v", g)
+				}
+			},
+		},
+		{
+			name:    "updates status only",
+			patch:   models.GuestPatch{RSVPStatus: &attending},
+			version: func(g *models.Guest) int { return 0 },
+			check: func(t *testing.T, g *models.Guest) {
+				if g.RSVPStatus != attending || g.FamilySide != "Bride" {
+					t.Errorf("unexpected guest after patch: %+v", g)
+				}
+			},
+		},
+		{
+			name:    "stale version",
+			patch:   models.GuestPatch{RSVPStatus: &attending},
+			version: func(g *models.Guest) int { return g.Version + 1 },
+			wantErr: repository.ErrVersionConflict,
+		},
+		{
+			name:    "invalid total guests",
+			patch:   models.GuestPatch{TotalGuests: &zero},
+			version: func(g *models.Guest) int { return g.Version },
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, guest := newTestService(t)
+
+			patched, err := svc.PatchGuest(ctx, guest.ID, &tt.patch, tt.version(guest))
+			switch {
+			case tt.invalid:
+				if err == nil {
+					t.Fatal("expected validation error")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PatchGuest() error = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("PatchGuest() error = %v", err)
+				}
+				if patched.Version != guest.Version+1 {
+					t.Errorf("version = %d, want %d", patched.Version, guest.Version+1)
+				}
+				tt.check(t, patched)
+			}
+		})
+	}
+}
+
+func TestDeleteGuest(t *testing.T) {
+	svc, guest := newTestService(t)
+
+	if err := svc.DeleteGuest(ctx, guest.ID, guest.Version+1); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("DeleteGuest() with stale version error = %v", err)
+	}
+	if err := svc.DeleteGuest(ctx, guest.ID, guest.Version); err != nil {
+		t.Fatalf("DeleteGuest() error = %v", err)
+	}
+	if err := svc.DeleteGuest(ctx, guest.ID, 0); !errors.Is(err, repository.ErrGuestNotFound) {
+		t.Fatalf("DeleteGuest() of missing guest error = %v", err)
+	}
+}
+
+func TestUpdateRSVP(t *testing.T) {
+	svc, guest := newTestService(t)
+
+	if err := svc.UpdateRSVP(ctx, guest.RSVPToken, models.RSVPStatusAttending, 3); err != nil {
+		t.Fatalf("UpdateRSVP() error = %v", err)
+	}
+	stored, _ := svc.GetGuestByID(ctx, guest.ID)
+	if stored.RSVPStatus != models.RSVPStatusAttending || stored.TotalGuests != 3 {
+		t.Errorf("RSVP not recorded: %+v", stored)
+	}
+
+	if err := svc.UpdateRSVP(ctx, "not-a-token", models.RSVPStatusAttending, 1); err == nil {
+		t.Error("expected error for unknown token")
+	}
+}
+
+func TestRSVPCodes(t *testing.T) {
+	svc, guest := newTestService(t)
+
+	if _, ok := models.NormalizeRSVPCode(guest.RSVPCode); !ok {
+		t.Fatalf("generated code %q is not a valid code", guest.RSVPCode)
+	}
+
+	// Guests may type the code in lower case with grouping hyphens
+	typed := strings.ToLower(guest.RSVPCode[:4] + "-" + guest.RSVPCode[4:])
+	found, err := svc.GetGuestByToken(ctx, typed)
+	if err != nil || found.ID != guest.ID {
+		t.Fatalf("GetGuestByToken(%q) = %v, %v", typed, found, err)
+	}
+	if err := svc.UpdateRSVP(ctx, typed, models.RSVPStatusAttending, 2); err != nil {
+		t.Fatalf("UpdateRSVP by code: %v", err)
+	}
+
+	regenerated, err := svc.RegenerateRSVPCode(ctx, guest.ID, 0)
+	if err != nil {
+		t.Fatalf("RegenerateRSVPCode: %v", err)
+	}
+	if regenerated.RSVPCode == guest.RSVPCode {
+		t.Errorf("code not regenerated: %+v", regenerated)
+	}
+	if _, err := svc.GetGuestByToken(ctx, guest.RSVPToken); err != nil {
+		t.Errorf("token stopped working after code regeneration: %v", err)
+	}
+	if _, err := svc.GetGuestByToken(ctx, guest.RSVPCode); !errors.Is(err, repository.ErrInvalidToken) {
+		t.Errorf("old code still works: %v", err)
+	}
+
+	revoked, err := svc.RevokeRSVPToken(ctx, guest.ID, regenerated.Version)
+	if err != nil {
+		t.Fatalf("RevokeRSVPToken: %v", err)
+	}
+	if revoked.RSVPToken != "" {
+		t.Errorf("token not revoked: %q", revoked.RSVPToken)
+	}
+	if _, err := svc.GetGuestByToken(ctx, guest.RSVPToken); !errors.Is(err, repository.ErrInvalidToken) {
+		t.Errorf("revoked token still works: %v", err)
+	}
+	if _, err := svc.GetGuestByToken(ctx, regenerated.RSVPCode); err != nil {
+		t.Errorf("code stopped working after token revocation: %v", err)
+	}
+
+	if _, err := svc.RevokeRSVPCode(ctx, guest.ID, regenerated.Version); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Errorf("stale version: err = %v, want version conflict", err)
+	}
+}
+
+func TestSignedRSVPTokens(t *testing.T) {
+	svc, guest := newTestService(t)
+
+	if !tokens.LooksSigned(guest.RSVPToken) {
+		t.Fatalf("AddGuest issued an unsigned token %q", guest.RSVPToken)
+	}
+	stored, _ := svc.GetGuestByID(ctx, guest.ID)
+	if stored.RSVPToken != "" || stored.RSVPTokenHash == "" || strings.Contains(guest.RSVPToken, stored.RSVPTokenHash) {
+		t.Errorf("stored guest must keep only the token hash: %+v", stored)
+	}
+
+	regenerated, err := svc.RegenerateRSVPToken(ctx, guest.ID, 0)
+	if err != nil {
+		t.Fatalf("RegenerateRSVPToken: %v", err)
+	}
+	if _, err := svc.GetGuestByToken(ctx, guest.RSVPToken); !errors.Is(err, repository.ErrInvalidToken) {
+		t.Errorf("replaced token: err = %v, want invalid token", err)
+	}
+	if found, err := svc.GetGuestByToken(ctx, regenerated.RSVPToken); err != nil || found.ID != guest.ID {
+		t.Errorf("regenerated token: %v, %v", found, err)
+	}
+
+	// A token for a guest signed by another key never reaches the database
+	foreign, _ := tokens.NewSigner([]tokens.Key{{ID: "test", Secret: []byte(strings.Repeat("x", tokens.MinKeyLength))}}, time.Hour)
+	forged, _ := foreign.Issue(guest.ID)
+	if _, err := svc.GetGuestByToken(ctx, forged.Token); !errors.Is(err, tokens.ErrInvalid) {
+		t.Errorf("forged token: err = %v, want invalid", err)
+	}
+
+	// Plaintext tokens from before signing are honoured only while enabled
+	legacyToken := uuid.NewString()
+	legacy := models.NewGuest("Uncle Ben", "ben@example.com", "Groom", 1)
+	legacy.LegacyRSVPTokenHash = tokens.HashLegacy(legacyToken)
+	if err := svc.Repo.CreateGuest(ctx, legacy); err != nil {
+		t.Fatalf("CreateGuest: %v", err)
+	}
+	if _, err := svc.GetGuestByToken(ctx, legacyToken); !errors.Is(err, repository.ErrInvalidToken) {
+		t.Errorf("legacy token while disabled: err = %v, want invalid token", err)
+	}
+	svc.AcceptLegacyTokens = true
+	if found, err := svc.GetGuestByToken(ctx, legacyToken); err != nil || found.ID != legacy.ID {
+		t.Errorf("legacy token while enabled: %v, %v", found, err)
+	}
+	if _, err := svc.GetGuestByToken(ctx, legacy.LegacyRSVPTokenHash); !errors.Is(err, repository.ErrInvalidToken) {
+		t.Errorf("the stored hash must not work as a token: err = %v", err)
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	svc, guest := newTestService(t)
+	checkIns := NewCheckInService(svc.Repo.(repository.CheckInStore), svc)
+	warningCodes := func(result *models.CheckInResult) []string {
+		var codes []string
+		for _, w := range result.Warnings {
+			codes = append(codes, w.Code)
+		}
+		return codes
+	}
+
+	// One of two arrives before the guest ever replied
+	result, err := checkIns.CheckIn(ctx, CheckInRequest{Credential: guest.RSVPCode, Arrived: 1})
+	if err != nil {
+		t.Fatalf("CheckIn by code: %v", err)
+	}
+	if result.Arrived != 1 || result.Expected != 2 || !slices.Equal(warningCodes(result), []string{models.CheckInWarningRSVPPending}) {
+		t.Errorf("first check-in: %+v", result)
+	}
+
+	// Scanning the printed QR code checks in the rest of the party
+	if err := svc.UpdateRSVP(ctx, guest.RSVPToken, models.RSVPStatusAttending, 2); err != nil {
+		t.Fatalf("UpdateRSVP: %v", err)
+	}
+	result, err = checkIns.CheckIn(ctx, CheckInRequest{Credential: " " + svc.RSVPLink(guest.RSVPCode) + " "})
+	if err != nil {
+		t.Fatalf("CheckIn by scanned link: %v", err)
+	}
+	if result.CheckIn.Arrived != 1 || result.Arrived != 2 || !slices.Equal(warningCodes(result), []string{models.CheckInWarningAlreadyCheckedIn}) {
+		t.Errorf("second check-in: %+v", result)
+	}
+
+	// A double scan is still recorded, with a warning for door staff
+	result, err = checkIns.CheckIn(ctx, CheckInRequest{GuestID: guest.ID})
+	if err != nil || result.Arrived != 3 || !slices.Contains(warningCodes(result), models.CheckInWarningExceedsParty) {
+		t.Errorf("duplicate check-in: %+v, %v", result, err)
+	}
+
+	summary, err := checkIns.GetCheckInSummary(ctx)
+	if err != nil {
+		t.Fatalf("GetCheckInSummary: %v", err)
+	}
+	if summary.ExpectedAttendees != 2 || summary.ArrivedAttendees != 3 || summary.ArrivedParties != 1 ||
+		summary.RemainingAttendees != 0 || summary.UnexpectedAttendees != 1 || summary.LastCheckInAt == nil {
+		t.Errorf("summary: %+v", summary)
+	}
+
+	for name, req := range map[string]CheckInRequest{
+		"nothing":        {},
+		"both":           {Credential: guest.RSVPCode, GuestID: guest.ID},
+		"negative count": {GuestID: guest.ID, Arrived: -1},
+	} {
+		if _, err := checkIns.CheckIn(ctx, req); !errors.Is(err, apperrors.ErrValidation) {
+			t.Errorf("%s: err = %v, want validation error", name, err)
+		}
+	}
+	if _, err := checkIns.CheckIn(ctx, CheckInRequest{GuestID: uuid.New()}); !errors.Is(err, repository.ErrGuestNotFound) {
+		t.Errorf("unknown guest: err = %v", err)
+	}
+
+	// Deleting the guest removes their arrivals
+	if err := svc.DeleteGuest(ctx, guest.ID, 0); err != nil {
+		t.Fatalf("DeleteGuest: %v", err)
+	}
+	if summary, _ := checkIns.GetCheckInSummary(ctx); summary.ArrivedAttendees != 0 {
+		t.Errorf("check-ins survived guest deletion: %+v", summary)
+	}
+}
+
+// fakeRSVPMailer records notification emails instead of sending them
+type fakeRSVPMailer struct {
+	mu            sync.Mutex
+	confirmations []string // change links
+	calendars     [][]byte // attached to confirmations
+	digests       [][]models.RSVPResponse
+	confirmErr    error
+	digestErr     error
+}
+
+func (m *fakeRSVPMailer) SendRSVPConfirmation(_ context.Context, _ models.RSVPResponse, changeLink string, ics []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.confirmErr != nil {
+		return m.confirmErr
+	}
+	m.confirmations = append(m.confirmations, changeLink)
+	m.calendars = append(m.calendars, ics)
+	return nil
+}
+
+func (m *fakeRSVPMailer) SendRSVPDigest(_ context.Context, _ []string, responses []models.RSVPResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.digestErr != nil {
+		return m.digestErr
+	}
+	m.digests = append(m.digests, responses)
+	return nil
+}
+
+func TestRSVPNotifications(t *testing.T) {
+	svc, guest := newTestService(t)
+	mailer := &fakeRSVPMailer{digestErr: errors.New("mail server down")}
+	svc.Notifier = NewRSVPNotifier(mailer, config.NotificationConfig{
+		GuestConfirmation: true,
+		DigestRecipients:  []string{"couple@example.com"},
+		DigestInterval:    time.Hour,
+	})
+
+	// The guest answers, then changes their mind before the digest goes out
+	for _, status := range []string{models.RSVPStatusAttending, models.RSVPStatusNotAttending} {
+		if err := svc.UpdateRSVP(ctx, guest.RSVPToken, status, 2); err != nil {
+			t.Fatalf("UpdateRSVP: %v", err)
+		}
+	}
+
+	// A failed digest keeps its responses for the next one
+	if err := svc.Notifier.SendDigest(ctx); err == nil {
+		t.Fatal("SendDigest succeeded with a failing mail server")
+	}
+	mailer.digestErr = nil
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		svc.Notifier.Run(runCtx)
+		close(done)
+	}()
+	stop()
+	<-done
+
+	if want := "https://axeldaphne.com/rsvp/" + guest.RSVPCode; len(mailer.confirmations) != 2 || mailer.confirmations[0] != want {
+		t.Errorf("confirmations = %v, want two linking to %s", mailer.confirmations, want)
+	}
+	if len(mailer.digests) != 1 || len(mailer.digests[0]) != 1 {
+		t.Fatalf("digests = %+v, want one listing the guest once", mailer.digests)
+	}
+	response := mailer.digests[0][0]
+	if response.RSVPStatus != models.RSVPStatusNotAttending || response.PreviousStatus != models.RSVPStatusPending || response.Changed() {
+		t.Errorf("digest response = %+v", response)
+	}
+
+	// A confirmation that cannot be sent is logged against the guest
+	var logs bytes.Buffer
+	logCtx := logging.WithLogger(ctx, slog.New(slog.NewTextHandler(&logs, nil)))
+	mailer.confirmErr = errors.New("mailbox full")
+	svc.Notifier.sendConfirmation(logCtx, confirmation{response: models.RSVPResponse{GuestID: guest.ID}})
+	if !strings.Contains(logs.String(), "guest_id="+guest.ID.String()) || !strings.Contains(logs.String(), "mailbox full") {
+		t.Errorf("failed confirmation not logged with the guest ID: %s", logs.String())
+	}
+}
+
+func TestGuestCalendar(t *testing.T) {
+	svc, guest := newTestService(t)
+	if _, err := svc.GuestCalendar(ctx, guest.RSVPCode); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("calendar before the date is set: err = %v, want not found", err)
+	}
+
+	svc.Wedding = config.WeddingConfig{
+		Title:   "Axel & Daphne's Wedding",
+		Start:   time.Date(2026, 6, 20, 8, 0, 0, 0, time.UTC),
+		End:     time.Date(2026, 6, 20, 15, 0, 0, 0, time.UTC),
+		Venue:   "Hotel Mulia",
+		Address: "Jl. Asia Afrika, Jakarta",
+	}
+	ics, err := svc.GuestCalendar(ctx, guest.RSVPToken)
+	if err != nil {
+		t.Fatalf("GuestCalendar: %v", err)
+	}
+	for _, want := range []string{
+		"UID:wedding@axeldaphne.com",
+		"DTSTART:20260620T080000Z",
+		`LOCATION:Hotel Mulia\, Jl. Asia Afrika\, Jakarta`,
+		"URL:https://axeldaphne.com/rsvp/" + guest.RSVPCode,
+	} {
+		if !strings.Contains(string(ics), want+"\r\n") {
+			t.Errorf("calendar lacks %q:\n%s", want, ics)
+		}
+	}
+
+	// Only guests who are coming get the calendar with their confirmation
+	mailer := &fakeRSVPMailer{}
+	svc.Notifier = NewRSVPNotifier(mailer, config.NotificationConfig{GuestConfirmation: true, DigestInterval: time.Hour})
+	svc.UpdateRSVP(ctx, guest.RSVPCode, models.RSVPStatusAttending, 2)
+	svc.UpdateRSVP(ctx, guest.RSVPCode, models.RSVPStatusNotAttending, 2)
+	svc.Notifier.flush()
+	if len(mailer.calendars) != 2 || len(mailer.calendars[0]) == 0 || mailer.calendars[1] != nil {
+		t.Errorf("attached calendars = %q, want one for the attending answer only", mailer.calendars)
+	}
+
+	if _, err := svc.GuestCalendar(ctx, guest.RSVPCode); !errors.Is(err, apperrors.ErrConflict) {
+		t.Errorf("calendar after declining: err = %v, want conflict", err)
+	}
+}
+
+func TestSubEvents(t *testing.T) {
+	svc, guest := newTestService(t)
+	other, err := svc.AddGuest(ctx, "Uncle Ben", "ben@example.com", "Groom", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	tea := &models.SubEvent{Name: "Tea ceremony", StartsAt: time.Date(2026, 6, 20, 2, 0, 0, 0, time.UTC), EndsAt: time.Date(2026, 6, 20, 4, 0, 0, 0, time.UTC)}
+	banquet := &models.SubEvent{Name: "Banquet", Venue: "Hotel Mulia", StartsAt: time.Date(2026, 6, 20, 11, 0, 0, 0, time.UTC), EndsAt: time.Date(2026, 6, 20, 15, 0, 0, 0, time.UTC)}
+	for _, e := range []*models.SubEvent{banquet, tea} {
+		if err := svc.SubEvents.CreateSubEvent(ctx, e); err != nil {
+			t.Fatalf("CreateSubEvent: %v", err)
+		}
+	}
+	if err := svc.SubEvents.CreateSubEvent(ctx, &models.SubEvent{Name: "Backwards", StartsAt: tea.EndsAt, EndsAt: tea.StartsAt}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("sub-event ending before it starts: err = %v, want validation error", err)
+	}
+
+	// Only family comes to the tea ceremony; everyone comes to the banquet
+	if invited, _, err := svc.SubEvents.ChangeInvitations(ctx, tea.ID, []uuid.UUID{guest.ID}, nil); err != nil || invited != 1 {
+		t.Fatalf("invite to tea: invited = %d, err = %v", invited, err)
+	}
+	if invited, _, err := svc.SubEvents.ChangeInvitations(ctx, banquet.ID, []uuid.UUID{guest.ID, other.ID}, nil); err != nil || invited != 2 {
+		t.Fatalf("invite to banquet: invited = %d, err = %v", invited, err)
+	}
+	if _, _, err := svc.SubEvents.ChangeInvitations(ctx, tea.ID, []uuid.UUID{uuid.New()}, nil); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("inviting an unknown guest: err = %v, want not found", err)
+	}
+
+	subEvents, err := svc.SubEvents.GuestSubEvents(ctx, other.RSVPCode, "", "")
+	if err != nil || len(subEvents) != 1 || subEvents[0].ID != banquet.ID {
+		t.Fatalf("GuestSubEvents = %+v, %v; want the banquet only", subEvents, err)
+	}
+	if err := svc.SubEvents.RespondToSubEvents(ctx, other.RSVPCode, []models.SubEventAnswer{{SubEventID: tea.ID, RSVPStatus: models.RSVPStatusAttending, Attendees: 1}}); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("answering for an event the guest is not invited to: err = %v, want forbidden", err)
+	}
+
+	// Attending any sub-event makes the guest attending, with their largest party
+	err = svc.SubEvents.RespondToSubEvents(ctx, guest.RSVPCode, []models.SubEventAnswer{
+		{SubEventID: tea.ID, RSVPStatus: models.RSVPStatusAttending, Attendees: 1},
+		{SubEventID: banquet.ID, RSVPStatus: models.RSVPStatusAttending, Attendees: 3},
+	})
+	if err != nil {
+		t.Fatalf("RespondToSubEvents: %v", err)
+	}
+	if got, _ := svc.GetGuestByID(ctx, guest.ID); got.RSVPStatus != models.RSVPStatusAttending || got.TotalGuests != 3 {
+		t.Errorf("guest after attending = %s with %d, want Attending with 3", got.RSVPStatus, got.TotalGuests)
+	}
+
+	// Declining every sub-event makes the guest not attending
+	err = svc.SubEvents.RespondToSubEvents(ctx, other.RSVPCode, []models.SubEventAnswer{{SubEventID: banquet.ID, RSVPStatus: models.RSVPStatusNotAttending}})
+	if err != nil {
+		t.Fatalf("RespondToSubEvents: %v", err)
+	}
+	if got, _ := svc.GetGuestByID(ctx, other.ID); got.RSVPStatus != models.RSVPStatusNotAttending {
+		t.Errorf("guest after declining = %s, want Not Attending", got.RSVPStatus)
+	}
+
+	summary, err := svc.SubEvents.GetSubEventSummary(ctx)
+	if err != nil {
+		t.Fatalf("GetSubEventSummary: %v", err)
+	}
+	want := []models.SubEventSummary{
+		{SubEventID: tea.ID, Name: "Tea ceremony", Invited: 1, Attending: 1, ExpectedAttendees: 1},
+		{SubEventID: banquet.ID, Name: "Banquet", Invited: 2, Attending: 1, NotAttending: 1, ExpectedAttendees: 3},
+	}
+	if !slices.Equal(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+
+	matrix, err := svc.SubEvents.InvitationMatrix(ctx)
+	if err != nil {
+		t.Fatalf("InvitationMatrix: %v", err)
+	}
+	if len(matrix.Guests) != 2 || len(matrix.Guests[0].Invitations) != 2 || matrix.Guests[1].Invitations[tea.ID] != "" {
+		t.Errorf("matrix = %+v", matrix)
+	}
+
+	// The calendar lists the sub-events the guest is going to, without a configured wedding date
+	ics, err := svc.GuestCalendar(ctx, guest.RSVPCode)
+	if err != nil {
+		t.Fatalf("GuestCalendar: %v", err)
+	}
+	for _, want := range []string{"UID:" + tea.ID.String() + "@axeldaphne.com", "SUMMARY:Banquet", "LOCATION:Hotel Mulia"} {
+		if !strings.Contains(string(ics), want+"\r\n") {
+			t.Errorf("calendar lacks %q:\n%s", want, ics)
+		}
+	}
+
+	// Removing a sub-event or a guest takes their invitations with them
+	if err := svc.SubEvents.DeleteSubEvent(ctx, tea.ID); err != nil {
+		t.Fatalf("DeleteSubEvent: %v", err)
+	}
+	if err := svc.DeleteGuest(ctx, other.ID, 0); err != nil {
+		t.Fatalf("DeleteGuest: %v", err)
+	}
+	invitations, err := svc.SubEvents.Store.GetAllInvitations(ctx)
+	if err != nil || len(invitations) != 1 || invitations[0].GuestID != guest.ID || invitations[0].SubEventID != banquet.ID {
+		t.Errorf("invitations left = %+v, %v; want the guest's banquet invitation only", invitations, err)
+	}
+}
+
+func TestRSVPPageLanguage(t *testing.T) {
+	svc, guest := newTestService(t)
+	tea := &models.SubEvent{
+		Name:         "Tea ceremony",
+		StartsAt:     time.Date(2026, 6, 20, 2, 0, 0, 0, time.UTC),
+		EndsAt:       time.Date(2026, 6, 20, 4, 0, 0, 0, time.UTC),
+		Translations: map[string]models.SubEventText{"zh-CN": {Name: "敬茶仪式"}},
+	}
+	if err := svc.SubEvents.CreateSubEvent(ctx, tea); err != nil {
+		t.Fatalf("CreateSubEvent: %v", err)
+	}
+	if _, ok := tea.Translations[i18n.Mandarin]; !ok {
+		t.Errorf("translations = %v, want them keyed by %q", tea.Translations, i18n.Mandarin)
+	}
+	if _, _, err := svc.SubEvents.ChangeInvitations(ctx, tea.ID, []uuid.UUID{guest.ID}, nil); err != nil {
+		t.Fatalf("ChangeInvitations: %v", err)
+	}
+
+	tests := []struct {
+		name, preferred, requested, acceptLanguage string
+		want                                       string
+	}{
+		{"nothing known", "", "", "", i18n.English},
+		{"browser", "", "", "fr, zh-TW;q=0.8", i18n.Mandarin},
+		{"preference beats browser", "id", "", "zh", i18n.Indonesian},
+		{"explicit request beats preference", "id", "en", "zh", i18n.English},
+		{"unsupported request is ignored", "", "fr", "id", i18n.Indonesian},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang := tt.preferred
+			if _, err := svc.PatchGuest(ctx, guest.ID, &models.GuestPatch{PreferredLanguage: &lang}, 0); err != nil {
+				t.Fatalf("PatchGuest: %v", err)
+			}
+			page, err := svc.RSVPPage(ctx, guest.RSVPCode, tt.requested, tt.acceptLanguage)
+			if err != nil {
+				t.Fatalf("RSVPPage: %v", err)
+			}
+			if page.Language != tt.want || page.Labels["rsvp.submit"] != i18n.T(tt.want, "rsvp.submit") {
+				t.Errorf("language = %q, submit label = %q; want %q", page.Language, page.Labels["rsvp.submit"], tt.want)
+			}
+			wantName := "Tea ceremony"
+			if tt.want == i18n.Mandarin {
+				wantName = "敬茶仪式"
+			}
+			if len(page.SubEvents) != 1 || page.SubEvents[0].Name != wantName {
+				t.Errorf("sub-events = %+v, want %q", page.SubEvents, wantName)
+			}
+		})
+	}
+
+	bad := "klingon"
+	if _, err := svc.PatchGuest(ctx, guest.ID, &models.GuestPatch{PreferredLanguage: &bad}, 0); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("unsupported preferred language: err = %v, want validation error", err)
+	}
+}
+
+// fakeGuestMailer records invitations and reminders instead of sending them
+type fakeGuestMailer struct {
+	mu          sync.Mutex
+	invitations []string // emails invited
+	reminders   []string // emails reminded
+	failFor     string   // email whose messages fail
+}
+
+func (m *fakeGuestMailer) SendInvitation(_ context.Context, _, email, _, _ string, _ []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if email == m.failFor {
+		return errors.New("mailbox full")
+	}
+	m.invitations = append(m.invitations, email)
+	return nil
+}
+
+func (m *fakeGuestMailer) SendReminder(_ context.Context, _, email, token, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if email == m.failFor || token == "" {
+		return errors.New("mailbox full")
+	}
+	m.reminders = append(m.reminders, email)
+	return nil
+}
+
+func TestTagsAndBulkActions(t *testing.T) {
+	svc, guest := newTestService(t)
+	mailer := &fakeGuestMailer{failFor: "ben@example.com"}
+	svc.Mailer = mailer
+	ben, err := svc.AddGuest(ctx, "Uncle Ben", "ben@example.com", "Groom", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	jo, err := svc.AddGuest(ctx, "Jo", "jo@example.com", "Bride", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	attending, groom, email := models.RSVPStatusAttending, "Groom", "x@example.com"
+	if _, err := svc.PatchGuest(ctx, jo.ID, &models.GuestPatch{RSVPStatus: &attending}, 0); err != nil {
+		t.Fatalf("PatchGuest: %v", err)
+	}
+
+	overseas, err := svc.Tags.CreateTag(ctx, "  Overseas  ")
+	if err != nil || overseas.Name != "Overseas" {
+		t.Fatalf("CreateTag = %+v, %v", overseas, err)
+	}
+	if _, err := svc.Tags.CreateTag(ctx, "overseas"); !errors.Is(err, apperrors.ErrConflict) {
+		t.Errorf("duplicate tag name in another case: err = %v, want conflict", err)
+	}
+	vip, err := svc.Tags.CreateTag(ctx, "VIP")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+
+	if added, _, err := svc.Tags.ChangeTagging(ctx, overseas.ID, []uuid.UUID{guest.ID, ben.ID, jo.ID}, nil); err != nil || added != 3 {
+		t.Fatalf("tag overseas: added = %d, err = %v", added, err)
+	}
+	// Tagging is a change to the guest: Jo was patched once and tagged once
+	tagged, _ := svc.GetGuestByID(ctx, jo.ID)
+	if tagged.Version != jo.Version+2 {
+		t.Errorf("version after tagging = %d, want %d", tagged.Version, jo.Version+2)
+	}
+	if added, _, err := svc.Tags.ChangeTagging(ctx, overseas.ID, []uuid.UUID{jo.ID}, nil); err != nil || added != 0 {
+		t.Errorf("tag overseas again: added = %d, err = %v", added, err)
+	}
+	if again, _ := svc.GetGuestByID(ctx, jo.ID); again.Version != tagged.Version {
+		t.Errorf("version after tagging again = %d, want %d", again.Version, tagged.Version)
+	}
+	if added, _, err := svc.Tags.ChangeTagging(ctx, vip.ID, []uuid.UUID{guest.ID, ben.ID}, nil); err != nil || added != 2 {
+		t.Fatalf("tag VIP: added = %d, err = %v", added, err)
+	}
+	if _, _, err := svc.Tags.ChangeTagging(ctx, vip.ID, []uuid.UUID{uuid.New()}, nil); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("tagging an unknown guest: err = %v, want not found", err)
+	}
+	if got, _ := svc.GetGuestByID(ctx, guest.ID); !slices.Equal(got.Tags, []string{"Overseas", "VIP"}) {
+		t.Errorf("guest tags = %v, want Overseas and VIP", got.Tags)
+	}
+	if got, _ := svc.Tags.GetTag(ctx, vip.ID); got.Guests != 2 {
+		t.Errorf("VIP guests = %d, want 2", got.Guests)
+	}
+
+	// Tag filters match every tag named, ignoring case
+	selected, err := svc.FindGuests(ctx, models.GuestFilter{Tags: []string{"vip", "overseas"}})
+	if err != nil || len(selected) != 2 {
+		t.Fatalf("FindGuests by tags = %d guests, %v; want 2", len(selected), err)
+	}
+	if _, err := svc.FindGuests(ctx, models.GuestFilter{Tags: []string{"Oversea"}}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("unknown tag: err = %v, want validation error", err)
+	}
+
+	// Reminders only go to overseas guests who have not answered
+	filter := models.GuestFilter{Tags: []string{"Overseas"}}
+	remind := models.BulkAction{Action: models.BulkSendReminders}
+	preview, err := svc.PreviewBulk(ctx, filter, remind)
+	if err != nil || preview.Count != 2 {
+		t.Fatalf("PreviewBulk = %+v, %v; want 2 pending guests", preview, err)
+	}
+	if _, err := svc.RunBulk(ctx, filter, remind, 3); !errors.Is(err, apperrors.ErrPreconditionFailed) {
+		t.Errorf("stale confirmation: err = %v, want precondition failed", err)
+	}
+	if len(mailer.reminders) != 0 {
+		t.Fatalf("reminders sent without a matching confirmation: %v", mailer.reminders)
+	}
+	result, err := svc.RunBulk(ctx, filter, remind, preview.Count)
+	if err != nil {
+		t.Fatalf("RunBulk: %v", err)
+	}
+	if result.Succeeded != 1 || len(result.Failed) != 1 || result.Failed[0].GuestID != ben.ID {
+		t.Errorf("RunBulk = %+v, want one success and Ben failing", result)
+	}
+	if !slices.Equal(mailer.reminders, []string{"may@example.com"}) {
+		t.Errorf("reminders = %v", mailer.reminders)
+	}
+
+	// Updates apply a patch to the whole selection; identities are off limits
+	if _, err := svc.PreviewBulk(ctx, filter, models.BulkAction{Action: models.BulkUpdate, Update: &models.GuestPatch{Email: &email}}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("bulk email change: err = %v, want validation error", err)
+	}
+	update := models.BulkAction{Action: models.BulkUpdate, Update: &models.GuestPatch{FamilySide: &groom}}
+	if result, err := svc.RunBulk(ctx, models.GuestFilter{Tags: []string{"VIP"}}, update, 2); err != nil || result.Succeeded != 2 {
+		t.Fatalf("bulk update = %+v, %v", result, err)
+	}
+	if got, _ := svc.GetGuestByID(ctx, guest.ID); got.FamilySide != "Groom" {
+		t.Errorf("family side after bulk update = %q", got.FamilySide)
+	}
+
+	// Deleting removes the guests and their tags
+	if result, err := svc.RunBulk(ctx, models.GuestFilter{IDs: []uuid.UUID{jo.ID}}, models.BulkAction{Action: models.BulkDelete}, 1); err != nil || result.Succeeded != 1 {
+		t.Fatalf("bulk delete = %+v, %v", result, err)
+	}
+	if got, _ := svc.Tags.GetTag(ctx, overseas.ID); got.Guests != 2 {
+		t.Errorf("overseas guests after delete = %d, want 2", got.Guests)
+	}
+	if err := svc.Tags.DeleteTag(ctx, vip.ID); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	if got, _ := svc.GetGuestByID(ctx, guest.ID); !slices.Equal(got.Tags, []string{"Overseas"}) {
+		t.Errorf("guest tags after deleting VIP = %v", got.Tags)
+	}
+}
+
+func TestHouseholdAddresses(t *testing.T) {
+	svc, _ := newTestService(t)
+	households := NewHouseholdService(svc.Repo.(repository.HouseholdStore), svc)
+	tests := []struct {
+		name    string
+		address models.PostalAddress
+		wantErr string // substring of the error, or "" for success
+	}{
+		{"Indonesia", models.PostalAddress{Line1: "Jl. Kemang Raya 8", City: "Jakarta Selatan", PostalCode: "12730", Country: "id"}, ""},
+		{"Indonesian postal code", models.PostalAddress{Line1: "Jl. Kemang Raya 8", City: "Jakarta Selatan", PostalCode: "1273", Country: "ID"}, "postal code"},
+		{"US without state", models.PostalAddress{Line1: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}, "state is required"},
+		{"US ZIP+4", models.PostalAddress{Line1: "1 Main St", City: "Springfield", Region: "IL", PostalCode: "62701-1234", Country: "US"}, ""},
+		{"UK postcode spacing", models.PostalAddress{Line1: "10 Downing Street", City: "London", PostalCode: "sw1a2aa", Country: "GB"}, ""},
+		{"UK without postcode", models.PostalAddress{Line1: "10 Downing Street", City: "London", Country: "GB"}, "postcode is required"},
+		{"Singapore without city", models.PostalAddress{Line1: "1 Orchard Road", PostalCode: "238824", Country: "SG"}, ""},
+		{"Hong Kong without postal code", models.PostalAddress{Line1: "1 Queen's Road", Region: "Central", Country: "HK"}, ""},
+		{"other country", models.PostalAddress{Line1: "Calle Mayor 1", City: "Madrid", Country: "ES"}, ""},
+		{"country name", models.PostalAddress{Line1: "Calle Mayor 1", City: "Madrid", Country: "Spain"}, "two-letter"},
+		{"no street", models.PostalAddress{City: "Madrid", Country: "ES"}, "address_line1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := households.CreateHousehold(ctx, &models.Household{Addressee: "The Tans", PostalAddress: tt.address})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("CreateHousehold: %v", err)
+			}
+			if tt.wantErr != "" && (!errors.Is(err, apperrors.ErrValidation) || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("CreateHousehold: err = %v, want validation error about %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMailingLabels(t *testing.T) {
+	svc, guest := newTestService(t)
+	households := NewHouseholdService(svc.Repo.(repository.HouseholdStore), svc)
+	svc.Wedding.Country = "ID"
+	ben, err := svc.AddGuest(ctx, "Uncle Ben", "ben@example.com", "Groom", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	loner, err := svc.AddGuest(ctx, "Jo", "jo@example.com", "Bride", 1, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	home := &models.Household{Addressee: "Keluarga Tan", PostalAddress: models.PostalAddress{
+		Line1: "Jl. Kemang Raya 8", City: "Jakarta Selatan", Region: "DKI Jakarta", PostalCode: "12730", Country: "ID"}}
+	abroad := &models.Household{Addressee: "The Parkers", PostalAddress: models.PostalAddress{
+		Line1: "20 Ingram Street", City: "Queens", Region: "NY", PostalCode: "11375", Country: "US"}}
+	for _, h := range []*models.Household{home, abroad} {
+		if err := households.CreateHousehold(ctx, h); err != nil {
+			t.Fatalf("CreateHousehold: %v", err)
+		}
+	}
+	if added, _, err := households.ChangeHouseholdMembers(ctx, abroad.ID, []uuid.UUID{guest.ID, ben.ID}, nil); err != nil || added != 2 {
+		t.Fatalf("add to household: added = %d, err = %v", added, err)
+	}
+	if _, _, err := households.ChangeHouseholdMembers(ctx, home.ID, []uuid.UUID{uuid.New()}, nil); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("adding an unknown guest: err = %v, want not found", err)
+	}
+	// Moving a guest takes them out of their previous household
+	if added, _, err := households.ChangeHouseholdMembers(ctx, home.ID, []uuid.UUID{ben.ID}, nil); err != nil || added != 1 {
+		t.Fatalf("move to household: added = %d, err = %v", added, err)
+	}
+	if got, _ := households.GetHousehold(ctx, abroad.ID); got.Guests != 1 {
+		t.Errorf("guests abroad after the move = %d, want 1", got.Guests)
+	}
+	if got, _ := svc.GetGuestByID(ctx, ben.ID); got.HouseholdID == nil || *got.HouseholdID != home.ID {
+		t.Errorf("Ben's household = %v, want %s", got.HouseholdID, home.ID)
+	}
+
+	labels, skipped, err := households.MailingLabels(ctx, models.GuestFilter{})
+	if err != nil {
+		t.Fatalf("MailingLabels: %v", err)
+	}
+	if skipped != 1 || len(labels) != 2 {
+		t.Fatalf("MailingLabels = %d labels, %d skipped; want 2 and Jo skipped", len(labels), skipped)
+	}
+	wantAbroad := []string{"The Parkers", "20 Ingram Street", "Queens, NY 11375", "UNITED STATES"}
+	if !slices.Equal(labels[0].Lines, wantAbroad) || !slices.Equal(labels[0].Guests, []string{"Aunt May"}) {
+		t.Errorf("first label = %q for %v, want %q", labels[0].Lines, labels[0].Guests, wantAbroad)
+	}
+	// Letters posted within the country leave it out
+	wantHome := []string{"Keluarga Tan", "Jl. Kemang Raya 8", "Jakarta Selatan 12730", "DKI Jakarta"}
+	if !slices.Equal(labels[1].Lines, wantHome) {
+		t.Errorf("second label = %q, want %q", labels[1].Lines, wantHome)
+	}
+
+	if _, _, err := households.MailingLabels(ctx, models.GuestFilter{IDs: []uuid.UUID{loner.ID}}); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("labels for guests without an address: err = %v, want not found", err)
+	}
+
+	// Deleting a household keeps its guests
+	if err := households.DeleteHousehold(ctx, home.ID); err != nil {
+		t.Fatalf("DeleteHousehold: %v", err)
+	}
+	if got, err := svc.GetGuestByID(ctx, ben.ID); err != nil || got.HouseholdID != nil {
+		t.Errorf("Ben after deleting his household = %+v, %v", got, err)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	svc, guest := newTestService(t)
+	duplicates := NewDuplicateService(svc.Repo.(repository.GuestMergeStore), svc)
+	phone, otherPhone := "+62 812-3456-7890", "0812 3456 7890"
+	if _, err := svc.PatchGuest(ctx, guest.ID, &models.GuestPatch{Phone: &phone}, 0); err != nil {
+		t.Fatalf("PatchGuest: %v", err)
+	}
+	samePhone, err := svc.AddGuest(ctx, "May", "may.tan@gmail.com", "Bride", 2, "")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	if _, err := svc.PatchGuest(ctx, samePhone.ID, &models.GuestPatch{Phone: &otherPhone}, 0); err != nil {
+		t.Fatalf("PatchGuest: %v", err)
+	}
+	if _, err := svc.AddGuest(ctx, "Tan, Ben", "b.e.n+wedding@gmail.com", "Groom", 1, ""); err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	if _, err := svc.AddGuest(ctx, "Ben Tän", "ben@gmail.com", "Groom", 1, ""); err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	if _, err := svc.AddGuest(ctx, "Jo", "jo@example.com", "Bride", 1, ""); err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	candidates, err := duplicates.FindDuplicates(ctx, models.DefaultDuplicateScore)
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("found %d candidates, want 2: %+v", len(candidates), candidates)
+	}
+	if c := candidates[0]; c.Score != 1 || c.Guests[0].Email != "b.e.n+wedding@gmail.com" || !slices.Equal(c.Reasons, []string{"same_name", "same_email"}) {
+		t.Errorf("first candidate = %.2f %v for %s", c.Score, c.Reasons, c.Guests[0].Email)
+	}
+	if c := candidates[1]; c.Score != 0.75 || c.Guests[0].ID != guest.ID || !slices.Equal(c.Reasons, []string{"same_name", "same_phone"}) {
+		t.Errorf("second candidate = %.2f %v", c.Score, c.Reasons)
+	}
+
+	if candidates, _ := duplicates.FindDuplicates(ctx, 1); len(candidates) != 2 {
+		t.Errorf("shared email or phone should be reported at any score, got %d candidates", len(candidates))
+	}
+	if _, err := duplicates.FindDuplicates(ctx, 1.5); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("score above 1: err = %v, want validation error", err)
+	}
+}
+
+func TestMergeGuests(t *testing.T) {
+	svc, guest := newTestService(t)
+	duplicates := NewDuplicateService(svc.Repo.(repository.GuestMergeStore), svc)
+	duplicate, err := svc.AddGuest(ctx, "Auntie May", "aunt.may@example.com", "Bride", 1, "zh")
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	hongbao, moreHongbao := 100.0, 50.0
+	if _, err := svc.PatchGuest(ctx, guest.ID, &models.GuestPatch{Hongbao: &hongbao}, 0); err != nil {
+		t.Fatalf("PatchGuest: %v", err)
+	}
+	if _, err := svc.PatchGuest(ctx, duplicate.ID, &models.GuestPatch{Hongbao: &moreHongbao}, 0); err != nil {
+		t.Fatalf("PatchGuest: %v", err)
+	}
+
+	vip, err := svc.Tags.CreateTag(ctx, "VIP")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if _, _, err := svc.Tags.ChangeTagging(ctx, vip.ID, []uuid.UUID{duplicate.ID}, nil); err != nil {
+		t.Fatalf("ChangeTagging: %v", err)
+	}
+	banquet := &models.SubEvent{Name: "Banquet", StartsAt: time.Date(2026, 11, 14, 18, 0, 0, 0, time.UTC), EndsAt: time.Date(2026, 11, 14, 22, 0, 0, 0, time.UTC)}
+	if err := svc.SubEvents.CreateSubEvent(ctx, banquet); err != nil {
+		t.Fatalf("CreateSubEvent: %v", err)
+	}
+	if _, _, err := svc.SubEvents.ChangeInvitations(ctx, banquet.ID, []uuid.UUID{guest.ID, duplicate.ID}, nil); err != nil {
+		t.Fatalf("ChangeInvitations: %v", err)
+	}
+	answer := []models.SubEventAnswer{{SubEventID: banquet.ID, RSVPStatus: models.RSVPStatusAttending, Attendees: 3}}
+	if err := svc.SubEvents.RespondToSubEvents(ctx, duplicate.RSVPCode, answer); err != nil {
+		t.Fatalf("RespondToSubEvents: %v", err)
+	}
+	checkIns := NewCheckInService(svc.Repo.(repository.CheckInStore), svc)
+	if _, err := checkIns.CheckIn(ctx, CheckInRequest{GuestID: duplicate.ID, Arrived: 1}); err != nil {
+		t.Fatalf("CheckIn: %v", err)
+	}
+
+	if _, _, err := duplicates.MergeGuests(ctx, guest.ID, guest.ID, 0, 0); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("merge into itself: err = %v, want validation error", err)
+	}
+	if _, _, err := duplicates.MergeGuests(ctx, guest.ID, duplicate.ID, 0, duplicate.Version); !errors.Is(err, apperrors.ErrPreconditionFailed) {
+		t.Errorf("stale duplicate version: err = %v, want precondition failed", err)
+	}
+
+	merged, merge, err := duplicates.MergeGuests(ctx, guest.ID, duplicate.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("MergeGuests: %v", err)
+	}
+	if merged.Name != "Aunt May" || merged.Email != "may@example.com" || merged.RSVPCode != guest.RSVPCode {
+		t.Errorf("primary identity changed: %+v", merged)
+	}
+	if merged.RSVPStatus != models.RSVPStatusAttending || merged.TotalGuests != 3 || merged.Hongbao != 150 || merged.PreferredLanguage != "zh" {
+		t.Errorf("merged guest = %+v", merged)
+	}
+	if stored, _ := svc.GetGuestByID(ctx, guest.ID); stored.Version != merged.Version || !slices.Equal(stored.Tags, []string{"VIP"}) || stored.Hongbao != 150 {
+		t.Errorf("stored guest = %+v", stored)
+	}
+	if _, err := svc.GetGuestByID(ctx, duplicate.ID); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("duplicate still exists: err = %v", err)
+	}
+
+	invitations, _ := svc.SubEvents.GetSubEventInvitations(ctx, banquet.ID)
+	if len(invitations) != 1 || invitations[0].GuestID != guest.ID || invitations[0].Attendees != 3 {
+		t.Errorf("banquet invitations = %+v", invitations)
+	}
+	if moved, _ := checkIns.Store.GetCheckIns(ctx, guest.ID); len(moved) != 1 {
+		t.Errorf("check-ins moved = %d, want 1", len(moved))
+	}
+
+	merges, err := duplicates.GetGuestMerges(ctx)
+	if err != nil || len(merges) != 1 {
+		t.Fatalf("GetGuestMerges = %v, %v", merges, err)
+	}
+	if merges[0].ID != merge.ID || merges[0].Primary.Hongbao != 100 || merges[0].Duplicate.Name != "Auntie May" || !slices.Equal(merges[0].Duplicate.Tags, []string{"VIP"}) {
+		t.Errorf("merge record = %+v", merges[0])
+	}
+}
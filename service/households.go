@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// HouseholdService manages households, who lives in them and the mailing
+// labels printed for them
+type HouseholdService struct {
+	Store repository.HouseholdStore
+
+	// Guests selects the guests mailing labels are printed for
+	Guests *GuestService
+}
+
+// NewHouseholdService initializes a new household service
+func NewHouseholdService(store repository.HouseholdStore, guests *GuestService) *HouseholdService {
+	return &HouseholdService{Store: store, Guests: guests}
+}
+
+// GetHouseholds lists the households by addressee, with how many guests live in each
+func (s *HouseholdService) GetHouseholds(ctx context.Context) ([]models.Household, error) {
+	households, err := s.Store.GetHouseholds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch households: %w", err)
+	}
+	return households, nil
+}
+
+// GetHousehold retrieves a household
+func (s *HouseholdService) GetHousehold(ctx context.Context, id uuid.UUID) (*models.Household, error) {
+	household, err := s.Store.GetHousehold(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch household: %w", err)
+	}
+	return household, nil
+}
+
+// CreateHousehold validates the address for its country and stores the
+// household, assigning its ID
+func (s *HouseholdService) CreateHousehold(ctx context.Context, household *models.Household) error {
+	if err := household.Validate(); err != nil {
+		return err
+	}
+	household.ID = uuid.New()
+	household.CreatedAt = time.Now().UTC()
+	if err := s.Store.CreateHousehold(ctx, household); err != nil {
+		return fmt.Errorf("failed to create household: %w", err)
+	}
+	return nil
+}
+
+// UpdateHousehold replaces a household's addressee and address; its guests stay
+func (s *HouseholdService) UpdateHousehold(ctx context.Context, household *models.Household) error {
+	if err := household.Validate(); err != nil {
+		return err
+	}
+	if err := s.Store.UpdateHousehold(ctx, household); err != nil {
+		return fmt.Errorf("failed to update household: %w", err)
+	}
+	stored, err := s.Store.GetHousehold(ctx, household.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch household: %w", err)
+	}
+	*household = *stored
+	return nil
+}
+
+// DeleteHousehold removes a household; its guests are kept without an address
+func (s *HouseholdService) DeleteHousehold(ctx context.Context, id uuid.UUID) error {
+	if err := s.Store.DeleteHousehold(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete household: %w", err)
+	}
+	return nil
+}
+
+// ChangeHouseholdMembers moves guests into a household, out of any other,
+// and takes guests out of it, reporting how many joined and left
+func (s *HouseholdService) ChangeHouseholdMembers(ctx context.Context, householdID uuid.UUID, add, remove []uuid.UUID) (added, removed int, err error) {
+	for _, id := range add {
+		for _, other := range remove {
+			if id == other {
+				return 0, 0, apperrors.Newf(apperrors.ErrValidation, "guest %s is both added and removed", id)
+			}
+		}
+	}
+	if _, err := s.Store.GetHousehold(ctx, householdID); err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch household: %w", err)
+	}
+
+	if len(add) > 0 {
+		if added, err = s.Store.MoveToHousehold(ctx, householdID, add); err != nil {
+			return 0, 0, fmt.Errorf("failed to add guests to household: %w", err)
+		}
+	}
+	if len(remove) > 0 {
+		if removed, err = s.Store.RemoveFromHousehold(ctx, householdID, remove); err != nil {
+			return added, 0, fmt.Errorf("failed to remove guests from household: %w", err)
+		}
+	}
+	return added, removed, nil
+}
+
+// MailingLabels builds one address label per household of the guests
+// selected by filter, in the order the guests are listed. Guests without a
+// household have nowhere to be mailed and are counted in skipped.
+func (s *HouseholdService) MailingLabels(ctx context.Context, filter models.GuestFilter) (labels []models.MailingLabel, skipped int, err error) {
+	guests, err := s.Guests.FindGuests(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	households, err := s.Store.GetHouseholds(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch households: %w", err)
+	}
+	byID := make(map[uuid.UUID]*models.Household, len(households))
+	for i := range households {
+		byID[households[i].ID] = &households[i]
+	}
+
+	index := make(map[uuid.UUID]int)
+	for _, guest := range guests {
+		if guest.HouseholdID == nil || byID[*guest.HouseholdID] == nil {
+			skipped++
+			continue
+		}
+		h := byID[*guest.HouseholdID]
+		i, ok := index[h.ID]
+		if !ok {
+			i = len(labels)
+			index[h.ID] = i
+			labels = append(labels, models.MailingLabel{
+				HouseholdID: h.ID,
+				Addressee:   h.Addressee,
+				Address:     h.PostalAddress,
+				Lines:       append([]string{h.Addressee}, h.Lines(s.Guests.Wedding.Country)...),
+			})
+		}
+		labels[i].Guests = append(labels[i].Guests, guest.Name)
+	}
+	if len(labels) == 0 {
+		return nil, skipped, apperrors.New(apperrors.ErrNotFound, "no guests with a postal address match the filter")
+	}
+	return labels, skipped, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/g4l1l10/rsvp-backend/i18n"
+	"github.com/g4l1l10/rsvp-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// guestLanguage picks the language to show a guest: the one asked for
+// explicitly, then the guest's preference, then the best match for their
+// browser's Accept-Language header, then the default
+func guestLanguage(guest *models.Guest, requestedLanguage, acceptLanguage string) string {
+	return i18n.Resolve(requestedLanguage, guest.PreferredLanguage, i18n.Negotiate(acceptLanguage))
+}
+
+// invitedSubEvents lists the sub-events a guest is invited to, with the
+// sub-events' text in lang; none when sub-events are not set up
+func (s *GuestService) invitedSubEvents(ctx context.Context, guestID uuid.UUID, lang string) ([]models.GuestSubEvent, error) {
+	if s.SubEvents == nil {
+		return []models.GuestSubEvent{}, nil
+	}
+	return s.SubEvents.guestSubEvents(ctx, guestID, lang)
+}
+
+// RSVPPage gathers what the guest holding the RSVP credential sees on their
+// RSVP page, in the language chosen by guestLanguage
+func (s *GuestService) RSVPPage(ctx context.Context, credential, requestedLanguage, acceptLanguage string) (*models.RSVPPage, error) {
+	guest, err := s.lookupCredential(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving guest by token: %w", err)
+	}
+
+	lang := guestLanguage(guest, requestedLanguage, acceptLanguage)
+	subEvents, err := s.invitedSubEvents(ctx, guest.ID, lang)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RSVPPage{PublicGuest: guest.Public(), Language: lang, Labels: i18n.Labels(lang), SubEvents: subEvents}, nil
+}
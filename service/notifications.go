@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+)
+
+const (
+	// confirmationQueue is how many confirmation emails may wait to be sent
+	confirmationQueue = 100
+	// emailTimeout bounds each notification email
+	emailTimeout = 30 * time.Second
+)
+
+// RSVPMailer sends the emails that follow a guest's RSVP
+type RSVPMailer interface {
+	SendRSVPConfirmation(ctx context.Context, response models.RSVPResponse, changeLink string, ics []byte) error
+	SendRSVPDigest(ctx context.Context, recipients []string, responses []models.RSVPResponse) error
+}
+
+// confirmation is a queued confirmation email
+type confirmation struct {
+	response   models.RSVPResponse
+	changeLink string
+	ics        []byte
+}
+
+// RSVPNotifier emails guests a confirmation of their RSVP and sends the
+// couple a periodic digest of new responses. Emails are sent in the
+// background by Run, so a slow mail server never delays an RSVP; anything
+// not yet sent when the process stops is lost.
+type RSVPNotifier struct {
+	Mailer RSVPMailer
+	Config config.NotificationConfig
+
+	queue  chan confirmation
+	mu     sync.Mutex
+	digest []models.RSVPResponse // responses since the last digest, one per guest
+}
+
+// NewRSVPNotifier initializes a notifier; call Run to start sending
+func NewRSVPNotifier(mailer RSVPMailer, cfg config.NotificationConfig) *RSVPNotifier {
+	return &RSVPNotifier{Mailer: mailer, Config: cfg, queue: make(chan confirmation, confirmationQueue)}
+}
+
+// Notify queues the emails for a response without blocking; ics, when set,
+// is attached to the guest's confirmation
+func (n *RSVPNotifier) Notify(ctx context.Context, response models.RSVPResponse, changeLink string, ics []byte) {
+	if len(n.Config.DigestRecipients) > 0 {
+		n.mu.Lock()
+		// A guest answering twice between digests is listed once, with their
+		// latest answer and the status they started from
+		if i := slices.IndexFunc(n.digest, func(r models.RSVPResponse) bool { return r.GuestID == response.GuestID }); i >= 0 {
+			response.PreviousStatus = n.digest[i].PreviousStatus
+			n.digest = slices.Delete(n.digest, i, i+1)
+		}
+		n.digest = append(n.digest, response)
+		n.mu.Unlock()
+	}
+
+	if n.Config.GuestConfirmation {
+		select {
+		case n.queue <- confirmation{response: response, changeLink: changeLink, ics: ics}:
+		default:
+			logging.FromContext(ctx).Warn("confirmation email dropped: queue is full", slog.String("guest_id", response.GuestID.String()))
+		}
+	}
+}
+
+// Run sends confirmations as they are queued and the digest every
+// DigestInterval until ctx is cancelled. Queued emails are sent before it
+// returns, so responses received just before shutdown are still reported.
+func (n *RSVPNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.Config.DigestInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.flush()
+			return
+		case c := <-n.queue:
+			n.sendConfirmation(ctx, c)
+		case <-ticker.C:
+			n.SendDigest(ctx)
+		}
+	}
+}
+
+// flush sends queued confirmations and the pending digest during shutdown,
+// giving up once emailTimeout has passed
+func (n *RSVPNotifier) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+	defer cancel()
+
+drain:
+	for ctx.Err() == nil {
+		select {
+		case c := <-n.queue:
+			n.sendConfirmation(ctx, c)
+		default:
+			break drain
+		}
+	}
+	n.SendDigest(ctx)
+}
+
+// sendConfirmation emails one guest, logging a failure with the guest's ID
+func (n *RSVPNotifier) sendConfirmation(ctx context.Context, c confirmation) {
+	sendCtx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+	if err := n.Mailer.SendRSVPConfirmation(sendCtx, c.response, c.changeLink, c.ics); err != nil {
+		logging.FromContext(ctx).Error("RSVP confirmation not sent",
+			slog.String("guest_id", c.response.GuestID.String()), slog.Any("error", err))
+	}
+}
+
+// SendDigest emails the responses received since the last digest, if any.
+// If sending fails they are kept for the next digest.
+func (n *RSVPNotifier) SendDigest(ctx context.Context) error {
+	n.mu.Lock()
+	responses := n.digest
+	n.digest = nil
+	n.mu.Unlock()
+	if len(responses) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+	if err := n.Mailer.SendRSVPDigest(ctx, n.Config.DigestRecipients, responses); err != nil {
+		n.mu.Lock()
+		// Responses that arrived meanwhile are newer; keep them in place of older ones
+		for _, r := range responses {
+			if !slices.ContainsFunc(n.digest, func(newer models.RSVPResponse) bool { return newer.GuestID == r.GuestID }) {
+				n.digest = append(n.digest, r)
+			}
+		}
+		n.mu.Unlock()
+		return err
+	}
+	return nil
+}
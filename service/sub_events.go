@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// SubEventService manages sub-events, who is invited to them and the guests' answers
+type SubEventService struct {
+	Store repository.SubEventStore
+	// Guests looks up RSVP credentials and publishes the guest changes answers make
+	Guests *GuestService
+}
+
+// NewSubEventService initializes a new sub-event service
+func NewSubEventService(store repository.SubEventStore, guests *GuestService) *SubEventService {
+	return &SubEventService{Store: store, Guests: guests}
+}
+
+// GetSubEvents lists the sub-events in chronological order
+func (s *SubEventService) GetSubEvents(ctx context.Context) ([]models.SubEvent, error) {
+	subEvents, err := s.Store.GetSubEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-events: %w", err)
+	}
+	return subEvents, nil
+}
+
+// GetSubEvent retrieves a sub-event
+func (s *SubEventService) GetSubEvent(ctx context.Context, id uuid.UUID) (*models.SubEvent, error) {
+	subEvent, err := s.Store.GetSubEvent(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-event: %w", err)
+	}
+	return subEvent, nil
+}
+
+// CreateSubEvent validates and stores a new sub-event, assigning its ID
+func (s *SubEventService) CreateSubEvent(ctx context.Context, subEvent *models.SubEvent) error {
+	if err := subEvent.Validate(); err != nil {
+		return err
+	}
+	subEvent.ID = uuid.New()
+	subEvent.CreatedAt = time.Now().UTC()
+	if err := s.Store.CreateSubEvent(ctx, subEvent); err != nil {
+		return fmt.Errorf("failed to create sub-event: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubEvent replaces a sub-event's details
+func (s *SubEventService) UpdateSubEvent(ctx context.Context, subEvent *models.SubEvent) error {
+	if err := subEvent.Validate(); err != nil {
+		return err
+	}
+	if err := s.Store.UpdateSubEvent(ctx, subEvent); err != nil {
+		return fmt.Errorf("failed to update sub-event: %w", err)
+	}
+	stored, err := s.Store.GetSubEvent(ctx, subEvent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch sub-event: %w", err)
+	}
+	*subEvent = *stored
+	return nil
+}
+
+// DeleteSubEvent removes a sub-event and every invitation to it
+func (s *SubEventService) DeleteSubEvent(ctx context.Context, id uuid.UUID) error {
+	if err := s.Store.DeleteSubEvent(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete sub-event: %w", err)
+	}
+	return nil
+}
+
+// ChangeInvitations invites and uninvites guests to a sub-event, reporting
+// how many invitations were added and withdrawn. Re-inviting a guest keeps
+// their answer.
+func (s *SubEventService) ChangeInvitations(ctx context.Context, subEventID uuid.UUID, invite, uninvite []uuid.UUID) (invited, uninvited int, err error) {
+	for _, id := range invite {
+		for _, other := range uninvite {
+			if id == other {
+				return 0, 0, apperrors.Newf(apperrors.ErrValidation, "guest %s is both invited and uninvited", id)
+			}
+		}
+	}
+	if _, err := s.Store.GetSubEvent(ctx, subEventID); err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch sub-event: %w", err)
+	}
+
+	if len(invite) > 0 {
+		if invited, err = s.Store.InviteGuests(ctx, subEventID, invite); err != nil {
+			return 0, 0, fmt.Errorf("failed to invite guests: %w", err)
+		}
+	}
+	if len(uninvite) > 0 {
+		if uninvited, err = s.Store.UninviteGuests(ctx, subEventID, uninvite); err != nil {
+			return invited, 0, fmt.Errorf("failed to uninvite guests: %w", err)
+		}
+	}
+	return invited, uninvited, nil
+}
+
+// GetSubEventInvitations lists who is invited to a sub-event and their answers
+func (s *SubEventService) GetSubEventInvitations(ctx context.Context, subEventID uuid.UUID) ([]models.Invitation, error) {
+	if _, err := s.Store.GetSubEvent(ctx, subEventID); err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-event: %w", err)
+	}
+	invitations, err := s.Store.GetSubEventInvitations(ctx, subEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// InvitationMatrix lays out which guest is invited to which sub-event.
+// Every guest gets a row, including those invited to nothing.
+func (s *SubEventService) InvitationMatrix(ctx context.Context) (*models.InvitationMatrix, error) {
+	subEvents, err := s.Store.GetSubEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-events: %w", err)
+	}
+	guests, err := s.Guests.Repo.GetAllGuests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guests: %w", err)
+	}
+	invitations, err := s.Store.GetAllInvitations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+
+	matrix := &models.InvitationMatrix{SubEvents: subEvents, Guests: make([]models.InvitationsRow, len(guests))}
+	if matrix.SubEvents == nil {
+		matrix.SubEvents = []models.SubEvent{}
+	}
+	rows := make(map[uuid.UUID]*models.InvitationsRow, len(guests))
+	for i, g := range guests {
+		matrix.Guests[i] = models.InvitationsRow{GuestID: g.ID, Name: g.Name, Invitations: map[uuid.UUID]string{}}
+		rows[g.ID] = &matrix.Guests[i]
+	}
+	for _, inv := range invitations {
+		if row, ok := rows[inv.GuestID]; ok {
+			row.Invitations[inv.SubEventID] = inv.RSVPStatus
+		}
+	}
+	return matrix, nil
+}
+
+// GetSubEventSummary counts invitations, answers and expected attendees per sub-event
+func (s *SubEventService) GetSubEventSummary(ctx context.Context) ([]models.SubEventSummary, error) {
+	subEvents, err := s.Store.GetSubEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-events: %w", err)
+	}
+	invitations, err := s.Store.GetAllInvitations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+
+	summary := make([]models.SubEventSummary, len(subEvents))
+	index := make(map[uuid.UUID]int, len(subEvents))
+	for i, e := range subEvents {
+		summary[i] = models.SubEventSummary{SubEventID: e.ID, Name: e.Name}
+		index[e.ID] = i
+	}
+	for _, inv := range invitations {
+		i, ok := index[inv.SubEventID]
+		if !ok {
+			continue
+		}
+		summary[i].Invited++
+		switch inv.RSVPStatus {
+		case models.RSVPStatusAttending:
+			summary[i].Attending++
+			summary[i].ExpectedAttendees += inv.Attendees
+		case models.RSVPStatusNotAttending:
+			summary[i].NotAttending++
+		default:
+			summary[i].Pending++
+		}
+	}
+	return summary, nil
+}
+
+// GuestSubEvents lists the sub-events the guest holding the RSVP credential
+// is invited to, with their answers, in the language chosen by guestLanguage
+func (s *SubEventService) GuestSubEvents(ctx context.Context, credential, requestedLanguage, acceptLanguage string) ([]models.GuestSubEvent, error) {
+	guest, err := s.Guests.lookupCredential(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guest: %w", err)
+	}
+	return s.guestSubEvents(ctx, guest.ID, guestLanguage(guest, requestedLanguage, acceptLanguage))
+}
+
+// guestSubEvents joins a guest's invitations with their sub-events, in
+// chronological order, with the sub-events' text in lang
+func (s *SubEventService) guestSubEvents(ctx context.Context, guestID uuid.UUID, lang string) ([]models.GuestSubEvent, error) {
+	invitations, err := s.Store.GetGuestInvitations(ctx, guestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+	subEvents, err := s.Store.GetSubEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sub-events: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]models.Invitation, len(invitations))
+	for _, inv := range invitations {
+		byID[inv.SubEventID] = inv
+	}
+	result := []models.GuestSubEvent{}
+	for _, e := range subEvents {
+		if inv, ok := byID[e.ID]; ok {
+			result = append(result, models.GuestSubEvent{SubEvent: e.Localized(lang), RSVPStatus: inv.RSVPStatus, Attendees: inv.Attendees})
+		}
+	}
+	return result, nil
+}
+
+// RespondToSubEvents records the answers of the guest holding the RSVP
+// credential for the sub-events they are invited to. The guest's overall
+// RSVP follows from their answers: attending if they attend any sub-event,
+// with the largest party they bring to one; not attending once they have
+// declined every sub-event; otherwise unchanged.
+func (s *SubEventService) RespondToSubEvents(ctx context.Context, credential string, answers []models.SubEventAnswer) error {
+	if len(answers) == 0 {
+		return apperrors.New(apperrors.ErrValidation, "answer at least one sub-event")
+	}
+	seen := make(map[uuid.UUID]bool, len(answers))
+	for i := range answers {
+		if err := answers[i].Validate(); err != nil {
+			return fmt.Errorf("invalid RSVP: %w", err)
+		}
+		if seen[answers[i].SubEventID] {
+			return apperrors.Newf(apperrors.ErrValidation, "sub-event %s is answered more than once", answers[i].SubEventID)
+		}
+		seen[answers[i].SubEventID] = true
+	}
+
+	guest, err := s.Guests.lookupCredential(ctx, credential)
+	if err != nil {
+		return fmt.Errorf("failed to fetch guest: %w", err)
+	}
+	invitations, err := s.Store.GetGuestInvitations(ctx, guest.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+
+	answered := make(map[uuid.UUID]models.SubEventAnswer, len(answers))
+	for _, a := range answers {
+		answered[a.SubEventID] = a
+	}
+	attendees, declined := 0, 0
+	for _, inv := range invitations {
+		status, count := inv.RSVPStatus, inv.Attendees
+		if a, ok := answered[inv.SubEventID]; ok {
+			status, count = a.RSVPStatus, a.Attendees
+		}
+		switch status {
+		case models.RSVPStatusAttending:
+			attendees = max(attendees, count)
+		case models.RSVPStatusNotAttending:
+			declined++
+		}
+	}
+
+	previousStatus := guest.RSVPStatus
+	switch {
+	case attendees > 0:
+		guest.RSVPStatus = models.RSVPStatusAttending
+		guest.TotalGuests = attendees
+	case declined == len(invitations):
+		guest.RSVPStatus = models.RSVPStatusNotAttending
+	}
+
+	// Answers for sub-events the guest is not invited to fail with ErrNotInvited
+	if err := s.Store.RespondToSubEvents(ctx, guest, answers, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to update RSVP: %w", err)
+	}
+	s.Guests.publishGuest(ctx, events.GuestRSVP, guest)
+	s.Guests.notifyRSVP(ctx, guest, credential, previousStatus)
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// TagService manages the tags grouping guests for filtering and bulk actions
+type TagService struct {
+	Store repository.TagStore
+
+	// Guests publishes the guest changes tagging makes
+	Guests *GuestService
+}
+
+// NewTagService initializes a new tag service
+func NewTagService(store repository.TagStore, guests *GuestService) *TagService {
+	return &TagService{Store: store, Guests: guests}
+}
+
+// GetTags lists the tags in alphabetical order, with how many guests carry each
+func (s *TagService) GetTags(ctx context.Context) ([]models.Tag, error) {
+	tags, err := s.Store.GetTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	return tags, nil
+}
+
+// GetTag retrieves a tag
+func (s *TagService) GetTag(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	tag, err := s.Store.GetTag(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tag: %w", err)
+	}
+	return tag, nil
+}
+
+// CreateTag adds a tag; names are unique regardless of case
+func (s *TagService) CreateTag(ctx context.Context, name string) (*models.Tag, error) {
+	name, err := models.NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	tag := &models.Tag{ID: uuid.New(), Name: name, CreatedAt: time.Now().UTC()}
+	if err := s.Store.CreateTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// RenameTag changes a tag's name; the guests carrying it keep it
+func (s *TagService) RenameTag(ctx context.Context, id uuid.UUID, name string) (*models.Tag, error) {
+	name, err := models.NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.UpdateTag(ctx, &models.Tag{ID: id, Name: name}); err != nil {
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return s.GetTag(ctx, id)
+}
+
+// DeleteTag removes a tag from every guest and deletes it
+func (s *TagService) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	if err := s.Store.DeleteTag(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// ChangeTagging assigns a tag to guests and removes it from others, reporting
+// how many guests gained and lost it. Each guest whose tags changed is
+// published as updated.
+func (s *TagService) ChangeTagging(ctx context.Context, tagID uuid.UUID, add, remove []uuid.UUID) (added, removed int, err error) {
+	for _, id := range add {
+		for _, other := range remove {
+			if id == other {
+				return 0, 0, apperrors.Newf(apperrors.ErrValidation, "guest %s is both added and removed", id)
+			}
+		}
+	}
+	if _, err := s.Store.GetTag(ctx, tagID); err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch tag: %w", err)
+	}
+
+	var tagged, untagged []uuid.UUID
+	if len(add) > 0 {
+		if tagged, err = s.Store.TagGuests(ctx, tagID, add); err != nil {
+			return 0, 0, fmt.Errorf("failed to tag guests: %w", err)
+		}
+		s.publishChanged(ctx, tagged)
+	}
+	if len(remove) > 0 {
+		if untagged, err = s.Store.UntagGuests(ctx, tagID, remove); err != nil {
+			return len(tagged), 0, fmt.Errorf("failed to untag guests: %w", err)
+		}
+		s.publishChanged(ctx, untagged)
+	}
+	return len(tagged), len(untagged), nil
+}
+
+// publishChanged publishes the guests whose tags changed. The change is
+// already stored, so a guest that cannot be reloaded is only logged.
+func (s *TagService) publishChanged(ctx context.Context, guestIDs []uuid.UUID) {
+	for _, id := range guestIDs {
+		guest, err := s.Guests.Repo.GetGuestByID(ctx, id)
+		if err != nil {
+			logging.FromContext(ctx).Warn("tagged guest not published", slog.String("guest_id", id.String()), slog.Any("error", err))
+			continue
+		}
+		s.Guests.publishGuest(ctx, events.GuestUpdated, guest)
+	}
+}
+
+// checkTagNames fails with a validation error naming the first tag that does
+// not exist, so a typo in a filter is not mistaken for an empty selection
+func (s *TagService) checkTagNames(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	tags, err := s.Store.GetTags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	for _, name := range names {
+		known := false
+		for _, tag := range tags {
+			if strings.EqualFold(tag.Name, strings.TrimSpace(name)) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return apperrors.Newf(apperrors.ErrValidation, "unknown tag %q", name)
+		}
+	}
+	return nil
+}
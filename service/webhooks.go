@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+	"github.com/g4l1l10/rsvp-backend/webhooks"
+
+	"github.com/google/uuid"
+)
+
+// WebhookService manages the endpoints guest events are delivered to
+type WebhookService struct {
+	Store      repository.WebhookStore
+	Dispatcher *webhooks.Dispatcher
+}
+
+// NewWebhookService initializes a new webhook service
+func NewWebhookService(store repository.WebhookStore, dispatcher *webhooks.Dispatcher) *WebhookService {
+	return &WebhookService{Store: store, Dispatcher: dispatcher}
+}
+
+// WebhookSettings are the admin-editable fields of a webhook
+type WebhookSettings struct {
+	URL         string
+	EventTypes  []string
+	Description string
+	Active      bool
+}
+
+// validate checks the endpoint URL and event types, dropping duplicate types
+func (w *WebhookSettings) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return apperrors.New(apperrors.ErrValidation, "webhook URL must be an absolute http or https URL")
+	}
+	if len(w.EventTypes) == 0 {
+		return apperrors.Newf(apperrors.ErrValidation, "subscribe to at least one event type: %s", strings.Join(webhooks.EventTypes, ", "))
+	}
+	var types []string
+	for _, t := range w.EventTypes {
+		if !slices.Contains(webhooks.EventTypes, t) {
+			return apperrors.Newf(apperrors.ErrValidation, "unknown event type %q; must be one of %s", t, strings.Join(webhooks.EventTypes, ", "))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	w.EventTypes = types
+	return nil
+}
+
+// GetWebhooks lists registered webhooks without their secrets
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	hooks, err := s.Store.GetWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// GetWebhook retrieves a webhook without its secret
+func (s *WebhookService) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	webhook, err := s.Store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// CreateWebhook registers an endpoint. The returned webhook carries its
+// signing secret, which is not shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, settings WebhookSettings) (*models.Webhook, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	webhook := &models.Webhook{
+		ID:          uuid.New(),
+		URL:         settings.URL,
+		EventTypes:  settings.EventTypes,
+		Description: settings.Description,
+		Active:      settings.Active,
+		Secret:      webhooks.NewSecret(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.Store.CreateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook replaces a webhook's settings, keeping its secret
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, settings WebhookSettings) (*models.Webhook, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	return s.changeWebhook(ctx, id, func(w *models.Webhook) {
+		w.URL = settings.URL
+		w.EventTypes = settings.EventTypes
+		w.Description = settings.Description
+		w.Active = settings.Active
+	})
+}
+
+// RotateWebhookSecret replaces a webhook's signing secret and returns the
+// webhook with the new secret. Deliveries signed from now on use it.
+func (s *WebhookService) RotateWebhookSecret(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	secret := webhooks.NewSecret()
+	webhook, err := s.changeWebhook(ctx, id, func(w *models.Webhook) { w.Secret = secret })
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// changeWebhook applies change to the stored webhook and saves it
+func (s *WebhookService) changeWebhook(ctx context.Context, id uuid.UUID, change func(*models.Webhook)) (*models.Webhook, error) {
+	webhook, err := s.Store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	change(webhook)
+	webhook.UpdatedAt = time.Now().UTC()
+	if err := s.Store.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := s.Store.DeleteWebhook(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// TestWebhook sends a test event to a webhook and returns the logged delivery
+func (s *WebhookService) TestWebhook(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	webhook, err := s.Store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	delivery, err := s.Dispatcher.Fire(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send test event: %w", err)
+	}
+	return delivery, nil
+}
+
+// GetWebhookDeliveries returns a webhook's most recent deliveries, newest first
+func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.Store.GetWebhook(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	deliveries, err := s.Store.GetWebhookDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,193 @@
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by Verify. Both are classified so they can be returned to
+// guests as they are.
+var (
+	ErrInvalid = apperrors.New(apperrors.ErrNotFound, "invalid RSVP token")
+	ErrExpired = apperrors.New(apperrors.ErrForbidden, "this RSVP link has expired; please ask the couple for a new one")
+)
+
+// prefix marks the token format version
+const prefix = "v1"
+
+// MinKeyLength is the minimum signing key size in bytes
+const MinKeyLength = 32
+
+// secretLength is the size of the random per-link secret whose hash is stored
+const secretLength = 16
+
+// payloadLength is guest ID + expiry (unix seconds) + secret
+const payloadLength = 16 + 8 + secretLength
+
+var (
+	encoding     = base64.RawURLEncoding
+	validKeyID   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	tokenPattern = regexp.MustCompile(`^` + prefix + `\.[A-Za-z0-9_-]{1,32}\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`)
+)
+
+// Key is an HMAC signing key identified by ID, which is embedded in every
+// token it signs so verification can pick the right key after a rotation
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Claims are the verified contents of a token
+type Claims struct {
+	GuestID   uuid.UUID
+	ExpiresAt time.Time
+	KeyID     string
+	secret    []byte
+}
+
+// Issued is a freshly signed token together with what is persisted for it
+type Issued struct {
+	Token      string // handed to the guest, never stored
+	SecretHash string // stored with the guest; revoking replaces it
+	ExpiresAt  time.Time
+}
+
+// Signer issues and verifies RSVP magic-link tokens of the form
+// v1.<key id>.<payload>.<signature>, where the payload carries the guest ID,
+// the expiry and a random secret. Only a hash of the secret is stored, so a
+// database leak does not expose working links, and replacing the hash revokes
+// a link before it expires.
+type Signer struct {
+	keys    map[string][]byte
+	current string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewSigner initializes a signer. The first key signs new tokens; the others
+// are only accepted for verification, which lets old links keep working while
+// a key is being retired.
+func NewSigner(keys []Key, ttl time.Duration) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("token lifetime must be greater than zero")
+	}
+
+	s := &Signer{keys: make(map[string][]byte, len(keys)), current: keys[0].ID, ttl: ttl, now: time.Now}
+	for _, key := range keys {
+		if !validKeyID.MatchString(key.ID) {
+			return nil, fmt.Errorf("invalid signing key ID %q", key.ID)
+		}
+		if len(key.Secret) < MinKeyLength {
+			return nil, fmt.Errorf("signing key %q must be at least %d bytes", key.ID, MinKeyLength)
+		}
+		if _, duplicate := s.keys[key.ID]; duplicate {
+			return nil, fmt.Errorf("duplicate signing key ID %q", key.ID)
+		}
+		s.keys[key.ID] = key.Secret
+	}
+	return s, nil
+}
+
+// Issue signs a new token for the guest with the current key
+func (s *Signer) Issue(guestID uuid.UUID) (Issued, error) {
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return Issued{}, err
+	}
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+
+	payload := make([]byte, 0, payloadLength)
+	payload = append(payload, guestID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expires.Unix()))
+	payload = append(payload, secret...)
+
+	signed := prefix + "." + s.current + "." + encoding.EncodeToString(payload)
+	return Issued{
+		Token:      signed + "." + encoding.EncodeToString(sign(s.keys[s.current], signed)),
+		SecretHash: hashSecret(secret),
+		ExpiresAt:  expires,
+	}, nil
+}
+
+// LooksSigned reports whether token has the shape of a signed token, without verifying it
+func LooksSigned(token string) bool {
+	return tokenPattern.MatchString(token)
+}
+
+// Verify checks the signature and expiry of token. It needs no storage, so
+// forged or expired tokens are rejected before any database lookup.
+func (s *Signer) Verify(token string) (Claims, error) {
+	if !LooksSigned(token) {
+		return Claims{}, ErrInvalid
+	}
+	parts := strings.Split(token, ".")
+	keyID, encodedPayload, encodedSig := parts[1], parts[2], parts[3]
+
+	key, ok := s.keys[keyID]
+	if !ok {
+		return Claims{}, ErrInvalid
+	}
+	sig, err := encoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, sign(key, prefix+"."+keyID+"."+encodedPayload)) {
+		return Claims{}, ErrInvalid
+	}
+
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != payloadLength {
+		return Claims{}, ErrInvalid
+	}
+	claims := Claims{
+		GuestID:   uuid.UUID(payload[:16]),
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0),
+		KeyID:     keyID,
+		secret:    payload[24:],
+	}
+	if !s.now().Before(claims.ExpiresAt) {
+		return Claims{}, ErrExpired
+	}
+	return claims, nil
+}
+
+// MatchesHash reports whether the token's secret is the one whose hash was stored
+func (c Claims) MatchesHash(storedHash string) bool {
+	if storedHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashSecret(c.secret)), []byte(storedHash)) == 1
+}
+
+// sign computes the HMAC-SHA256 of message
+func sign(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// HashLegacy is the stored form of a plaintext RSVP token issued before links
+// were signed. Migration 006 computes the same hash for tokens already stored.
+func HashLegacy(token string) string {
+	return hashSecret([]byte(token))
+}
+
+// hashSecret is the stored form of a link secret
+func hashSecret(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:])
+}
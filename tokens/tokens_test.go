@@ -0,0 +1,157 @@
+package tokens
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func key(id, fill string) Key {
+	return Key{ID: id, Secret: []byte(strings.Repeat(fill, MinKeyLength))}
+}
+
+func newSigner(t *testing.T, keys ...Key) *Signer {
+	t.Helper()
+	s, err := NewSigner(keys, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestIssueVerify(t *testing.T) {
+	s := newSigner(t, key("k1", "a"))
+	guestID := uuid.New()
+
+	issued, err := s.Issue(guestID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !LooksSigned(issued.Token) || strings.Contains(issued.Token, issued.SecretHash) {
+		t.Fatalf("unexpected token %q / hash %q", issued.Token, issued.SecretHash)
+	}
+
+	claims, err := s.Verify(issued.Token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.GuestID != guestID || claims.KeyID != "k1" || !claims.ExpiresAt.Equal(issued.ExpiresAt) {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if !claims.MatchesHash(issued.SecretHash) {
+		t.Error("claims do not match their own hash")
+	}
+	if claims.MatchesHash("") {
+		t.Error("a revoked (empty) hash must never match")
+	}
+	other, _ := s.Issue(guestID)
+	if claims.MatchesHash(other.SecretHash) {
+		t.Error("claims match a newer link's hash")
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	s := newSigner(t, key("k1", "a"))
+	issued, _ := s.Issue(uuid.New())
+	parts := strings.Split(issued.Token, ".")
+
+	// flip changes the first character of a segment
+	flip := func(segment string) string {
+		if segment[0] == 'A' {
+			return "B" + segment[1:]
+		}
+		return "A" + segment[1:]
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"uuid", uuid.NewString()},
+		{"tampered payload", strings.Join([]string{parts[0], parts[1], flip(parts[2]), parts[3]}, ".")},
+		{"tampered signature", strings.Join([]string{parts[0], parts[1], parts[2], flip(parts[3])}, ".")},
+		{"unknown key", strings.Join([]string{parts[0], "k9", parts[2], parts[3]}, ".")},
+		{"other version", strings.Join([]string{"v2", parts[1], parts[2], parts[3]}, ".")},
+		{"signed by another key", func() string {
+			forged, _ := newSigner(t, key("k1", "b")).Issue(uuid.New())
+			return forged.Token
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Verify() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	s := newSigner(t, key("k1", "a"))
+	start := time.Now()
+	s.now = func() time.Time { return start }
+	issued, _ := s.Issue(uuid.New())
+
+	s.now = func() time.Time { return start.Add(time.Hour - time.Minute) }
+	if _, err := s.Verify(issued.Token); err != nil {
+		t.Fatalf("before expiry: %v", err)
+	}
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	if _, err := s.Verify(issued.Token); !errors.Is(err, ErrExpired) {
+		t.Errorf("after expiry: err = %v, want ErrExpired", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old := newSigner(t, key("2025", "a"))
+	issued, _ := old.Issue(uuid.New())
+
+	// The new key signs; the old one is still accepted while links signed with it are live
+	rotated := newSigner(t, key("2026", "b"), key("2025", "a"))
+	if _, err := rotated.Verify(issued.Token); err != nil {
+		t.Errorf("token signed by retiring key: %v", err)
+	}
+	fresh, _ := rotated.Issue(uuid.New())
+	if claims, err := rotated.Verify(fresh.Token); err != nil || claims.KeyID != "2026" {
+		t.Errorf("new token: %+v, %v", claims, err)
+	}
+
+	// Once the old key is dropped its links stop working
+	retired := newSigner(t, key("2026", "b"))
+	if _, err := retired.Verify(issued.Token); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token signed by removed key: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestNewSignerValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []Key
+		ttl  time.Duration
+	}{
+		{"no keys", nil, time.Hour},
+		{"zero ttl", []Key{key("k1", "a")}, 0},
+		{"short key", []Key{{ID: "k1", Secret: []byte("short")}}, time.Hour},
+		{"bad id", []Key{key("k.1", "a")}, time.Hour},
+		{"duplicate id", []Key{key("k1", "a"), key("k1", "b")}, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.keys, tt.ttl); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestHashLegacy(t *testing.T) {
+	// The same value migration 006 stores for this token
+	const want = "854b25d66950ffb20971880642a9999ecfec5b94205a46ec26e6b163734819d8"
+	if got := HashLegacy("6f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"); got != want {
+		t.Errorf("HashLegacy = %s, want %s", got, want)
+	}
+}
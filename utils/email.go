@@ -1,61 +1,286 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"html"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/smtp"
-	"os"
+	"net/textproto"
+	"strings"
+
+	"github.com/g4l1l10/rsvp-backend/calendar"
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/i18n"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/metrics"
+	"github.com/g4l1l10/rsvp-backend/models"
 )
 
-// SendEmail sends a personalized wedding invitation email using Gmail SMTP with an App Password
-func SendEmail(guestName, guestEmail, rsvpToken string) error {
-	// Load Gmail SMTP settings
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-	smtpUser := os.Getenv("SMTP_USER")     // Your Gmail address
-	smtpPass := os.Getenv("SMTP_PASSWORD") // App Password
+// calendarFilename names the calendar attached to guest emails
+const calendarFilename = "wedding.ics"
+
+// attachment is a file sent along with an email
+type attachment struct {
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// calendarAttachment attaches ics, if there is one
+func calendarAttachment(ics []byte) []attachment {
+	if len(ics) == 0 {
+		return nil
+	}
+	return []attachment{{filename: calendarFilename, contentType: calendar.ContentType, data: ics}}
+}
+
+// Mailer sends guest emails through the configured SMTP server
+type Mailer struct {
+	smtp      config.SMTPConfig
+	publicURL string
+}
+
+// NewMailer initializes a mailer; RSVP links in emails point at publicURL
+func NewMailer(cfg config.SMTPConfig, publicURL string) *Mailer {
+	return &Mailer{smtp: cfg, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// SendInvitation sends a personalized wedding invitation email using Gmail SMTP with an App Password.
+// It is written in lang, or in English when lang has no template; ics, when
+// set, is attached so the guest can save the date.
+// The SMTP conversation is abandoned once ctx is cancelled or its deadline passes.
+func (m *Mailer) SendInvitation(ctx context.Context, guestName, guestEmail, rsvpToken, lang string, ics []byte) error {
+	lang = i18n.Resolve(lang)
+	body, err := renderEmail("invitation", lang, struct {
+		Name, Token, Link string
+	}{
+		Name:  guestName,
+		Token: rsvpToken,
+		// RSVP link with a path parameter instead of a query parameter
+		Link: fmt.Sprintf("%s/rsvp/%s", m.publicURL, rsvpToken),
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := i18n.T(lang, "email.invitation.subject")
+	return m.send(ctx, "invitation", []string{guestEmail}, subject, body, calendarAttachment(ics)...)
+}
+
+// SendReminder nudges a guest who has not answered yet to RSVP, in lang or in
+// English when lang has no template
+func (m *Mailer) SendReminder(ctx context.Context, guestName, guestEmail, rsvpToken, lang string) error {
+	lang = i18n.Resolve(lang)
+	body, err := renderEmail("reminder", lang, struct {
+		Name, Token, Link string
+	}{
+		Name:  guestName,
+		Token: rsvpToken,
+		Link:  fmt.Sprintf("%s/rsvp/%s", m.publicURL, rsvpToken),
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := i18n.T(lang, "email.reminder.subject")
+	return m.send(ctx, "reminder", []string{guestEmail}, subject, body)
+}
+
+// SendRSVPConfirmation emails a guest a summary of their RSVP, in their
+// language, with a link to change it; ics, when set, is attached
+func (m *Mailer) SendRSVPConfirmation(ctx context.Context, response models.RSVPResponse, changeLink string, ics []byte) error {
+	lang := i18n.Resolve(response.Language)
+	var greeting string
+	switch response.RSVPStatus {
+	case models.RSVPStatusAttending:
+		greeting = i18n.T(lang, "email.confirmation.attending")
+	case models.RSVPStatusNotAttending:
+		greeting = i18n.T(lang, "email.confirmation.not_attending")
+	default:
+		greeting = i18n.T(lang, "email.confirmation.recorded")
+	}
 
-	// Validate SMTP settings
-	if smtpUser == "" || smtpPass == "" {
-		return fmt.Errorf("❌ SMTP configuration is missing")
+	body, err := renderEmail("rsvp_confirmation", lang, struct {
+		Name, Greeting, Status, Link string
+		TotalGuests                  int
+	}{
+		Name:        response.Name,
+		Greeting:    greeting,
+		Status:      i18n.StatusLabel(lang, response.RSVPStatus),
+		Link:        changeLink,
+		TotalGuests: response.TotalGuests,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Generate RSVP Link with path parameter instead of query parameter
-	rsvpLink := fmt.Sprintf("https://axeldaphne.com/rsvp/%s", rsvpToken) // ✅ Fixed URL format
+	subject := i18n.T(lang, "email.confirmation.subject")
+	return m.send(ctx, "rsvp_confirmation", []string{response.Email}, subject, body, calendarAttachment(ics)...)
+}
+
+// SendRSVPDigest emails the couple a summary of the responses received since the last digest
+func (m *Mailer) SendRSVPDigest(ctx context.Context, recipients []string, responses []models.RSVPResponse) error {
+	var rows strings.Builder
+	for _, r := range responses {
+		answer := html.EscapeString(r.RSVPStatus)
+		if r.Changed() {
+			answer += " <em>(changed from " + html.EscapeString(r.PreviousStatus) + ")</em>"
+		}
+		fmt.Fprintf(&rows, "<tr><td>%s</td><td>%s</td><td>%s</td><td style='text-align:right;'>%d</td><td>%s</td></tr>",
+			html.EscapeString(r.Name), html.EscapeString(r.Email), answer, r.TotalGuests, r.RespondedAt.Format("Jan 2 15:04 MST"))
+	}
 
-	// Email subject and HTML body with RSVP button
-	subject := "💍 You're Invited to Axel and Daphne's Wedding Celebration!"
+	subject := fmt.Sprintf("📬 %d new RSVP response(s)", len(responses))
 	body := fmt.Sprintf(
-		"Dear %s,<br><br>"+
-			"With great joy in our hearts, we invite you to celebrate our special day with us! 💍✨<br><br>"+
-			"We would love for you to be part of our wedding, creating memories that will last a lifetime.<br><br>"+
-			"<strong>Your unique RSVP token: <span style='color:#2c3e50;'>%s</span></strong><br><br>"+ // ✅ Token included
-			"<strong style='color:red;'>⚠️ Please do not share your invite token.</strong><br><br>"+ // ✅ Privacy notice
-			"To confirm your attendance, please click the button below:<br><br>"+
-			"<a href='%s' style='display:inline-block; padding:12px 24px; font-size:16px; "+
-			"color:#fff; background-color:#3498db; text-decoration:none; border-radius:5px;'>💌 RSVP Now</a><br><br>"+
-			"We truly hope you can join us on this wonderful occasion, and we can't wait to celebrate together! 🎊<br><br>"+
-			"With love and excitement,<br>"+
-			"<strong>Axel & Daphne 💕</strong>",
-		guestName, rsvpToken, rsvpLink, // Pass the token into the email
+		"%d guest(s) responded since the last update:<br><br>"+
+			"<table cellpadding='6' style='border-collapse:collapse;'>"+
+			"<tr><th align='left'>Guest</th><th align='left'>Email</th><th align='left'>Response</th><th align='right'>Party</th><th align='left'>When</th></tr>"+
+			"%s</table>",
+		len(responses), rows.String(),
 	)
+	return m.send(ctx, "rsvp_digest", recipients, subject, body)
+}
 
-	// Format the email message with proper headers for HTML content
-	message := fmt.Sprintf("Subject: %s\nMIME-Version: 1.0\nContent-Type: text/html; charset=UTF-8\n\n%s", subject, body)
+// send delivers one HTML email to the recipients, recording the outcome under kind
+func (m *Mailer) send(ctx context.Context, kind string, to []string, subject, body string, attachments ...attachment) error {
+	if !m.smtp.Enabled() {
+		metrics.Emails.WithLabelValues(kind, "failed").Inc()
+		return errors.New("SMTP configuration is missing")
+	}
 
-	// Build Gmail SMTP server address
-	serverAddress := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+	message, err := buildMessage(subject, body, attachments)
+	if err != nil {
+		metrics.Emails.WithLabelValues(kind, "failed").Inc()
+		return err
+	}
 
-	// Set up authentication using Gmail App Password
-	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
+	// Build SMTP server address
+	serverAddress := net.JoinHostPort(m.smtp.Host, m.smtp.Port)
+
+	// Set up authentication using the App Password
+	auth := smtp.PlainAuth("", m.smtp.User, m.smtp.Password.Reveal(), m.smtp.Host)
 
 	// Send the email
-	err := smtp.SendMail(serverAddress, auth, smtpUser, []string{guestEmail}, []byte(message))
+	err = sendMail(ctx, serverAddress, m.smtp.Host, auth, m.smtp.User, to, message)
 	if err != nil {
-		log.Printf("❌ Error sending email to %s: %v", guestEmail, err)
+		metrics.Emails.WithLabelValues(kind, "failed").Inc()
+		logging.FromContext(ctx).Error("failed to send email", slog.String("kind", kind), slog.Any("to", to), slog.Any("error", err))
 		return err
 	}
 
-	log.Printf("✅ RSVP invitation email sent to %s", guestEmail)
+	metrics.Emails.WithLabelValues(kind, "sent").Inc()
+	logging.FromContext(ctx).Info("email sent", slog.String("kind", kind), slog.Any("to", to))
 	return nil
 }
+
+// buildMessage formats an HTML email. With attachments it becomes a
+// multipart/mixed message whose first part is the HTML body.
+func buildMessage(subject, body string, attachments []attachment) ([]byte, error) {
+	// Headers are ASCII; non-ASCII subjects are sent as RFC 2047 encoded words
+	subject = mime.QEncoding.Encode("UTF-8", subject)
+	if len(attachments) == 0 {
+		return []byte(fmt.Sprintf("Subject: %s\nMIME-Version: 1.0\nContent-Type: text/html; charset=UTF-8\n\n%s", subject, body)), nil
+	}
+
+	var parts bytes.Buffer
+	mw := multipart.NewWriter(&parts)
+	htmlPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=UTF-8"}})
+	if err != nil {
+		return nil, err
+	}
+	htmlPart.Write([]byte(body))
+
+	for _, a := range attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(a.contentType, map[string]string{"name": a.filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Base64 lines may be at most 76 characters long
+		encoded := base64.StdEncoding.EncodeToString(a.data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("Subject: %s\nMIME-Version: 1.0\nContent-Type: multipart/mixed; boundary=%q\n\n", subject, mw.Boundary())
+	return append([]byte(header), parts.Bytes()...), nil
+}
+
+// sendMail is smtp.SendMail with context support: the connection is dialed with
+// ctx and closed early if ctx ends before the message has been delivered.
+func sendMail(ctx context.Context, addr, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Unblock any in-flight SMTP command when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = deliver(conn, host, auth, from, to, msg)
+	if err != nil && ctx.Err() != nil {
+		// Report the cancellation rather than the resulting "use of closed connection"
+		return ctx.Err()
+	}
+	return err
+}
+
+// deliver runs the SMTP conversation for a single message over conn
+func deliver(conn net.Conn, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+		return err
+	}
+	if err := client.Auth(auth); err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+
+	"github.com/g4l1l10/rsvp-backend/i18n"
+)
+
+// templateFiles holds one HTML email body per kind and language, named
+// <kind>.<language>.html
+//
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// templates are the parsed email bodies; a broken template fails at startup
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+// renderEmail fills in the kind of email in lang, falling back to English
+// when that language has no template yet
+func renderEmail(kind, lang string, data any) (string, error) {
+	t := templates.Lookup(kind + "." + lang + ".html")
+	if t == nil {
+		t = templates.Lookup(kind + "." + i18n.Default + ".html")
+	}
+	if t == nil {
+		return "", fmt.Errorf("no %s email template", kind)
+	}
+
+	var body bytes.Buffer
+	if err := t.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to render %s email: %w", kind, err)
+	}
+	return body.String(), nil
+}
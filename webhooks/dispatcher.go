@@ -0,0 +1,285 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/logging"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// EventTypes lists the events webhooks can subscribe to
+var EventTypes = []string{events.GuestCreated, events.GuestRSVP, events.GuestDeleted}
+
+// EventTest is sent by Fire to check that an endpoint is reachable
+const EventTest = "webhook.test"
+
+const (
+	// claimBatch is how many due deliveries are attempted at once
+	claimBatch = 10
+	// maxResponseBytes is how much of a response body is read before it is discarded
+	maxResponseBytes = 64 << 10
+	userAgent        = "rsvp-backend-webhooks"
+)
+
+// Payload is the JSON body of every delivery
+type Payload struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Dispatcher records deliveries for subscribed webhooks and sends them in
+// the background, retrying failures with exponential backoff. Deliveries
+// are persisted before they are attempted, so none are lost on restart.
+type Dispatcher struct {
+	Store  repository.WebhookStore
+	Client *http.Client
+	Config config.WebhookConfig
+
+	wake chan struct{}
+	now  func() time.Time
+}
+
+// NewDispatcher initializes a dispatcher; call Run to start delivering
+func NewDispatcher(store repository.WebhookStore, cfg config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		Store:  store,
+		Client: &http.Client{Timeout: cfg.Timeout},
+		Config: cfg,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// Enqueue records a delivery of the event for every active webhook
+// subscribed to its type and wakes the background sender
+func (d *Dispatcher) Enqueue(ctx context.Context, eventType string, data any) error {
+	if !slices.Contains(EventTypes, eventType) {
+		return nil
+	}
+	webhooks, err := d.Store.GetWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(w models.Webhook) bool { return !w.Subscribes(eventType) })
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := d.now().UTC()
+	event := Payload{ID: uuid.New(), Type: eventType, CreatedAt: now, Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var errs []error
+	for _, w := range webhooks {
+		delivery := &models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     w.ID,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		if err := d.Store.CreateWebhookDelivery(ctx, delivery); err != nil && !errors.Is(err, repository.ErrWebhookNotFound) {
+			errs = append(errs, fmt.Errorf("failed to record delivery to webhook %s: %w", w.ID, err))
+		}
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default: // already woken
+	}
+	return errors.Join(errs...)
+}
+
+// Fire sends a test event to the webhook straight away, whether or not it is
+// active, and returns the logged delivery. A failed test is not retried.
+func (d *Dispatcher) Fire(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	now := d.now().UTC()
+	event := Payload{
+		ID:        uuid.New(),
+		Type:      EventTest,
+		CreatedAt: now,
+		Data:      map[string]any{"webhook_id": webhook.ID, "message": "Test event from the RSVP backend"},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: EventTest,
+		Payload:   payload,
+		Status:    models.WebhookDeliveryPending,
+		CreatedAt: now,
+	}
+	if err := d.Store.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to record test delivery: %w", err)
+	}
+	if err := d.attempt(ctx, webhook, delivery, false); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Run sends due deliveries until ctx is cancelled, checking every
+// PollInterval and whenever new deliveries are enqueued
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for d.DispatchDue(ctx) == claimBatch {
+			// A full batch may mean more are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchDue attempts one batch of due deliveries concurrently and returns
+// how many were claimed
+func (d *Dispatcher) DispatchDue(ctx context.Context) int {
+	logger := logging.FromContext(ctx)
+
+	// The lease outlasts an attempt, so a delivery is not claimed twice while in flight
+	deliveries, err := d.Store.ClaimWebhookDeliveries(ctx, d.now().UTC(), 2*d.Config.Timeout, claimBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("failed to claim webhook deliveries", slog.Any("error", err))
+		}
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			webhook, err := d.Store.GetWebhook(ctx, delivery.WebhookID)
+			if err != nil {
+				// A deleted webhook takes its deliveries with it
+				if !errors.Is(err, repository.ErrWebhookNotFound) {
+					logger.Error("failed to fetch webhook", slog.String("webhook_id", delivery.WebhookID.String()), slog.Any("error", err))
+				}
+				return
+			}
+			if err := d.attempt(ctx, webhook, delivery, true); err != nil && ctx.Err() == nil {
+				logger.Error("failed to record webhook delivery", slog.String("delivery_id", delivery.ID.String()), slog.Any("error", err))
+			}
+		}(&deliveries[i])
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+// attempt sends a delivery once and records the outcome. With retry, a
+// failure is rescheduled with backoff until MaxAttempts is reached.
+func (d *Dispatcher) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, retry bool) error {
+	if !webhook.Active && retry {
+		now := d.now().UTC()
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastAttemptAt = &now
+		delivery.LastError = "webhook is inactive"
+		return d.Store.UpdateWebhookDelivery(ctx, delivery)
+	}
+
+	status, sendErr := d.send(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down: leave the delivery to be retried once its lease expires
+		return ctx.Err()
+	}
+
+	now := d.now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.NextAttemptAt = nil
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+	case retry && delivery.Attempts < d.Config.MaxAttempts:
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+	}
+	if sendErr != nil {
+		logging.FromContext(ctx).Warn("webhook delivery failed",
+			slog.String("webhook_id", webhook.ID.String()), slog.String("delivery_id", delivery.ID.String()),
+			slog.Int("attempts", delivery.Attempts), slog.String("status", delivery.Status), slog.Any("error", sendErr))
+	}
+	return d.Store.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// send posts the signed payload and returns the response status; any status
+// outside 2xx is an error
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes)) // lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before the attempt following the given number of
+// failed attempts: InitialBackoff doubled each time, capped at MaxBackoff
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	wait := d.Config.InitialBackoff
+	for i := 1; i < failures && wait < d.Config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.Config.MaxBackoff)
+}
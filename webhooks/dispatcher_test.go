@@ -0,0 +1,185 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/g4l1l10/rsvp-backend/config"
+	"github.com/g4l1l10/rsvp-backend/events"
+	"github.com/g4l1l10/rsvp-backend/models"
+	"github.com/g4l1l10/rsvp-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+var ctx = context.Background()
+
+// newTestDispatcher returns a dispatcher on a memory store with a clock the test controls
+func newTestDispatcher(t *testing.T) (*Dispatcher, *time.Time) {
+	t.Helper()
+	cfg := config.Default().Webhooks
+	cfg.MaxAttempts = 3
+	d := NewDispatcher(repository.NewMemoryGuestRepository(), cfg)
+	now := time.Date(2026, 6, 20, 15, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+// addWebhook registers an active webhook for url
+func addWebhook(t *testing.T, d *Dispatcher, url string, eventTypes ...string) *models.Webhook {
+	t.Helper()
+	w := &models.Webhook{ID: uuid.New(), URL: url, Secret: NewSecret(), EventTypes: eventTypes, Active: true}
+	if err := d.Store.CreateWebhook(ctx, w); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	return w
+}
+
+func deliveries(t *testing.T, d *Dispatcher, w *models.Webhook) []models.WebhookDelivery {
+	t.Helper()
+	log, err := d.Store.GetWebhookDeliveries(ctx, w.ID, 100)
+	if err != nil {
+		t.Fatalf("GetWebhookDeliveries: %v", err)
+	}
+	return log
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"type":"guest.rsvp"}`)
+	at := time.Unix(1750000000, 0)
+	signature := Sign("whsec_test", at, body)
+
+	if !Verify("whsec_test", "1750000000", signature, body) {
+		t.Error("Verify rejected a valid signature")
+	}
+	if Verify("whsec_other", "1750000000", signature, body) {
+		t.Error("Verify accepted the wrong secret")
+	}
+	if Verify("whsec_test", "1750000001", signature, body) {
+		t.Error("Verify accepted a different timestamp")
+	}
+	if Verify("whsec_test", "1750000000", signature, []byte(`{"type":"guest.deleted"}`)) {
+		t.Error("Verify accepted a tampered body")
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+	var received atomic.Int32
+	var subscriber *models.Webhook
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(subscriber.Secret, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body) {
+			t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != events.GuestRSVP || r.Header.Get(DeliveryHeader) == "" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		received.Add(1)
+	}))
+	defer receiver.Close()
+
+	subscriber = addWebhook(t, d, receiver.URL, events.GuestRSVP)
+	other := addWebhook(t, d, receiver.URL, events.GuestCreated)
+	inactive := addWebhook(t, d, receiver.URL, events.GuestRSVP)
+	inactive.Active = false
+	d.Store.UpdateWebhook(ctx, inactive)
+
+	if err := d.Enqueue(ctx, events.GuestRSVP, map[string]string{"rsvp_status": "Attending"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := d.Enqueue(ctx, events.GuestUpdated, nil); err != nil {
+		t.Fatalf("Enqueue of an event webhooks cannot subscribe to: %v", err)
+	}
+	if claimed := d.DispatchDue(ctx); claimed != 1 || received.Load() != 1 {
+		t.Fatalf("claimed %d deliveries, endpoint received %d; want 1 and 1", claimed, received.Load())
+	}
+
+	log := deliveries(t, d, subscriber)
+	if len(log) != 1 || log[0].Status != models.WebhookDeliverySucceeded || log[0].Attempts != 1 || log[0].ResponseStatus != http.StatusOK {
+		t.Errorf("delivery log = %+v", log)
+	}
+	if n := len(deliveries(t, d, other)) + len(deliveries(t, d, inactive)); n != 0 {
+		t.Errorf("%d deliveries to webhooks not subscribed", n)
+	}
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	d, now := newTestDispatcher(t)
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+	webhook := addWebhook(t, d, receiver.URL, events.GuestDeleted)
+
+	if err := d.Enqueue(ctx, events.GuestDeleted, nil); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	start := *now
+	for attempt, wait := range []time.Duration{0, 30 * time.Second, time.Minute} {
+		*now = now.Add(wait - time.Second)
+		if claimed := d.DispatchDue(ctx); claimed != 0 {
+			t.Fatalf("attempt %d was made %s early", attempt+1, time.Second)
+		}
+		*now = now.Add(time.Second)
+		if claimed := d.DispatchDue(ctx); claimed != 1 {
+			t.Fatalf("attempt %d: claimed %d deliveries, want 1", attempt+1, claimed)
+		}
+	}
+
+	log := deliveries(t, d, webhook)
+	if len(log) != 1 || log[0].Status != models.WebhookDeliveryFailed || log[0].Attempts != 3 || received.Load() != 3 {
+		t.Fatalf("delivery log = %+v after %d requests", log, received.Load())
+	}
+	if log[0].ResponseStatus != http.StatusServiceUnavailable || log[0].LastError == "" || !log[0].LastAttemptAt.Equal(start.Add(90*time.Second)) {
+		t.Errorf("failed delivery = %+v", log[0])
+	}
+
+	*now = now.Add(24 * time.Hour)
+	if claimed := d.DispatchDue(ctx); claimed != 0 {
+		t.Errorf("failed delivery was attempted again")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+	d.Config.InitialBackoff = time.Second
+	d.Config.MaxBackoff = 10 * time.Second
+
+	for failures, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 60: 10 * time.Second} {
+		if got := d.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestFire(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(EventHeader) != EventTest {
+			t.Errorf("event header = %q", r.Header.Get(EventHeader))
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	webhook := addWebhook(t, d, receiver.URL, events.GuestRSVP)
+	webhook.Active = false // tests reach inactive webhooks too
+
+	delivery, err := d.Fire(ctx, webhook)
+	if err != nil {
+		t.Fatalf("Fire: %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.ResponseStatus != http.StatusInternalServerError {
+		t.Errorf("test delivery = %+v", delivery)
+	}
+	if log := deliveries(t, d, webhook); len(log) != 1 || log[0].Status != models.WebhookDeliveryFailed {
+		t.Errorf("delivery log = %+v", log)
+	}
+}